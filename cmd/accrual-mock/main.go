@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/aifedorov/gophermart/internal/client/accrual"
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"go.uber.org/zap"
+)
+
+func main() {
+	var listenAddress, configPath, logLevel string
+
+	flag.StringVar(&listenAddress, "a", envOrDefault("RUN_ADDRESS", "localhost:8084"), "address and port to run mock accrual server")
+	flag.StringVar(&configPath, "c", os.Getenv("ACCRUAL_MOCK_CONFIG"), "path to YAML or JSON file with mock rules")
+	flag.StringVar(&logLevel, "l", envOrDefault("LOG_LEVEL", "info"), "log level")
+	flag.Parse()
+
+	if err := logger.Initialize(logLevel); err != nil {
+		log.Fatal(err)
+	}
+	defer func() {
+		_ = logger.Log.Sync()
+	}()
+
+	cfg := defaultConfig()
+	if configPath != "" {
+		var err error
+		cfg, err = accrual.LoadMockConfig(configPath)
+		if err != nil {
+			logger.Log.Fatal("accrualmock: failed to load config", zap.Error(err))
+		}
+	}
+
+	logger.Log.Info("accrualmock: running on",
+		zap.String("address", listenAddress), zap.Int("rules", len(cfg.Rules)), zap.Int("rateLimit", cfg.RateLimit))
+	if err := http.ListenAndServe(listenAddress, accrual.NewMockHandler(cfg)); err != nil {
+		logger.Log.Fatal("accrualmock: failed to run", zap.Error(err))
+	}
+}
+
+// defaultConfig accrues a fixed amount for every order when no rules file is given.
+func defaultConfig() accrual.MockConfig {
+	amount := 100.0
+	return accrual.MockConfig{
+		Rules: []accrual.MockRule{
+			{Status: accrual.StatusProcessed, Accrual: &amount},
+		},
+	}
+}
+
+func envOrDefault(key, value string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return value
+}
@@ -3,16 +3,24 @@ package main
 import (
 	"context"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/aifedorov/gophermart/internal/client/accrual"
 	orderDomain "github.com/aifedorov/gophermart/internal/order/domain"
 	orderRepository "github.com/aifedorov/gophermart/internal/order/repository/db"
 	"github.com/aifedorov/gophermart/internal/pkg/config"
+	"github.com/aifedorov/gophermart/internal/pkg/health"
+	"github.com/aifedorov/gophermart/internal/pkg/idempotency"
 	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/aifedorov/gophermart/internal/pkg/metrics"
 	"github.com/aifedorov/gophermart/internal/pkg/posgre"
+	"github.com/aifedorov/gophermart/internal/pkg/tracing"
 	"github.com/aifedorov/gophermart/internal/server"
 	userDomain "github.com/aifedorov/gophermart/internal/user/domain"
 	userRepository "github.com/aifedorov/gophermart/internal/user/repository/db"
+	"github.com/aifedorov/gophermart/migrations"
 	"go.uber.org/zap"
 )
 
@@ -31,41 +39,168 @@ func main() {
 	}()
 
 	ctx := context.Background()
+
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		Exporter: cfg.TracingExporter,
+		File:     cfg.TracingFile,
+	})
+	if err != nil {
+		logger.Log.Fatal("failed to set up tracing", zap.Error(err))
+	}
+	signalCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	db := posgre.NewPosgresRepository(ctx, cfg.StorageDSN)
 	err = db.Open()
-	defer db.Close()
-
 	if err != nil {
 		logger.Log.Fatal("failed to connect to database", zap.Error(err))
 	}
 
-	userRepo := userRepository.NewRepository(ctx, db.DBPool())
-	userService := userDomain.NewService(userRepo)
+	metrics.RegisterPgxPool(db.DBPool())
 
 	accrualClient := accrual.NewHTTPClient(cfg)
-	defer func() {
-		err := accrualClient.Close()
+
+	orderRepo := orderRepository.NewRepository(db.DBPool(), orderRepository.Config{
+		PointsTTL:          cfg.PointsTTL,
+		ExpiringSoonWindow: cfg.PointsExpiringSoonWindow,
+		ReferralRewards: orderRepository.ReferralRewards{
+			ReferrerBonus:  cfg.ReferrerBonus,
+			ReferredBonus:  cfg.ReferredBonus,
+			MaxPerReferrer: cfg.ReferralMaxRewards,
+			MinAccrual:     cfg.ReferralMinAccrual,
+		},
+	})
+
+	// The order repository writes the referral in the transaction that creates the referred user.
+	userRepo := userRepository.NewRepository(db.DBPool(), orderRepo)
+	userService := userDomain.NewService(userRepo)
+
+	tiers, err := orderDomain.ParseTiers(cfg.LoyaltyTiers)
+	if err != nil {
+		logger.Log.Fatal("failed to parse loyalty tiers", zap.Error(err))
+	}
+	tierPolicy := orderDomain.TierPolicy{
+		Window: cfg.TierWindow,
+		Tiers:  tiers,
+	}
+	orderService := orderDomain.NewService(orderRepo, orderDomain.Config{
+		Tiers:              tierPolicy,
+		TransferDailyLimit: cfg.TransferDailyLimit,
+	})
+
+	rateLimiter := orderDomain.NewRateLimiter()
+	poller := orderDomain.NewPoller(orderRepo, accrualClient, rateLimiter)
+	pool := orderDomain.NewWorkerPool(ctx, cfg.AccrualWorkers, cfg.AccrualQueueSize, cfg.AccrualOrderTimeout)
+	checker := orderDomain.NewChecker(signalCtx, orderRepo, poller, pool, orderDomain.CheckerConfig{
+		JobLease: cfg.AccrualJobLease,
+		Retry: orderDomain.RetryPolicy{
+			BaseDelay: cfg.AccrualRetryBaseDelay,
+			MaxDelay:  cfg.AccrualRetryMaxDelay,
+			MaxAge:    cfg.AccrualOrderMaxAge,
+		},
+	})
+	metrics.RegisterGaugeFunc("accrual", "queue_length", "Accrual jobs waiting for a free worker.", func() float64 {
+		return float64(pool.Stats().QueueLen)
+	})
+	metrics.RegisterGaugeFunc("accrual", "busy_workers", "Workers currently polling the accrual system.", func() float64 {
+		return float64(pool.Stats().BusyWorkers)
+	})
+
+	checkerDone := make(chan struct{})
+	go func() {
+		defer close(checkerDone)
+		err := checker.Run()
 		if err != nil {
-			logger.Log.Error("accrualclient: error closing http client", zap.Error(err))
+			logger.Log.Error("checker: error running checker", zap.Error(err))
 		}
 	}()
 
-	orderRepo := orderRepository.NewRepository(ctx, db.DBPool())
-	orderService := orderDomain.NewService(orderRepo)
+	holdExpirer := orderDomain.NewHoldExpirer(signalCtx, orderRepo, cfg.HoldExpiryInterval)
+	holdExpirerDone := make(chan struct{})
+	go func() {
+		defer close(holdExpirerDone)
+		err := holdExpirer.Run()
+		if err != nil {
+			logger.Log.Error("holdexpirer: error running hold expirer", zap.Error(err))
+		}
+	}()
 
-	poller := orderDomain.NewPoller(ctx, orderRepo, accrualClient)
-	checker := orderDomain.NewChecker(ctx, orderRepo, poller)
+	pointExpirer := orderDomain.NewPointExpirer(signalCtx, orderRepo, cfg.PointsExpiryInterval)
+	pointExpirerDone := make(chan struct{})
 	go func() {
-		err := checker.Run()
+		defer close(pointExpirerDone)
+		err := pointExpirer.Run()
+		if err != nil {
+			logger.Log.Error("pointexpirer: error running point expirer", zap.Error(err))
+		}
+	}()
+
+	tierRecalculator := orderDomain.NewTierRecalculator(signalCtx, orderRepo, tierPolicy, cfg.TierRecalcInterval)
+	tierRecalculatorDone := make(chan struct{})
+	go func() {
+		defer close(tierRecalculatorDone)
+		err := tierRecalculator.Run()
 		if err != nil {
-			logger.Log.Fatal("checker: error running checker", zap.Error(err))
+			logger.Log.Error("tierrecalculator: error running tier recalculator", zap.Error(err))
 		}
 	}()
 
-	s := server.NewServer(cfg, userService, orderService)
-	if err := s.Run(); err != nil {
-		logger.Log.Fatal("server: failed to run", zap.Error(err))
+	schemaVersion, err := migrations.LatestVersion()
+	if err != nil {
+		logger.Log.Fatal("failed to read embedded migrations", zap.Error(err))
+	}
+	healthChecks := []health.Check{
+		health.NewPostgresCheck(db),
+		health.NewMigrationCheck(db, schemaVersion),
+		health.NewAccrualCheck(accrualClient),
+	}
+
+	idempotencyStore := idempotency.NewPostgresStore(db.DBPool())
+
+	s := server.NewServer(cfg, userService, orderService, healthChecks, idempotencyStore)
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- s.Run()
+	}()
+
+	select {
+	case <-signalCtx.Done():
+		logger.Log.Info("app: shutdown signal received")
+	case err := <-serverErr:
+		if err != nil {
+			logger.Log.Error("server: failed to run", zap.Error(err))
+		}
+	}
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, cfg.ShutdownTimeout)
+	defer cancel()
+
+	err = s.Shutdown(shutdownCtx)
+	if err != nil {
+		logger.Log.Error("server: failed to drain in-flight requests", zap.Error(err))
+	}
+
+	<-checkerDone
+	<-holdExpirerDone
+	<-pointExpirerDone
+	<-tierRecalculatorDone
+	err = pool.Shutdown(shutdownCtx)
+	if err != nil {
+		logger.Log.Error("checker: failed to drain accrual jobs", zap.Error(err))
+	}
+
+	db.Close()
+
+	err = accrualClient.Close()
+	if err != nil {
+		logger.Log.Error("accrualclient: error closing http client", zap.Error(err))
+	}
+
+	err = shutdownTracing(shutdownCtx)
+	if err != nil {
+		logger.Log.Error("tracing: failed to flush spans", zap.Error(err))
 	}
 
-	// TODO: Handle shoutdown
+	logger.Log.Info("app: stopped")
 }
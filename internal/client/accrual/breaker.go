@@ -0,0 +1,162 @@
+package accrual
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"go.uber.org/zap"
+)
+
+var ErrCircuitOpen = errors.New("accrualclient: circuit breaker is open")
+
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerHalfOpen
+	BreakerOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerHalfOpen:
+		return "half-open"
+	case BreakerOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the breaker.
+	FailureThreshold int
+	// OpenTimeout is how long the breaker stays open before letting trial requests through.
+	OpenTimeout time.Duration
+	// HalfOpenRequests is the number of successful trial requests required to close the breaker.
+	HalfOpenRequests int
+	// OnStateChange is called on every state change, e.g. to export it as a metric.
+	OnStateChange func(from, to BreakerState)
+}
+
+type circuitBreaker struct {
+	cfg BreakerConfig
+
+	mu        sync.Mutex
+	state     BreakerState
+	failures  int
+	successes int
+	inFlight  int
+	openedAt  time.Time
+	now       func() time.Time
+}
+
+func newCircuitBreaker(cfg BreakerConfig) *circuitBreaker {
+	if cfg.FailureThreshold < 1 {
+		cfg.FailureThreshold = 1
+	}
+	if cfg.HalfOpenRequests < 1 {
+		cfg.HalfOpenRequests = 1
+	}
+	return &circuitBreaker{
+		cfg: cfg,
+		now: time.Now,
+	}
+}
+
+func (b *circuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.currentState()
+}
+
+// Allow reports whether a request may be sent. Every allowed request must be
+// followed by either Success or Failure.
+func (b *circuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.currentState() {
+	case BreakerOpen:
+		return ErrCircuitOpen
+	case BreakerHalfOpen:
+		if b.inFlight >= b.cfg.HalfOpenRequests {
+			return ErrCircuitOpen
+		}
+	}
+	b.inFlight++
+	return nil
+}
+
+func (b *circuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.inFlight--
+	b.failures = 0
+	if b.state == BreakerHalfOpen {
+		b.successes++
+		if b.successes >= b.cfg.HalfOpenRequests {
+			b.setState(BreakerClosed)
+		}
+	}
+}
+
+func (b *circuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.inFlight--
+	switch b.state {
+	case BreakerHalfOpen:
+		b.open()
+	case BreakerClosed:
+		b.failures++
+		if b.failures >= b.cfg.FailureThreshold {
+			b.open()
+		}
+	}
+}
+
+// Release finishes an allowed request without counting it as a success or a failure.
+func (b *circuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.inFlight--
+}
+
+// currentState moves an open breaker to half-open once the open timeout has passed.
+func (b *circuitBreaker) currentState() BreakerState {
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.cfg.OpenTimeout {
+		b.setState(BreakerHalfOpen)
+	}
+	return b.state
+}
+
+func (b *circuitBreaker) open() {
+	b.openedAt = b.now()
+	b.setState(BreakerOpen)
+}
+
+func (b *circuitBreaker) setState(state BreakerState) {
+	if b.state == state {
+		return
+	}
+
+	from := b.state
+	b.state = state
+	b.failures = 0
+	b.successes = 0
+
+	logger.Log.Warn("accrualclient: circuit breaker state changed",
+		zap.Stringer("from", from), zap.Stringer("to", state))
+	if b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(from, state)
+	}
+}
@@ -0,0 +1,81 @@
+package accrual
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	var transitions []BreakerState
+
+	b := newCircuitBreaker(BreakerConfig{
+		FailureThreshold: 2,
+		OpenTimeout:      time.Minute,
+		HalfOpenRequests: 1,
+		OnStateChange: func(from, to BreakerState) {
+			transitions = append(transitions, to)
+		},
+	})
+	b.now = func() time.Time { return now }
+
+	// A success resets the consecutive failure counter.
+	require.NoError(t, b.Allow())
+	b.Failure()
+	require.NoError(t, b.Allow())
+	b.Success()
+	require.NoError(t, b.Allow())
+	b.Failure()
+	assert.Equal(t, BreakerClosed, b.State())
+
+	require.NoError(t, b.Allow())
+	b.Failure()
+	assert.Equal(t, BreakerOpen, b.State())
+	assert.ErrorIs(t, b.Allow(), ErrCircuitOpen)
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, BreakerHalfOpen, b.State())
+
+	// Only one trial request is allowed while half-open, and its failure reopens the breaker.
+	require.NoError(t, b.Allow())
+	assert.ErrorIs(t, b.Allow(), ErrCircuitOpen)
+	b.Failure()
+	assert.Equal(t, BreakerOpen, b.State())
+
+	now = now.Add(time.Minute)
+	require.NoError(t, b.Allow())
+	b.Success()
+	assert.Equal(t, BreakerClosed, b.State())
+
+	assert.Equal(t, []BreakerState{BreakerOpen, BreakerHalfOpen, BreakerOpen, BreakerHalfOpen, BreakerClosed}, transitions)
+}
+
+func TestHTTPClientCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	srv := NewMockServer(MockConfig{
+		Rules: []MockRule{{Code: 500}},
+	})
+	defer srv.Close()
+
+	client := NewHTTPClient(testConfig(srv.URL))
+	defer func() {
+		_ = client.Close()
+	}()
+
+	for i := 0; i < testConfig(srv.URL).AccrualBreakerFailures; i++ {
+		res, err := client.GetAccrualByOrderNumber(context.Background(), "2377225624")
+		require.NoError(t, err)
+		assert.Equal(t, OutcomeServerError, res.Outcome)
+	}
+	assert.Equal(t, BreakerOpen, client.BreakerState())
+
+	_, err := client.GetAccrualByOrderNumber(context.Background(), "2377225624")
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+}
@@ -0,0 +1,206 @@
+package accrual
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aifedorov/gophermart/internal/pkg/config"
+	"github.com/aifedorov/gophermart/internal/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAccrualByOrderNumber(t *testing.T) {
+	t.Parallel()
+
+	type want struct {
+		outcome    Outcome
+		status     Status
+		retryAfter time.Duration
+		err        bool
+	}
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    want
+	}{
+		{
+			name: "processed order",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("Content-Type", "application/json")
+				_, _ = rw.Write([]byte(`{"order":"2377225624","status":"PROCESSED","accrual":500}`))
+			},
+			want: want{
+				outcome: OutcomeOK,
+				status:  StatusProcessed,
+			},
+		},
+		{
+			name: "order not registered",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(http.StatusNoContent)
+			},
+			want: want{
+				outcome: OutcomeNotRegistered,
+			},
+		},
+		{
+			name: "accrual system failure",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(http.StatusInternalServerError)
+			},
+			want: want{
+				outcome: OutcomeServerError,
+			},
+		},
+		{
+			name: "too many requests with retry after",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("Retry-After", "15")
+				http.Error(rw, "No more than 10 requests per minute allowed", http.StatusTooManyRequests)
+			},
+			want: want{
+				outcome:    OutcomeThrottled,
+				retryAfter: 15 * time.Second,
+			},
+		},
+		{
+			name: "too many requests without retry after",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				http.Error(rw, "No more than 10 requests per minute allowed", http.StatusTooManyRequests)
+			},
+			want: want{
+				outcome:    OutcomeThrottled,
+				retryAfter: defaultRetryAfter,
+			},
+		},
+		{
+			name: "unexpected status",
+			handler: func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(http.StatusNotFound)
+			},
+			want: want{
+				err: true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+
+			client := NewHTTPClient(testConfig(srv.URL))
+			defer func() {
+				_ = client.Close()
+			}()
+
+			res, err := client.GetAccrualByOrderNumber(context.Background(), "2377225624")
+			if tt.want.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want.outcome, res.Outcome)
+			assert.Equal(t, tt.want.status, res.Order.Status)
+			assert.Equal(t, tt.want.retryAfter, res.RetryAfter)
+		})
+	}
+}
+
+func TestGetAccrualByOrderNumberCancelled(t *testing.T) {
+	t.Parallel()
+
+	srv := NewMockServer(MockConfig{
+		Rules: []MockRule{{Latency: time.Minute}},
+	})
+	defer srv.Close()
+
+	client := NewHTTPClient(testConfig(srv.URL))
+	defer func() {
+		_ = client.Close()
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err := client.GetAccrualByOrderNumber(ctx, "2377225624")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, BreakerClosed, client.BreakerState())
+}
+
+// TestBreakerStateMetrics is not parallel: the metrics are global and other tests open breakers too.
+func TestBreakerStateMetrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	client := NewHTTPClient(testConfig(srv.URL))
+	defer func() {
+		_ = client.Close()
+	}()
+
+	opened := metrics.AccrualBreakerStateChanges.WithLabelValues(BreakerClosed.String(), BreakerOpen.String())
+	before := testutil.ToFloat64(opened)
+
+	for i := 0; i < testConfig(srv.URL).AccrualBreakerFailures; i++ {
+		_, err := client.GetAccrualByOrderNumber(context.Background(), "2377225624")
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, BreakerOpen, client.BreakerState())
+	assert.Equal(t, before+1, testutil.ToFloat64(opened))
+	assert.Equal(t, float64(BreakerOpen), testutil.ToFloat64(metrics.AccrualBreakerState))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "seconds", value: "60", want: 60 * time.Second},
+		{name: "http date", value: now.Add(30 * time.Second).Format(http.TimeFormat), want: 30 * time.Second},
+		{name: "zero seconds", value: "0", want: minRetryAfter},
+		{name: "date in the past", value: now.Add(-time.Minute).Format(http.TimeFormat), want: minRetryAfter},
+		{name: "current date", value: now.Format(http.TimeFormat), want: minRetryAfter},
+		{name: "empty", value: "", want: defaultRetryAfter},
+		{name: "garbage", value: "soon", want: defaultRetryAfter},
+		{name: "negative", value: "-5", want: defaultRetryAfter},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, parseRetryAfter(tt.value, now))
+		})
+	}
+}
+
+func testConfig(address string) config.Config {
+	return config.Config{
+		AccrualSystemAddress:           address,
+		AccrualConnectTimeout:          time.Second,
+		AccrualReadTimeout:             time.Second,
+		AccrualBreakerFailures:         3,
+		AccrualBreakerOpenTimeout:      time.Minute,
+		AccrualBreakerHalfOpenRequests: 1,
+	}
+}
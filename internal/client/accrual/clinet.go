@@ -1,47 +1,133 @@
 package accrual
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
 	"github.com/aifedorov/gophermart/internal/pkg/config"
 	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/aifedorov/gophermart/internal/pkg/metrics"
+	"github.com/aifedorov/gophermart/internal/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
 	"go.uber.org/zap"
 	"resty.dev/v3"
 )
 
 type HTTPClient interface {
-	GetAccrualByOrderNumber(orderNumber string) (OrderResponse, bool, error)
+	GetAccrualByOrderNumber(ctx context.Context, orderNumber string) (Result, error)
+	BreakerState() BreakerState
 	Close() error
 }
 
 type httpClient struct {
 	client        *resty.Client
+	breaker       *circuitBreaker
 	ListenAddress string
 }
 
 func NewHTTPClient(cfg config.Config) HTTPClient {
+	client := resty.NewWithTransportSettings(&resty.TransportSettings{
+		DialerTimeout:         cfg.AccrualConnectTimeout,
+		ResponseHeaderTimeout: cfg.AccrualReadTimeout,
+	})
+	if cfg.AccrualConnectTimeout > 0 || cfg.AccrualReadTimeout > 0 {
+		client.SetTimeout(cfg.AccrualConnectTimeout + cfg.AccrualReadTimeout)
+	}
+
 	return &httpClient{
-		client:        resty.New(),
+		client: client,
+		breaker: newCircuitBreaker(BreakerConfig{
+			FailureThreshold: cfg.AccrualBreakerFailures,
+			OpenTimeout:      cfg.AccrualBreakerOpenTimeout,
+			HalfOpenRequests: cfg.AccrualBreakerHalfOpenRequests,
+			OnStateChange:    recordBreakerStateChange,
+		}),
 		ListenAddress: cfg.AccrualSystemAddress,
 	}
 }
 
+// recordBreakerStateChange exports every transition, so flaps between scrapes are not lost.
+func recordBreakerStateChange(from, to BreakerState) {
+	metrics.AccrualBreakerStateChanges.WithLabelValues(from.String(), to.String()).Inc()
+	metrics.AccrualBreakerState.Set(float64(to))
+}
+
 func (c *httpClient) Close() error {
 	return c.client.Close()
 }
 
-func (c *httpClient) GetAccrualByOrderNumber(orderNumber string) (OrderResponse, bool, error) {
+func (c *httpClient) BreakerState() BreakerState {
+	return c.breaker.State()
+}
+
+// GetAccrualByOrderNumber returns an error only if the request did not produce a meaningful
+// response: the breaker is open, the transport failed or the status code is unexpected.
+func (c *httpClient) GetAccrualByOrderNumber(ctx context.Context, orderNumber string) (result Result, err error) {
+	ctx, span := tracing.Start(ctx, "accrual GET /api/orders/{number}", tracing.OrderNumber(orderNumber))
+	defer func() {
+		if result.StatusCode != 0 {
+			span.SetAttributes(semconv.HTTPResponseStatusCode(result.StatusCode))
+		}
+		if result.Outcome == OutcomeServerError {
+			span.SetStatus(codes.Error, http.StatusText(result.StatusCode))
+		}
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	if err := c.breaker.Allow(); err != nil {
+		return Result{}, err
+	}
+
+	header := http.Header{}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+
 	res, err := c.client.R().
+		SetContext(ctx).
 		SetResult(&OrderResponse{}).
 		SetHeader("Accept", "application/json").
+		SetHeaderMultiValues(header).
 		Get(c.ListenAddress + "/api/orders/" + orderNumber)
 
+	if errors.Is(err, context.Canceled) {
+		// Cancellation says nothing about the accrual system health.
+		c.breaker.Release()
+		return Result{}, err
+	}
 	if err != nil {
+		c.breaker.Failure()
 		logger.Log.Error("accrualclient: order processing failed", zap.Error(err))
-		return OrderResponse{}, false, err
+		return Result{}, err
 	}
-	if res.StatusCode() < 200 || res.StatusCode() > 299 {
-		logger.Log.Error("accrualclient: order processing failed", zap.Int("status", res.StatusCode()))
-		return OrderResponse{}, false, nil
+
+	statusCode := res.StatusCode()
+	if statusCode >= http.StatusInternalServerError {
+		c.breaker.Failure()
+	} else {
+		c.breaker.Success()
+	}
+
+	switch {
+	case statusCode == http.StatusOK:
+		result := res.Result().(*OrderResponse)
+		return Result{Outcome: OutcomeOK, StatusCode: statusCode, Order: *result}, nil
+	case statusCode == http.StatusNoContent:
+		return Result{Outcome: OutcomeNotRegistered, StatusCode: statusCode}, nil
+	case statusCode == http.StatusTooManyRequests:
+		retryAfter := parseRetryAfter(res.Header().Get("Retry-After"), time.Now())
+		logger.Log.Warn("accrualclient: too many requests", zap.Duration("retry_after", retryAfter))
+		return Result{Outcome: OutcomeThrottled, StatusCode: statusCode, RetryAfter: retryAfter}, nil
+	case statusCode >= http.StatusInternalServerError:
+		logger.Log.Error("accrualclient: order processing failed", zap.Int("status", statusCode))
+		return Result{Outcome: OutcomeServerError, StatusCode: statusCode}, nil
+	default:
+		logger.Log.Error("accrualclient: unexpected response status", zap.Int("status", statusCode))
+		return Result{}, fmt.Errorf("accrualclient: unexpected response status %d", statusCode)
 	}
-	result := res.Result().(*OrderResponse)
-	return *result, true, nil
 }
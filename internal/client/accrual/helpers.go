@@ -0,0 +1,37 @@
+package accrual
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRetryAfter = 60 * time.Second
+	// minRetryAfter keeps a throttled caller from retrying at once when the header says
+	// "0" or a date that has already passed.
+	minRetryAfter = time.Second
+)
+
+// parseRetryAfter supports both forms of the Retry-After header: delay in seconds and HTTP-date.
+// The delay is never shorter than minRetryAfter.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return defaultRetryAfter
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return defaultRetryAfter
+		}
+		return max(time.Duration(seconds)*time.Second, minRetryAfter)
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), minRetryAfter)
+	}
+
+	return defaultRetryAfter
+}
@@ -0,0 +1,195 @@
+package accrual
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultMockRetryAfter = 60 * time.Second
+
+// MockRule describes how the mock accrual server answers for orders whose number starts with Prefix.
+// An empty prefix matches every order.
+type MockRule struct {
+	Prefix string `yaml:"prefix"`
+	// Code forces the HTTP status code of the response, e.g. 204, 429 or 500.
+	// Zero means 200 with the accrual body.
+	Code    int      `yaml:"code"`
+	Status  Status   `yaml:"status"`
+	Accrual *float64 `yaml:"accrual"`
+	// PendingPolls is the number of requests answered with PROCESSING before Status is returned.
+	PendingPolls int           `yaml:"pending_polls"`
+	Latency      time.Duration `yaml:"latency"`
+	// ErrorRate is the probability in [0, 1] of answering with 500 Internal Server Error.
+	ErrorRate  float64       `yaml:"error_rate"`
+	RetryAfter time.Duration `yaml:"retry_after"`
+}
+
+type MockConfig struct {
+	Rules []MockRule `yaml:"rules"`
+	// RateLimit is the number of requests per minute after which the server answers with 429.
+	// Zero disables the limit.
+	RateLimit int `yaml:"rate_limit"`
+}
+
+// LoadMockConfig reads mock rules from a YAML or JSON file.
+func LoadMockConfig(path string) (MockConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return MockConfig{}, fmt.Errorf("accrualmock: failed to read config: %w", err)
+	}
+
+	// JSON is a subset of YAML, so a single decoder handles both formats.
+	var cfg MockConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return MockConfig{}, fmt.Errorf("accrualmock: failed to parse config: %w", err)
+	}
+	return cfg, nil
+}
+
+type mockServer struct {
+	cfg MockConfig
+
+	mu          sync.Mutex
+	polls       map[string]int
+	windowStart time.Time
+	windowCount int
+}
+
+// NewMockHandler returns an http.Handler that implements GET /api/orders/{number} of the accrual system.
+func NewMockHandler(cfg MockConfig) http.Handler {
+	s := &mockServer{
+		cfg:   cfg,
+		polls: make(map[string]int),
+	}
+
+	r := chi.NewRouter()
+	r.Get("/api/orders/{number}", s.getOrder)
+	return r
+}
+
+// NewMockServer starts an httptest.Server with the mock accrual handler. The caller must close it.
+func NewMockServer(cfg MockConfig) *httptest.Server {
+	return httptest.NewServer(NewMockHandler(cfg))
+}
+
+func (s *mockServer) getOrder(rw http.ResponseWriter, req *http.Request) {
+	number := chi.URLParam(req, "number")
+
+	if retryAfter, limited := s.throttle(time.Now()); limited {
+		writeTooManyRequests(rw, retryAfter, s.cfg.RateLimit)
+		return
+	}
+
+	rule, ok := s.match(number)
+	if !ok {
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if rule.Latency > 0 {
+		select {
+		case <-req.Context().Done():
+			return
+		case <-time.After(rule.Latency):
+		}
+	}
+
+	if rule.ErrorRate > 0 && rand.Float64() < rule.ErrorRate {
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	switch rule.Code {
+	case 0, http.StatusOK:
+	case http.StatusTooManyRequests:
+		retryAfter := rule.RetryAfter
+		if retryAfter == 0 {
+			retryAfter = defaultMockRetryAfter
+		}
+		writeTooManyRequests(rw, retryAfter, s.cfg.RateLimit)
+		return
+	case http.StatusNoContent:
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		http.Error(rw, http.StatusText(rule.Code), rule.Code)
+		return
+	}
+
+	resp := OrderResponse{
+		Number: number,
+		Status: rule.Status,
+	}
+	if resp.Status == "" {
+		resp.Status = StatusProcessed
+	}
+	if s.poll(number) <= rule.PendingPolls {
+		resp.Status = StatusProcessing
+	}
+	if resp.Status == StatusProcessed {
+		resp.Amount = rule.Accrual
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(rw).Encode(resp)
+}
+
+func (s *mockServer) match(number string) (MockRule, bool) {
+	for _, rule := range s.cfg.Rules {
+		if strings.HasPrefix(number, rule.Prefix) {
+			return rule, true
+		}
+	}
+	return MockRule{}, false
+}
+
+func (s *mockServer) poll(number string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.polls[number]++
+	return s.polls[number]
+}
+
+// throttle implements a fixed one-minute window like the real accrual system does.
+func (s *mockServer) throttle(now time.Time) (time.Duration, bool) {
+	if s.cfg.RateLimit <= 0 {
+		return 0, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.windowStart) >= time.Minute {
+		s.windowStart = now
+		s.windowCount = 0
+	}
+	s.windowCount++
+	if s.windowCount <= s.cfg.RateLimit {
+		return 0, false
+	}
+	return s.windowStart.Add(time.Minute).Sub(now), true
+}
+
+func writeTooManyRequests(rw http.ResponseWriter, retryAfter time.Duration, limit int) {
+	seconds := int(retryAfter.Round(time.Second) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	rw.Header().Set("Content-Type", "text/plain")
+	rw.Header().Set("Retry-After", strconv.Itoa(seconds))
+	rw.WriteHeader(http.StatusTooManyRequests)
+	_, _ = fmt.Fprintf(rw, "No more than %d requests per minute allowed", limit)
+}
@@ -0,0 +1,147 @@
+package accrual
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockServer(t *testing.T) {
+	t.Parallel()
+
+	amount := 500.0
+	srv := NewMockServer(MockConfig{
+		Rules: []MockRule{
+			{Prefix: "1", Status: StatusProcessed, Accrual: &amount, PendingPolls: 1},
+			{Prefix: "2", Status: StatusInvalid},
+			{Prefix: "3", Code: http.StatusTooManyRequests, RetryAfter: 5 * time.Second},
+			{Prefix: "4", Code: http.StatusInternalServerError},
+			{Prefix: "5", ErrorRate: 1},
+		},
+	})
+	defer srv.Close()
+
+	client := NewHTTPClient(testConfig(srv.URL))
+	defer func() {
+		_ = client.Close()
+	}()
+
+	ctx := context.Background()
+
+	t.Run("processed after pending polls", func(t *testing.T) {
+		res, err := client.GetAccrualByOrderNumber(ctx, "12345678903")
+		require.NoError(t, err)
+		assert.Equal(t, OutcomeOK, res.Outcome)
+		assert.Equal(t, StatusProcessing, res.Order.Status)
+		assert.Nil(t, res.Order.Amount)
+
+		res, err = client.GetAccrualByOrderNumber(ctx, "12345678903")
+		require.NoError(t, err)
+		assert.Equal(t, OutcomeOK, res.Outcome)
+		assert.Equal(t, StatusProcessed, res.Order.Status)
+		require.NotNil(t, res.Order.Amount)
+		assert.Equal(t, amount, *res.Order.Amount)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		res, err := client.GetAccrualByOrderNumber(ctx, "2377225624")
+		require.NoError(t, err)
+		assert.Equal(t, OutcomeOK, res.Outcome)
+		assert.Equal(t, StatusInvalid, res.Order.Status)
+	})
+
+	t.Run("too many requests", func(t *testing.T) {
+		res, err := client.GetAccrualByOrderNumber(ctx, "3000")
+		require.NoError(t, err)
+		assert.Equal(t, OutcomeThrottled, res.Outcome)
+		assert.Equal(t, 5*time.Second, res.RetryAfter)
+	})
+
+	t.Run("server error", func(t *testing.T) {
+		res, err := client.GetAccrualByOrderNumber(ctx, "4000")
+		require.NoError(t, err)
+		assert.Equal(t, OutcomeServerError, res.Outcome)
+	})
+
+	t.Run("random errors", func(t *testing.T) {
+		res, err := client.GetAccrualByOrderNumber(ctx, "5000")
+		require.NoError(t, err)
+		assert.Equal(t, OutcomeServerError, res.Outcome)
+	})
+
+	t.Run("not registered", func(t *testing.T) {
+		res, err := client.GetAccrualByOrderNumber(ctx, "9000")
+		require.NoError(t, err)
+		assert.Equal(t, OutcomeNotRegistered, res.Outcome)
+	})
+}
+
+func TestMockServerRateLimit(t *testing.T) {
+	t.Parallel()
+
+	srv := NewMockServer(MockConfig{
+		Rules:     []MockRule{{Status: StatusProcessed}},
+		RateLimit: 2,
+	})
+	defer srv.Close()
+
+	codes := make([]int, 0, 3)
+	for i := 0; i < 3; i++ {
+		res, err := http.Get(srv.URL + "/api/orders/2377225624")
+		require.NoError(t, err)
+		_ = res.Body.Close()
+		codes = append(codes, res.StatusCode)
+		if res.StatusCode == http.StatusTooManyRequests {
+			assert.NotEmpty(t, res.Header.Get("Retry-After"))
+		}
+	}
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes)
+}
+
+func TestLoadMockConfig(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	yamlPath := filepath.Join(dir, "rules.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte(`
+rate_limit: 10
+rules:
+  - prefix: "1"
+    status: PROCESSED
+    accrual: 500
+    latency: 250ms
+    error_rate: 0.5
+`), 0o600))
+
+	jsonPath := filepath.Join(dir, "rules.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{
+  "rate_limit": 10,
+  "rules": [
+    {"prefix": "1", "status": "PROCESSED", "accrual": 500, "latency": "250ms", "error_rate": 0.5}
+  ]
+}`), 0o600))
+
+	amount := 500.0
+	want := MockConfig{
+		RateLimit: 10,
+		Rules: []MockRule{
+			{Prefix: "1", Status: StatusProcessed, Accrual: &amount, Latency: 250 * time.Millisecond, ErrorRate: 0.5},
+		},
+	}
+
+	for _, path := range []string{yamlPath, jsonPath} {
+		cfg, err := LoadMockConfig(path)
+		require.NoError(t, err)
+		assert.Equal(t, want, cfg)
+	}
+
+	_, err := LoadMockConfig(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
@@ -1,5 +1,7 @@
 package accrual
 
+import "time"
+
 type Status string
 
 const (
@@ -14,3 +16,38 @@ type OrderResponse struct {
 	Status Status   `json:"status"`
 	Amount *float64 `json:"accrual,omitempty"`
 }
+
+type Outcome int
+
+const (
+	// OutcomeOK means the accrual system knows the order, see Result.Order.
+	OutcomeOK Outcome = iota
+	// OutcomeNotRegistered means the order is not registered in the accrual system yet (204).
+	OutcomeNotRegistered
+	// OutcomeThrottled means the accrual system is rate limiting us (429), see Result.RetryAfter.
+	OutcomeThrottled
+	// OutcomeServerError means the accrual system failed to handle the request (5xx).
+	OutcomeServerError
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeOK:
+		return "ok"
+	case OutcomeNotRegistered:
+		return "not_registered"
+	case OutcomeThrottled:
+		return "throttled"
+	case OutcomeServerError:
+		return "server_error"
+	default:
+		return "unknown"
+	}
+}
+
+type Result struct {
+	Outcome    Outcome
+	StatusCode int
+	Order      OrderResponse
+	RetryAfter time.Duration
+}
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	repository "github.com/aifedorov/gophermart/internal/order/repository/db"
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// EvaluateCampaigns returns the bonus every applicable campaign adds to the order.
+// Multipliers apply to the accrual only, so a 2x and a 1.5x campaign together give 2.5x,
+// not 3x. A bonus is rounded down to cents and cut to what is left of the campaign budget.
+func EvaluateCampaigns(campaigns []Campaign, order CampaignOrder) []CampaignBonus {
+	if !order.Accrual.IsPositive() {
+		return nil
+	}
+
+	var bonuses []CampaignBonus
+	for _, campaign := range campaigns {
+		if !campaign.appliesTo(order) {
+			continue
+		}
+
+		amount := order.Accrual.Mul(campaign.Multiplier.Sub(decimal.NewFromInt(1))).
+			Add(campaign.Bonus).
+			Truncate(2)
+		if campaign.BudgetLeft != nil {
+			amount = decimal.Min(amount, *campaign.BudgetLeft)
+		}
+		if !amount.IsPositive() {
+			continue
+		}
+
+		bonuses = append(bonuses, CampaignBonus{
+			CampaignID:     campaign.ID,
+			CampaignName:   campaign.Name,
+			Amount:         amount,
+			FirstOrderOnly: campaign.FirstOrderOnly,
+		})
+	}
+	return bonuses
+}
+
+func (c Campaign) appliesTo(order CampaignOrder) bool {
+	if len(c.DaysOfWeek) > 0 && !slices.Contains(c.DaysOfWeek, order.ProcessedAt.UTC().Weekday()) {
+		return false
+	}
+	if c.FirstOrderOnly && !order.FirstOrder {
+		return false
+	}
+	if c.Cohort != "" && !c.InCohort {
+		return false
+	}
+	return true
+}
+
+// campaignBonuses evaluates the running campaigns for an order that is about to be processed.
+// The repository pays the bonuses in the same transaction that credits the accrual.
+func campaignBonuses(ctx context.Context, repo repository.Repository, order repository.Order, accrual decimal.Decimal, at time.Time) ([]repository.CampaignBonus, error) {
+	if !accrual.IsPositive() {
+		return nil, nil
+	}
+
+	userID := order.UserID.String()
+	dbCampaigns, err := repo.GetActiveCampaigns(ctx, userID, at)
+	if err != nil {
+		return nil, err
+	}
+	if len(dbCampaigns) == 0 {
+		return nil, nil
+	}
+
+	campaigns := make([]Campaign, 0, len(dbCampaigns))
+	firstOrderOnly := false
+	for _, dbCampaign := range dbCampaigns {
+		campaign := convertCampaignToDomain(dbCampaign)
+		firstOrderOnly = firstOrderOnly || campaign.FirstOrderOnly
+		campaigns = append(campaigns, campaign)
+	}
+
+	firstOrder := false
+	if firstOrderOnly {
+		processed, err := repo.CountProcessedOrders(ctx, userID, order.ID)
+		if err != nil {
+			return nil, err
+		}
+		firstOrder = processed == 0
+	}
+
+	bonuses := EvaluateCampaigns(campaigns, CampaignOrder{
+		Accrual:     accrual,
+		ProcessedAt: at,
+		FirstOrder:  firstOrder,
+	})
+
+	dbBonuses := make([]repository.CampaignBonus, 0, len(bonuses))
+	for _, bonus := range bonuses {
+		campaignID, err := uuid.Parse(bonus.CampaignID)
+		if err != nil {
+			return nil, err
+		}
+		dbBonuses = append(dbBonuses, repository.CampaignBonus{
+			CampaignID:     campaignID,
+			Amount:         bonus.Amount,
+			FirstOrderOnly: bonus.FirstOrderOnly,
+		})
+		logger.FromContext(ctx).Debug("orderservice: campaign applies to order",
+			zap.String("orderNumber", order.Number), zap.String("campaign", bonus.CampaignName), zap.String("bonus", bonus.Amount.String()))
+	}
+	return dbBonuses, nil
+}
@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateCampaigns(t *testing.T) {
+	t.Parallel()
+
+	saturday := time.Date(2025, 6, 7, 12, 0, 0, 0, time.UTC)
+	monday := time.Date(2025, 6, 9, 12, 0, 0, 0, time.UTC)
+	weekend := []time.Weekday{time.Saturday, time.Sunday}
+	budget := func(v int64) *decimal.Decimal {
+		d := decimal.NewFromInt(v)
+		return &d
+	}
+
+	doublePoints := Campaign{ID: "double", Multiplier: decimal.NewFromInt(2), DaysOfWeek: weekend}
+	welcome := Campaign{ID: "welcome", Multiplier: decimal.NewFromInt(1), Bonus: decimal.NewFromInt(100), FirstOrderOnly: true}
+	gold := Campaign{ID: "gold", Multiplier: decimal.NewFromFloat(1.5), Cohort: "gold", InCohort: true}
+
+	tests := []struct {
+		name      string
+		campaigns []Campaign
+		order     CampaignOrder
+		want      map[string]string
+	}{
+		{
+			name:      "weekend multiplier on saturday",
+			campaigns: []Campaign{doublePoints},
+			order:     CampaignOrder{Accrual: decimal.NewFromInt(200), ProcessedAt: saturday},
+			want:      map[string]string{"double": "200"},
+		},
+		{
+			name:      "weekend multiplier on monday",
+			campaigns: []Campaign{doublePoints},
+			order:     CampaignOrder{Accrual: decimal.NewFromInt(200), ProcessedAt: monday},
+			want:      map[string]string{},
+		},
+		{
+			name:      "first order bonus",
+			campaigns: []Campaign{welcome},
+			order:     CampaignOrder{Accrual: decimal.NewFromInt(50), ProcessedAt: monday, FirstOrder: true},
+			want:      map[string]string{"welcome": "100"},
+		},
+		{
+			name:      "no first order bonus for later orders",
+			campaigns: []Campaign{welcome},
+			order:     CampaignOrder{Accrual: decimal.NewFromInt(50), ProcessedAt: monday},
+			want:      map[string]string{},
+		},
+		{
+			name:      "cohort member",
+			campaigns: []Campaign{gold},
+			order:     CampaignOrder{Accrual: decimal.NewFromFloat(10.25), ProcessedAt: monday},
+			want:      map[string]string{"gold": "5.12"},
+		},
+		{
+			name:      "not a cohort member",
+			campaigns: []Campaign{{ID: "gold", Multiplier: decimal.NewFromFloat(1.5), Cohort: "gold"}},
+			order:     CampaignOrder{Accrual: decimal.NewFromInt(100), ProcessedAt: monday},
+			want:      map[string]string{},
+		},
+		{
+			name:      "multipliers stack on the accrual",
+			campaigns: []Campaign{doublePoints, gold, welcome},
+			order:     CampaignOrder{Accrual: decimal.NewFromInt(100), ProcessedAt: saturday, FirstOrder: true},
+			want:      map[string]string{"double": "100", "gold": "50", "welcome": "100"},
+		},
+		{
+			name:      "bonus is cut to the budget left",
+			campaigns: []Campaign{{ID: "welcome", Multiplier: decimal.NewFromInt(1), Bonus: decimal.NewFromInt(100), BudgetLeft: budget(30)}},
+			order:     CampaignOrder{Accrual: decimal.NewFromInt(50), ProcessedAt: monday},
+			want:      map[string]string{"welcome": "30"},
+		},
+		{
+			name:      "spent budget",
+			campaigns: []Campaign{{ID: "welcome", Multiplier: decimal.NewFromInt(1), Bonus: decimal.NewFromInt(100), BudgetLeft: budget(0)}},
+			order:     CampaignOrder{Accrual: decimal.NewFromInt(50), ProcessedAt: monday},
+			want:      map[string]string{},
+		},
+		{
+			name:      "order without accrual",
+			campaigns: []Campaign{doublePoints, welcome},
+			order:     CampaignOrder{Accrual: decimal.Zero, ProcessedAt: saturday, FirstOrder: true},
+			want:      map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := make(map[string]string)
+			for _, bonus := range EvaluateCampaigns(tt.campaigns, tt.order) {
+				got[bonus.CampaignID] = bonus.Amount.String()
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"os"
 	"time"
 
 	repository "github.com/aifedorov/gophermart/internal/order/repository/db"
 	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -15,27 +18,45 @@ type Checker interface {
 	Run() error
 }
 
+type CheckerConfig struct {
+	// JobLease must be longer than the time a job may spend in the pool queue plus
+	// the per-order deadline, otherwise another replica may claim it in the meantime.
+	JobLease time.Duration
+	Retry    RetryPolicy
+}
+
 type checker struct {
-	ctx    context.Context
-	repo   repository.Repository
-	poller Poller
+	ctx      context.Context
+	repo     repository.Repository
+	poller   Poller
+	pool     WorkerPool
+	cfg      CheckerConfig
+	workerID string
 }
 
-func NewChecker(ctx context.Context, repo repository.Repository, poller Poller) Checker {
+// NewChecker creates a checker that claims accrual jobs while the pool has room for them.
+func NewChecker(ctx context.Context, repo repository.Repository, poller Poller, pool WorkerPool, cfg CheckerConfig) Checker {
 	return &checker{
-		ctx:    ctx,
-		repo:   repo,
-		poller: poller,
+		ctx:      ctx,
+		repo:     repo,
+		poller:   poller,
+		pool:     pool,
+		cfg:      cfg,
+		workerID: newWorkerID(),
 	}
 }
 
+// Run claims accrual jobs until the checker context is cancelled.
+// It returns nil when it was stopped by the cancellation.
 func (c *checker) Run() error {
 	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case <-c.ctx.Done():
-			logger.Log.Debug("poller: context was cancelled")
-			return c.ctx.Err()
+			logger.Log.Debug("checker: context was cancelled, stopped claiming accrual jobs")
+			return nil
 		case <-ticker.C:
 			err := c.processNewOrders()
 			if err != nil {
@@ -46,19 +67,117 @@ func (c *checker) Run() error {
 }
 
 func (c *checker) processNewOrders() error {
-	order, err := c.repo.GetNewTopUpOrder()
-	if errors.Is(err, sql.ErrNoRows) {
+	if !c.poller.Available() {
+		logger.Log.Debug("checker: accrual system is unavailable, skipping dispatch")
 		return nil
 	}
-	if err != nil {
-		return err
-	}
 
-	go func() {
-		err := c.poller.StartPollingWithOrderNumber(order.Number)
+	for c.hasCapacity() {
+		job, err := c.repo.ClaimAccrualJob(c.ctx, c.workerID, c.cfg.JobLease)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
 		if err != nil {
-			logger.Log.Error("poller failed to start orders", zap.Error(err))
+			return err
 		}
-	}()
+
+		if !c.pool.Submit(func(ctx context.Context) { c.processJob(ctx, job) }) {
+			logger.Log.Warn("checker: worker pool rejected accrual job", zap.String("orderNumber", job.OrderNumber))
+			return c.repo.ReleaseAccrualJob(context.WithoutCancel(c.ctx), job.ID, c.workerID)
+		}
+	}
+
+	stats := c.pool.Stats()
+	logger.Log.Debug("checker: worker pool is saturated",
+		zap.Int("busyWorkers", stats.BusyWorkers), zap.Int("queueLen", stats.QueueLen))
 	return nil
 }
+
+func (c *checker) hasCapacity() bool {
+	stats := c.pool.Stats()
+	return stats.QueueLen < stats.QueueCap || stats.BusyWorkers+stats.QueueLen < stats.Workers
+}
+
+func (c *checker) processJob(ctx context.Context, job repository.AccrualJob) {
+	// Job bookkeeping must survive the cancellation that interrupted the poll.
+	jobCtx := context.WithoutCancel(ctx)
+
+	if ctx.Err() != nil {
+		// The pool is shutting down before the job has started.
+		c.releaseJob(jobCtx, job)
+		return
+	}
+
+	err := c.poller.CheckOrder(ctx, job.OrderNumber)
+	if err == nil {
+		err = c.repo.CompleteAccrualJob(jobCtx, job.ID, c.workerID)
+		if err != nil {
+			logger.Log.Error("checker: failed to complete accrual job", zap.String("orderNumber", job.OrderNumber), zap.Error(err))
+		}
+		return
+	}
+
+	if errors.Is(err, ErrAccrualUnavailable) || errors.Is(ctx.Err(), context.Canceled) {
+		// The job did not reach the accrual system or was interrupted by shutdown,
+		// so it is not counted as an attempt.
+		c.releaseJob(jobCtx, job)
+		return
+	}
+
+	if !errors.Is(err, ErrAccrualPending) {
+		logger.Log.Error("poller failed to process order",
+			zap.String("orderNumber", job.OrderNumber), zap.Int32("attempts", job.Attempts), zap.Error(err))
+	}
+
+	if c.cfg.Retry.Expired(job.CreatedAt.Time, time.Now()) {
+		c.expireJob(jobCtx, job)
+		return
+	}
+
+	delay := c.cfg.Retry.Backoff(int(job.Attempts))
+	logger.Log.Debug("checker: accrual job rescheduled",
+		zap.String("orderNumber", job.OrderNumber), zap.Int32("attempts", job.Attempts), zap.Duration("delay", delay))
+
+	err = c.repo.RescheduleAccrualJob(jobCtx, job.ID, c.workerID, delay, err.Error())
+	if err != nil {
+		logger.Log.Error("checker: failed to reschedule accrual job", zap.String("orderNumber", job.OrderNumber), zap.Error(err))
+	}
+}
+
+func (c *checker) releaseJob(ctx context.Context, job repository.AccrualJob) {
+	err := c.repo.ReleaseAccrualJob(ctx, job.ID, c.workerID)
+	if err != nil {
+		logger.Log.Error("checker: failed to release accrual job", zap.String("orderNumber", job.OrderNumber), zap.Error(err))
+	}
+}
+
+// expireJob gives up on an order that did not reach a final status before the hard deadline.
+func (c *checker) expireJob(ctx context.Context, job repository.AccrualJob) {
+	logger.Log.Warn("checker: accrual job exceeded max age, marking order invalid",
+		zap.String("orderNumber", job.OrderNumber), zap.Int32("attempts", job.Attempts))
+
+	order, err := c.repo.GetOrderByNumber(ctx, job.OrderNumber)
+	if err != nil {
+		logger.Log.Error("checker: failed to get expired order", zap.String("orderNumber", job.OrderNumber), zap.Error(err))
+		return
+	}
+
+	err = transitionOrder(ctx, c.repo, order, StatusInvalid, nil)
+	if err != nil && !errors.Is(err, ErrIllegalStatusTransition) {
+		logger.Log.Error("checker: failed to invalidate expired order", zap.String("orderNumber", job.OrderNumber), zap.Error(err))
+		return
+	}
+
+	err = c.repo.CompleteAccrualJob(ctx, job.ID, c.workerID)
+	if err != nil {
+		logger.Log.Error("checker: failed to complete accrual job", zap.String("orderNumber", job.OrderNumber), zap.Error(err))
+	}
+}
+
+func newWorkerID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.NewString()[:8])
+}
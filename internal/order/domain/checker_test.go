@@ -0,0 +1,266 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	repository "github.com/aifedorov/gophermart/internal/order/repository/db"
+	orderMocks "github.com/aifedorov/gophermart/internal/order/repository/mocks"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+var testCheckerConfig = CheckerConfig{
+	JobLease: 10 * time.Minute,
+	Retry: RetryPolicy{
+		BaseDelay: time.Second,
+		MaxDelay:  time.Minute,
+		MaxAge:    time.Hour,
+	},
+}
+
+type stubPoller struct {
+	check       func(ctx context.Context, number string) error
+	unavailable bool
+}
+
+func (p *stubPoller) CheckOrder(ctx context.Context, number string) error {
+	return p.check(ctx, number)
+}
+
+func (p *stubPoller) Available() bool {
+	return !p.unavailable
+}
+
+type stubPool struct {
+	stats    PoolStats
+	accepted bool
+	tasks    []Task
+}
+
+func (p *stubPool) Submit(task Task) bool {
+	if !p.accepted {
+		return false
+	}
+	p.tasks = append(p.tasks, task)
+	p.stats.QueueLen++
+	return true
+}
+
+func (p *stubPool) Stats() PoolStats {
+	return p.stats
+}
+
+func (p *stubPool) Wait() {}
+
+func (p *stubPool) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+const testOrderNumber = "2377225624"
+
+var testJob = repository.AccrualJob{
+	ID:          uuid.MustParse("550e8400-e29b-41d4-a716-446655440010"),
+	OrderNumber: testOrderNumber,
+	Attempts:    1,
+	CreatedAt:   pgtype.Timestamptz{Time: time.Now(), Valid: true},
+}
+
+func TestCheckerProcessJob(t *testing.T) {
+	t.Parallel()
+
+	expiredJob := testJob
+	expiredJob.CreatedAt = pgtype.Timestamptz{Time: time.Now().Add(-2 * time.Hour), Valid: true}
+
+	tests := []struct {
+		name      string
+		job       repository.AccrualJob
+		pollErr   error
+		cancelled bool
+		mock      func(mockRepo *orderMocks.MockRepository, workerID string)
+	}{
+		{
+			name: "completes job after successful polling",
+			job:  testJob,
+			mock: func(mockRepo *orderMocks.MockRepository, workerID string) {
+				mockRepo.EXPECT().CompleteAccrualJob(gomock.Any(), testJob.ID, workerID).Return(nil).Times(1)
+			},
+		},
+		{
+			name:    "reschedules pending order with backoff",
+			job:     testJob,
+			pollErr: ErrAccrualPending,
+			mock: func(mockRepo *orderMocks.MockRepository, workerID string) {
+				mockRepo.EXPECT().
+					RescheduleAccrualJob(gomock.Any(), testJob.ID, workerID, gomock.Any(), ErrAccrualPending.Error()).
+					Return(nil).
+					Times(1)
+			},
+		},
+		{
+			name:    "reschedules job after failed polling",
+			job:     testJob,
+			pollErr: assert.AnError,
+			mock: func(mockRepo *orderMocks.MockRepository, workerID string) {
+				mockRepo.EXPECT().
+					RescheduleAccrualJob(gomock.Any(), testJob.ID, workerID, gomock.Any(), assert.AnError.Error()).
+					Return(nil).
+					Times(1)
+			},
+		},
+		{
+			name:    "releases job while accrual is unavailable",
+			job:     testJob,
+			pollErr: ErrAccrualUnavailable,
+			mock: func(mockRepo *orderMocks.MockRepository, workerID string) {
+				mockRepo.EXPECT().ReleaseAccrualJob(gomock.Any(), testJob.ID, workerID).Return(nil).Times(1)
+			},
+		},
+		{
+			name:      "releases job interrupted by shutdown",
+			job:       testJob,
+			pollErr:   context.Canceled,
+			cancelled: true,
+			mock: func(mockRepo *orderMocks.MockRepository, workerID string) {
+				mockRepo.EXPECT().ReleaseAccrualJob(gomock.Any(), testJob.ID, workerID).Return(nil).Times(1)
+			},
+		},
+		{
+			name:    "invalidates pending order after max age",
+			job:     expiredJob,
+			pollErr: ErrAccrualPending,
+			mock: func(mockRepo *orderMocks.MockRepository, workerID string) {
+				mockRepo.EXPECT().
+					GetOrderByNumber(gomock.Any(), testOrderNumber).
+					Return(repository.Order{Number: testOrderNumber, Status: repository.OrderstatusPROCESSING}, nil).
+					Times(1)
+				mockRepo.EXPECT().
+					UpdateOrderStatus(gomock.Any(), testOrderNumber, repository.OrderstatusPROCESSING, repository.OrderstatusINVALID, nil, repository.OrderBonuses{}).
+					Return(repository.OrderCredits{}, nil).
+					Times(1)
+				mockRepo.EXPECT().CompleteAccrualJob(gomock.Any(), testJob.ID, workerID).Return(nil).Times(1)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := orderMocks.NewMockRepository(ctrl)
+			p := &stubPoller{}
+			c := NewChecker(context.Background(), mockRepo, p, &stubPool{}, testCheckerConfig).(*checker)
+			tt.mock(mockRepo, c.workerID)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			p.check = func(ctx context.Context, number string) error {
+				assert.Equal(t, testJob.OrderNumber, number)
+				if tt.cancelled {
+					cancel()
+				}
+				return tt.pollErr
+			}
+
+			c.processJob(ctx, tt.job)
+		})
+	}
+}
+
+func TestCheckerReleasesQueuedJobsOnCancellation(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pool := NewWorkerPool(ctx, 1, 1, time.Minute)
+	mockRepo := orderMocks.NewMockRepository(ctrl)
+	// The poller has no check func, so polling the queued job would panic.
+	c := NewChecker(ctx, mockRepo, &stubPoller{}, pool, testCheckerConfig).(*checker)
+	mockRepo.EXPECT().ReleaseAccrualJob(gomock.Any(), testJob.ID, c.workerID).Return(nil).Times(1)
+
+	started := make(chan struct{})
+	require.True(t, pool.Submit(func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+	}))
+	<-started
+	require.True(t, pool.Submit(func(ctx context.Context) { c.processJob(ctx, testJob) }))
+
+	cancel()
+	pool.Wait()
+}
+
+func TestCheckerProcessNewOrders(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no jobs to claim", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := orderMocks.NewMockRepository(ctrl)
+		mockRepo.EXPECT().ClaimAccrualJob(gomock.Any(), gomock.Any(), testCheckerConfig.JobLease).Return(repository.AccrualJob{}, sql.ErrNoRows).Times(1)
+
+		pool := &stubPool{accepted: true, stats: PoolStats{Workers: 1, QueueCap: 2}}
+		c := NewChecker(context.Background(), mockRepo, &stubPoller{}, pool, testCheckerConfig).(*checker)
+		assert.NoError(t, c.processNewOrders())
+		assert.Empty(t, pool.tasks)
+	})
+
+	t.Run("claims jobs until queue is full", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := orderMocks.NewMockRepository(ctrl)
+		mockRepo.EXPECT().ClaimAccrualJob(gomock.Any(), gomock.Any(), testCheckerConfig.JobLease).Return(testJob, nil).Times(2)
+
+		pool := &stubPool{accepted: true, stats: PoolStats{Workers: 1, BusyWorkers: 1, QueueCap: 2}}
+		c := NewChecker(context.Background(), mockRepo, &stubPoller{}, pool, testCheckerConfig).(*checker)
+		assert.NoError(t, c.processNewOrders())
+		assert.Len(t, pool.tasks, 2)
+	})
+
+	t.Run("releases job rejected by the pool", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := orderMocks.NewMockRepository(ctrl)
+		pool := &stubPool{accepted: false, stats: PoolStats{Workers: 1, QueueCap: 2}}
+		c := NewChecker(context.Background(), mockRepo, &stubPoller{}, pool, testCheckerConfig).(*checker)
+
+		mockRepo.EXPECT().ClaimAccrualJob(gomock.Any(), c.workerID, testCheckerConfig.JobLease).Return(testJob, nil).Times(1)
+		mockRepo.EXPECT().ReleaseAccrualJob(gomock.Any(), testJob.ID, c.workerID).Return(nil).Times(1)
+
+		assert.NoError(t, c.processNewOrders())
+	})
+
+	t.Run("does not claim jobs while accrual is unavailable", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := orderMocks.NewMockRepository(ctrl)
+		pool := &stubPool{accepted: true, stats: PoolStats{Workers: 1, QueueCap: 2}}
+		c := NewChecker(context.Background(), mockRepo, &stubPoller{unavailable: true}, pool, testCheckerConfig).(*checker)
+
+		assert.NoError(t, c.processNewOrders())
+		assert.Empty(t, pool.tasks)
+	})
+}
@@ -7,4 +7,25 @@ var (
 	ErrOrderNotFound             = errors.New("order not found")
 	ErrWithdrawNegativeAmount    = errors.New("withdraw amount should be positive")
 	ErrWithdrawInsufficientFunds = errors.New("withdraw insufficient funds")
+	ErrAccrualPending            = errors.New("accrual is not final yet")
+	ErrAccrualUnavailable        = errors.New("accrual system is unavailable")
+	ErrIllegalStatusTransition   = errors.New("illegal order status transition")
+	ErrHoldAlreadyExists         = errors.New("order number is already used")
+	ErrHoldNotFound              = errors.New("hold not found")
+	ErrHoldNotActive             = errors.New("hold is already captured, voided or expired")
+	ErrHoldExpired               = errors.New("hold has expired")
+	ErrWithdrawalNotFound        = errors.New("withdrawal not found")
+	ErrRefundNegativeAmount      = errors.New("refund amount should be positive")
+	ErrRefundExceedsWithdrawal   = errors.New("refund exceeds the withdrawn sum")
+	ErrWithdrawLimitExceeded     = errors.New("withdrawal limit of the tier is exceeded")
+	ErrTransferNegativeAmount    = errors.New("transfer amount should be positive")
+	ErrRecipientNotFound         = errors.New("transfer recipient not found")
+	ErrTransferToSelf            = errors.New("transfer to yourself")
+	ErrTransferLimitExceeded     = errors.New("daily transfer limit is exceeded")
+	ErrInvalidGiftCodeBatch      = errors.New("invalid gift code batch")
+	ErrGiftCodeBatchNotFound     = errors.New("gift code batch not found")
+	ErrGiftCodeNotFound          = errors.New("gift code not found")
+	ErrGiftCodeExpired           = errors.New("gift code has expired")
+	ErrGiftCodeExhausted         = errors.New("gift code has no redemptions left")
+	ErrGiftCodeAlreadyRedeemed   = errors.New("gift code is already redeemed by the user")
 )
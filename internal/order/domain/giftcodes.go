@@ -0,0 +1,161 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	repository "github.com/aifedorov/gophermart/internal/order/repository/db"
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/aifedorov/gophermart/internal/pkg/metrics"
+	"github.com/aifedorov/gophermart/internal/pkg/randcode"
+	"github.com/aifedorov/gophermart/internal/pkg/tracing"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+const (
+	giftCodeLength = 12
+
+	// MaxGiftCodeBatchSize caps the codes issued by one request.
+	MaxGiftCodeBatchSize = 1000
+
+	createGiftCodeBatchAttempts = 3
+)
+
+func (s *service) CreateGiftCodeBatch(ctx context.Context, batch NewGiftCodeBatch) (GiftCodeBatch, error) {
+	ctx, span := tracing.Start(ctx, "orderservice.CreateGiftCodeBatch")
+	defer span.End()
+
+	err := validateGiftCodeBatch(batch, time.Now())
+	if err != nil {
+		return GiftCodeBatch{}, err
+	}
+
+	params := repository.CreateGiftCodeBatchParams{
+		Name:           batch.Name,
+		Amount:         batch.Sum,
+		MaxRedemptions: int32(batch.MaxRedemptions),
+		ExpiresAt:      pgtype.Timestamptz{Time: batch.ExpiresAt, Valid: !batch.ExpiresAt.IsZero()},
+	}
+
+	log := logger.FromContext(ctx)
+	for attempt := 1; ; attempt++ {
+		codes, err := generateGiftCodes(batch.Count)
+		if err != nil {
+			return GiftCodeBatch{}, fmt.Errorf("orderservice: failed to generate gift codes: %w", err)
+		}
+
+		dbBatch, err := s.repo.CreateGiftCodeBatch(ctx, params, codes)
+		if errors.Is(err, repository.ErrGiftCodeExists) && attempt < createGiftCodeBatchAttempts {
+			log.Warn("orderservice: generated gift code is taken, generating the batch again", zap.Int("attempt", attempt))
+			continue
+		}
+		if err != nil {
+			return GiftCodeBatch{}, fmt.Errorf("orderservice: failed to create gift code batch: %w", err)
+		}
+
+		log.Info("orderservice: gift code batch created",
+			zap.String("batchID", dbBatch.ID.String()), zap.Int("count", len(codes)), zap.String("sum", batch.Sum.String()))
+
+		dbCodes := make([]repository.GiftCode, len(codes))
+		for i, code := range codes {
+			dbCodes[i] = repository.GiftCode{BatchID: dbBatch.ID, Code: code}
+		}
+		return convertGiftCodeBatchToDomain(dbBatch, dbCodes), nil
+	}
+}
+
+func (s *service) GetGiftCodeBatch(ctx context.Context, batchID string) (GiftCodeBatch, error) {
+	ctx, span := tracing.Start(ctx, "orderservice.GetGiftCodeBatch")
+	defer span.End()
+
+	dbBatch, dbCodes, err := s.repo.GetGiftCodeBatch(ctx, batchID)
+	if errors.Is(err, repository.ErrGiftCodeBatchNotFound) {
+		return GiftCodeBatch{}, ErrGiftCodeBatchNotFound
+	}
+	if err != nil {
+		return GiftCodeBatch{}, fmt.Errorf("orderservice: failed to get gift code batch: %w", err)
+	}
+	return convertGiftCodeBatchToDomain(dbBatch, dbCodes), nil
+}
+
+func (s *service) RedeemGiftCode(ctx context.Context, userID, code string) (GiftCodeRedemption, error) {
+	ctx, span := tracing.Start(ctx, "orderservice.RedeemGiftCode")
+	defer span.End()
+
+	code = NormalizeGiftCode(code)
+	if code == "" {
+		return GiftCodeRedemption{}, ErrGiftCodeNotFound
+	}
+
+	log := logger.FromContext(ctx)
+	dbRedemption, err := s.repo.RedeemGiftCode(ctx, userID, code)
+	switch {
+	case errors.Is(err, repository.ErrGiftCodeNotFound):
+		log.Info("orderservice: gift code not found")
+		return GiftCodeRedemption{}, ErrGiftCodeNotFound
+	case errors.Is(err, repository.ErrGiftCodeExpired):
+		return GiftCodeRedemption{}, ErrGiftCodeExpired
+	case errors.Is(err, repository.ErrGiftCodeExhausted):
+		return GiftCodeRedemption{}, ErrGiftCodeExhausted
+	case errors.Is(err, repository.ErrGiftCodeAlreadyRedeemed):
+		return GiftCodeRedemption{}, ErrGiftCodeAlreadyRedeemed
+	case err != nil:
+		return GiftCodeRedemption{}, fmt.Errorf("orderservice: failed to redeem gift code: %w", err)
+	}
+
+	log.Info("orderservice: gift code redeemed",
+		zap.String("redemptionID", dbRedemption.ID.String()), zap.String("sum", dbRedemption.Amount.String()))
+	metrics.PointsGifted.Add(dbRedemption.Amount.InexactFloat64())
+	return GiftCodeRedemption{
+		ID:         dbRedemption.ID.String(),
+		Code:       code,
+		Sum:        dbRedemption.Amount,
+		RedeemedAt: dbRedemption.CreatedAt.Time,
+	}, nil
+}
+
+// NormalizeGiftCode makes codes typed by users match the issued ones: it drops spaces and
+// dashes and upper-cases the letters.
+func NormalizeGiftCode(code string) string {
+	code = strings.NewReplacer(" ", "", "-", "").Replace(code)
+	return strings.ToUpper(code)
+}
+
+func validateGiftCodeBatch(batch NewGiftCodeBatch, now time.Time) error {
+	switch {
+	case strings.TrimSpace(batch.Name) == "":
+		return fmt.Errorf("%w: name is empty", ErrInvalidGiftCodeBatch)
+	case batch.Count < 1 || batch.Count > MaxGiftCodeBatchSize:
+		return fmt.Errorf("%w: count should be from 1 to %d", ErrInvalidGiftCodeBatch, MaxGiftCodeBatchSize)
+	case !batch.Sum.IsPositive():
+		return fmt.Errorf("%w: sum should be positive", ErrInvalidGiftCodeBatch)
+	case batch.MaxRedemptions < 1 || batch.MaxRedemptions > math.MaxInt32:
+		return fmt.Errorf("%w: max redemptions should be positive", ErrInvalidGiftCodeBatch)
+	case !batch.ExpiresAt.IsZero() && !batch.ExpiresAt.After(now):
+		return fmt.Errorf("%w: expiry is in the past", ErrInvalidGiftCodeBatch)
+	}
+	return nil
+}
+
+// generateGiftCodes returns count distinct random codes.
+func generateGiftCodes(count int) ([]string, error) {
+	codes := make([]string, 0, count)
+	seen := make(map[string]struct{}, count)
+	for len(codes) < count {
+		code, err := randcode.Generate(giftCodeLength)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[code]; ok {
+			continue
+		}
+		seen[code] = struct{}{}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
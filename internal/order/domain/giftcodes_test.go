@@ -0,0 +1,278 @@
+package domain
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	repository "github.com/aifedorov/gophermart/internal/order/repository/db"
+	orderMocks "github.com/aifedorov/gophermart/internal/order/repository/mocks"
+	"github.com/aifedorov/gophermart/internal/pkg/randcode"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNormalizeGiftCode(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "ABCD2345EFGH", NormalizeGiftCode("ABCD2345EFGH"))
+	assert.Equal(t, "ABCD2345EFGH", NormalizeGiftCode("abcd-2345-efgh"))
+	assert.Equal(t, "ABCD2345EFGH", NormalizeGiftCode(" abcd 2345 efgh "))
+	assert.Equal(t, "", NormalizeGiftCode(" - "))
+}
+
+func TestGenerateGiftCodes(t *testing.T) {
+	t.Parallel()
+
+	codes, err := generateGiftCodes(500)
+	require.NoError(t, err)
+	assert.Len(t, codes, 500)
+
+	seen := make(map[string]struct{}, len(codes))
+	for _, code := range codes {
+		assert.Len(t, code, giftCodeLength)
+		for _, char := range code {
+			assert.True(t, strings.ContainsRune(randcode.Alphabet, char), "unexpected character %q in %s", char, code)
+		}
+		assert.NotContains(t, seen, code)
+		seen[code] = struct{}{}
+	}
+}
+
+func TestValidateGiftCodeBatch(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	valid := NewGiftCodeBatch{
+		Name:           "new year",
+		Count:          10,
+		Sum:            decimal.NewFromInt(100),
+		MaxRedemptions: 1,
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(batch *NewGiftCodeBatch)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(batch *NewGiftCodeBatch) {}},
+		{name: "valid with expiry", modify: func(batch *NewGiftCodeBatch) { batch.ExpiresAt = now.Add(time.Hour) }},
+		{name: "empty name", modify: func(batch *NewGiftCodeBatch) { batch.Name = " " }, wantErr: true},
+		{name: "no codes", modify: func(batch *NewGiftCodeBatch) { batch.Count = 0 }, wantErr: true},
+		{name: "too many codes", modify: func(batch *NewGiftCodeBatch) { batch.Count = MaxGiftCodeBatchSize + 1 }, wantErr: true},
+		{name: "zero sum", modify: func(batch *NewGiftCodeBatch) { batch.Sum = decimal.Zero }, wantErr: true},
+		{name: "zero redemptions", modify: func(batch *NewGiftCodeBatch) { batch.MaxRedemptions = 0 }, wantErr: true},
+		{name: "expired", modify: func(batch *NewGiftCodeBatch) { batch.ExpiresAt = now.Add(-time.Hour) }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			batch := valid
+			tt.modify(&batch)
+			err := validateGiftCodeBatch(batch, now)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidGiftCodeBatch)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestServiceCreateGiftCodeBatch(t *testing.T) {
+	t.Parallel()
+
+	batch := NewGiftCodeBatch{
+		Name:           "new year",
+		Count:          3,
+		Sum:            decimal.NewFromInt(100),
+		MaxRedemptions: 5,
+	}
+	dbBatch := repository.GiftCodeBatch{
+		ID:             uuid.New(),
+		Name:           batch.Name,
+		Amount:         batch.Sum,
+		MaxRedemptions: 5,
+	}
+
+	tests := []struct {
+		name    string
+		mock    func(mockRepo *orderMocks.MockRepository)
+		wantErr bool
+	}{
+		{
+			name: "created",
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					CreateGiftCodeBatch(gomock.Any(), gomock.Any(), gomock.Len(3)).
+					Return(dbBatch, nil).
+					Times(1)
+			},
+		},
+		{
+			name: "generates again when a code is taken",
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				gomock.InOrder(
+					mockRepo.EXPECT().
+						CreateGiftCodeBatch(gomock.Any(), gomock.Any(), gomock.Len(3)).
+						Return(repository.GiftCodeBatch{}, repository.ErrGiftCodeExists),
+					mockRepo.EXPECT().
+						CreateGiftCodeBatch(gomock.Any(), gomock.Any(), gomock.Len(3)).
+						Return(dbBatch, nil),
+				)
+			},
+		},
+		{
+			name: "gives up after the last attempt",
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					CreateGiftCodeBatch(gomock.Any(), gomock.Any(), gomock.Len(3)).
+					Return(repository.GiftCodeBatch{}, repository.ErrGiftCodeExists).
+					Times(createGiftCodeBatchAttempts)
+			},
+			wantErr: true,
+		},
+		{
+			name: "repository error",
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					CreateGiftCodeBatch(gomock.Any(), gomock.Any(), gomock.Len(3)).
+					Return(repository.GiftCodeBatch{}, assert.AnError).
+					Times(1)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := orderMocks.NewMockRepository(ctrl)
+			tt.mock(mockRepo)
+
+			svc := NewService(mockRepo, Config{})
+			created, err := svc.CreateGiftCodeBatch(context.Background(), batch)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, dbBatch.ID.String(), created.ID)
+			assert.Len(t, created.Codes, batch.Count)
+		})
+	}
+}
+
+func TestServiceRedeemGiftCode(t *testing.T) {
+	t.Parallel()
+
+	const (
+		userID = "7f0c1f36-3b7a-4a43-9b5e-0d6f3c1b2a10"
+		code   = "ABCD2345EFGH"
+	)
+
+	tests := []struct {
+		name    string
+		code    string
+		mock    func(mockRepo *orderMocks.MockRepository)
+		wantErr error
+	}{
+		{
+			name: "redeemed",
+			code: "abcd-2345-efgh",
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					RedeemGiftCode(gomock.Any(), userID, code).
+					Return(repository.GiftCodeRedemption{Amount: decimal.NewFromInt(100)}, nil).
+					Times(1)
+			},
+		},
+		{
+			name:    "empty code",
+			code:    " ",
+			mock:    func(mockRepo *orderMocks.MockRepository) {},
+			wantErr: ErrGiftCodeNotFound,
+		},
+		{
+			name: "not found",
+			code: code,
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					RedeemGiftCode(gomock.Any(), userID, code).
+					Return(repository.GiftCodeRedemption{}, repository.ErrGiftCodeNotFound).
+					Times(1)
+			},
+			wantErr: ErrGiftCodeNotFound,
+		},
+		{
+			name: "expired",
+			code: code,
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					RedeemGiftCode(gomock.Any(), userID, code).
+					Return(repository.GiftCodeRedemption{}, repository.ErrGiftCodeExpired).
+					Times(1)
+			},
+			wantErr: ErrGiftCodeExpired,
+		},
+		{
+			name: "exhausted",
+			code: code,
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					RedeemGiftCode(gomock.Any(), userID, code).
+					Return(repository.GiftCodeRedemption{}, repository.ErrGiftCodeExhausted).
+					Times(1)
+			},
+			wantErr: ErrGiftCodeExhausted,
+		},
+		{
+			name: "already redeemed",
+			code: code,
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					RedeemGiftCode(gomock.Any(), userID, code).
+					Return(repository.GiftCodeRedemption{}, repository.ErrGiftCodeAlreadyRedeemed).
+					Times(1)
+			},
+			wantErr: ErrGiftCodeAlreadyRedeemed,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := orderMocks.NewMockRepository(ctrl)
+			tt.mock(mockRepo)
+
+			svc := NewService(mockRepo, Config{})
+			redemption, err := svc.RedeemGiftCode(context.Background(), userID, tt.code)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, code, redemption.Code)
+			assert.True(t, redemption.Sum.Equal(decimal.NewFromInt(100)))
+		})
+	}
+}
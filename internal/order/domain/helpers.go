@@ -4,6 +4,7 @@ import (
 	"time"
 
 	repository "github.com/aifedorov/gophermart/internal/order/repository/db"
+	"github.com/shopspring/decimal"
 )
 
 func convertOrderToDomain(dbOrder repository.Order) Order {
@@ -43,12 +44,139 @@ func convertStatusToDomain(dbStatus repository.Orderstatus) Status {
 	}
 }
 
-func convertOrderToWithdrawalDomain(dbOrder repository.Order) (Withdrawal, error) {
+func convertStatusToRepository(status Status) repository.Orderstatus {
+	switch status {
+	case StatusProcessing:
+		return repository.OrderstatusPROCESSING
+	case StatusProcessed:
+		return repository.OrderstatusPROCESSED
+	case StatusInvalid:
+		return repository.OrderstatusINVALID
+	default:
+		return repository.OrderstatusNEW
+	}
+}
+
+func convertOrderToWithdrawalDomain(dbWithdrawal repository.GetWithdrawalsByUserIDRow) (Withdrawal, error) {
 	return Withdrawal{
-		ID:          dbOrder.ID.String(),
-		UserID:      dbOrder.UserID.String(),
-		OrderNumber: dbOrder.Number,
-		Sum:         dbOrder.Amount,
-		ProcessedAt: dbOrder.ProcessedAt.Time,
+		ID:          dbWithdrawal.Order.ID.String(),
+		UserID:      dbWithdrawal.Order.UserID.String(),
+		OrderNumber: dbWithdrawal.Order.Number,
+		Sum:         dbWithdrawal.Order.Amount,
+		Refunded:    dbWithdrawal.Refunded,
+		ProcessedAt: dbWithdrawal.Order.ProcessedAt.Time,
 	}, nil
 }
+
+func convertHoldToDomain(dbHold repository.BalanceHold) Hold {
+	return Hold{
+		ID:          dbHold.ID.String(),
+		UserID:      dbHold.UserID.String(),
+		OrderNumber: dbHold.OrderNumber,
+		Sum:         dbHold.Amount,
+		Status:      convertHoldStatusToDomain(dbHold.Status),
+		ExpiresAt:   dbHold.ExpiresAt.Time,
+		CreatedAt:   dbHold.CreatedAt.Time,
+	}
+}
+
+func convertTransferToDomain(dbTransfer repository.GetTransfersByUserIDRow) Transfer {
+	direction := TransferDirectionIn
+	if dbTransfer.Outgoing {
+		direction = TransferDirectionOut
+	}
+	return Transfer{
+		ID:           dbTransfer.ID.String(),
+		Direction:    direction,
+		Counterparty: MaskLogin(dbTransfer.Counterparty),
+		Sum:          dbTransfer.Amount,
+		CreatedAt:    dbTransfer.CreatedAt.Time,
+	}
+}
+
+func convertGiftCodeBatchToDomain(dbBatch repository.GiftCodeBatch, dbCodes []repository.GiftCode) GiftCodeBatch {
+	codes := make([]GiftCode, len(dbCodes))
+	for i, dbCode := range dbCodes {
+		codes[i] = GiftCode{
+			Code:        dbCode.Code,
+			Redemptions: int(dbCode.Redemptions),
+		}
+	}
+	return GiftCodeBatch{
+		ID:             dbBatch.ID.String(),
+		Name:           dbBatch.Name,
+		Sum:            dbBatch.Amount,
+		MaxRedemptions: int(dbBatch.MaxRedemptions),
+		ExpiresAt:      dbBatch.ExpiresAt.Time,
+		CreatedAt:      dbBatch.CreatedAt.Time,
+		Codes:          codes,
+	}
+}
+
+func convertReferralToDomain(dbReferral repository.GetReferralsByReferrerIDRow) Referral {
+	return Referral{
+		Referred:  MaskLogin(dbReferral.Referred),
+		Status:    convertReferralStatusToDomain(dbReferral.Status),
+		Reason:    dbReferral.Reason.String,
+		Reward:    dbReferral.ReferrerReward,
+		SettledAt: dbReferral.SettledAt.Time,
+		CreatedAt: dbReferral.CreatedAt.Time,
+	}
+}
+
+func convertReferralStatusToDomain(dbStatus repository.Referralstatus) ReferralStatus {
+	switch dbStatus {
+	case repository.ReferralstatusREWARDED:
+		return ReferralStatusRewarded
+	case repository.ReferralstatusREJECTED:
+		return ReferralStatusRejected
+	default:
+		return ReferralStatusPending
+	}
+}
+
+func convertHoldStatusToDomain(dbStatus repository.Holdstatus) HoldStatus {
+	switch dbStatus {
+	case repository.HoldstatusCAPTURED:
+		return HoldStatusCaptured
+	case repository.HoldstatusVOIDED:
+		return HoldStatusVoided
+	case repository.HoldstatusEXPIRED:
+		return HoldStatusExpired
+	default:
+		return HoldStatusActive
+	}
+}
+
+func convertCampaignToDomain(dbCampaign repository.GetActiveCampaignsByUserIDRow) Campaign {
+	daysOfWeek := make([]time.Weekday, 0, len(dbCampaign.Campaign.DaysOfWeek))
+	for _, day := range dbCampaign.Campaign.DaysOfWeek {
+		daysOfWeek = append(daysOfWeek, time.Weekday(day))
+	}
+
+	var budgetLeft *decimal.Decimal
+	if dbCampaign.Campaign.Budget.Valid {
+		left := dbCampaign.Campaign.Budget.Decimal.Sub(dbCampaign.Campaign.Spent)
+		budgetLeft = &left
+	}
+
+	return Campaign{
+		ID:             dbCampaign.Campaign.ID.String(),
+		Name:           dbCampaign.Campaign.Name,
+		Multiplier:     dbCampaign.Campaign.Multiplier,
+		Bonus:          dbCampaign.Campaign.Bonus,
+		DaysOfWeek:     daysOfWeek,
+		FirstOrderOnly: dbCampaign.Campaign.FirstOrderOnly,
+		Cohort:         dbCampaign.Campaign.Cohort.String,
+		InCohort:       dbCampaign.InCohort,
+		BudgetLeft:     budgetLeft,
+	}
+}
+
+func convertTierToDomain(dbTier repository.UserTier) Tier {
+	return Tier{
+		Name:              dbTier.Tier,
+		AccrualMultiplier: dbTier.AccrualMultiplier,
+		WithdrawalLimit:   dbTier.WithdrawalLimit,
+	}
+}
@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	repository "github.com/aifedorov/gophermart/internal/order/repository/db"
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/aifedorov/gophermart/internal/pkg/metrics"
+	"github.com/aifedorov/gophermart/internal/pkg/tracing"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const expireHoldsBatchSize = 100
+
+func (s *service) CreateHold(ctx context.Context, userID, orderNumber string, amount decimal.Decimal, ttl time.Duration) (Hold, error) {
+	ctx, span := tracing.Start(ctx, "orderservice.CreateHold", tracing.OrderNumber(orderNumber))
+	defer span.End()
+
+	if !IsValidOrderNumber(orderNumber) {
+		return Hold{}, ErrInvalidOrderNumber
+	}
+	if !amount.IsPositive() {
+		return Hold{}, ErrWithdrawNegativeAmount
+	}
+
+	dbHold, err := s.repo.CreateHold(ctx, userID, orderNumber, amount, time.Now().Add(ttl))
+	if errors.Is(err, repository.ErrOrderAlreadyExists) {
+		return Hold{}, ErrHoldAlreadyExists
+	}
+	if errors.Is(err, repository.ErrWithdrawInsufficientFunds) {
+		logger.FromContext(ctx).Info("orderservice: insufficient funds to hold", zap.String("orderNumber", orderNumber))
+		return Hold{}, ErrWithdrawInsufficientFunds
+	}
+	if errors.Is(err, repository.ErrWithdrawLimitExceeded) {
+		logger.FromContext(ctx).Info("orderservice: withdrawal limit exceeded by hold", zap.String("orderNumber", orderNumber))
+		return Hold{}, ErrWithdrawLimitExceeded
+	}
+	if err != nil {
+		return Hold{}, fmt.Errorf("orderservice: failed to create hold: %w", err)
+	}
+
+	logger.FromContext(ctx).Info("orderservice: points held",
+		zap.String("orderNumber", orderNumber), zap.String("holdID", dbHold.ID.String()), zap.String("sum", amount.String()))
+	return convertHoldToDomain(dbHold), nil
+}
+
+func (s *service) CaptureHold(ctx context.Context, userID, holdID string) (Hold, error) {
+	ctx, span := tracing.Start(ctx, "orderservice.CaptureHold")
+	defer span.End()
+
+	dbHold, _, err := s.repo.CaptureHold(ctx, userID, holdID)
+	if err != nil {
+		return Hold{}, convertHoldError("capture", err)
+	}
+
+	logger.FromContext(ctx).Info("orderservice: hold captured",
+		zap.String("orderNumber", dbHold.OrderNumber), zap.String("holdID", holdID), zap.String("sum", dbHold.Amount.String()))
+	metrics.PointsWithdrawn.Add(dbHold.Amount.InexactFloat64())
+	return convertHoldToDomain(dbHold), nil
+}
+
+func (s *service) VoidHold(ctx context.Context, userID, holdID string) (Hold, error) {
+	ctx, span := tracing.Start(ctx, "orderservice.VoidHold")
+	defer span.End()
+
+	dbHold, err := s.repo.VoidHold(ctx, userID, holdID)
+	if err != nil {
+		return Hold{}, convertHoldError("void", err)
+	}
+
+	logger.FromContext(ctx).Info("orderservice: hold released",
+		zap.String("orderNumber", dbHold.OrderNumber), zap.String("holdID", holdID), zap.String("status", string(dbHold.Status)))
+	return convertHoldToDomain(dbHold), nil
+}
+
+func convertHoldError(op string, err error) error {
+	switch {
+	case errors.Is(err, repository.ErrHoldNotFound):
+		return ErrHoldNotFound
+	case errors.Is(err, repository.ErrHoldNotActive):
+		return ErrHoldNotActive
+	case errors.Is(err, repository.ErrHoldExpired):
+		return ErrHoldExpired
+	case errors.Is(err, repository.ErrOrderAlreadyExists):
+		return ErrHoldAlreadyExists
+	default:
+		return fmt.Errorf("orderservice: failed to %s hold: %w", op, err)
+	}
+}
+
+type HoldExpirer interface {
+	Run() error
+}
+
+type holdExpirer struct {
+	ctx      context.Context
+	repo     repository.Repository
+	interval time.Duration
+}
+
+// NewHoldExpirer creates a job that returns the points of expired holds to their owners.
+func NewHoldExpirer(ctx context.Context, repo repository.Repository, interval time.Duration) HoldExpirer {
+	return &holdExpirer{
+		ctx:      ctx,
+		repo:     repo,
+		interval: interval,
+	}
+}
+
+// Run expires holds until the context is cancelled.
+// It returns nil when it was stopped by the cancellation.
+func (e *holdExpirer) Run() error {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.ctx.Done():
+			logger.Log.Debug("holdexpirer: context was cancelled, stopped expiring holds")
+			return nil
+		case <-ticker.C:
+			err := e.expireHolds()
+			if err != nil {
+				logger.Log.Error("holdexpirer: failed to expire holds", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (e *holdExpirer) expireHolds() error {
+	for {
+		expired, err := e.repo.ExpireHolds(e.ctx, expireHoldsBatchSize)
+		if err != nil {
+			return err
+		}
+		if expired > 0 {
+			logger.Log.Info("holdexpirer: holds expired", zap.Int("count", expired))
+		}
+		if expired < expireHoldsBatchSize {
+			return nil
+		}
+	}
+}
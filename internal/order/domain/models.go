@@ -35,14 +35,189 @@ type Order struct {
 }
 
 type Balance struct {
-	Current   decimal.Decimal
-	Withdrawn decimal.Decimal
+	Current      decimal.Decimal
+	Withdrawn    decimal.Decimal
+	Held         decimal.Decimal
+	ExpiringSoon decimal.Decimal
+	// NextExpiry is zero when none of the points expire.
+	NextExpiry time.Time
 }
 
+type WithdrawalStatus string
+
+const (
+	WithdrawalStatusProcessed         WithdrawalStatus = "PROCESSED"
+	WithdrawalStatusPartiallyReversed WithdrawalStatus = "PARTIALLY_REVERSED"
+	WithdrawalStatusReversed          WithdrawalStatus = "REVERSED"
+)
+
 type Withdrawal struct {
 	ID          string
 	UserID      string
 	OrderNumber string
 	Sum         decimal.Decimal
+	Refunded    decimal.Decimal
 	ProcessedAt time.Time
 }
+
+// Status tells how much of the withdrawal has been refunded.
+func (w Withdrawal) Status() WithdrawalStatus {
+	switch {
+	case !w.Refunded.IsPositive():
+		return WithdrawalStatusProcessed
+	case w.Refunded.LessThan(w.Sum):
+		return WithdrawalStatusPartiallyReversed
+	default:
+		return WithdrawalStatusReversed
+	}
+}
+
+type HoldStatus string
+
+const (
+	HoldStatusActive   HoldStatus = "ACTIVE"
+	HoldStatusCaptured HoldStatus = "CAPTURED"
+	HoldStatusVoided   HoldStatus = "VOIDED"
+	HoldStatusExpired  HoldStatus = "EXPIRED"
+)
+
+type Hold struct {
+	ID          string
+	UserID      string
+	OrderNumber string
+	Sum         decimal.Decimal
+	Status      HoldStatus
+	ExpiresAt   time.Time
+	CreatedAt   time.Time
+}
+
+type TransferDirection string
+
+const (
+	TransferDirectionIn  TransferDirection = "IN"
+	TransferDirectionOut TransferDirection = "OUT"
+)
+
+// Transfer is a move of points between two users as one of them sees it.
+type Transfer struct {
+	ID        string
+	Direction TransferDirection
+	// Counterparty is the masked login of the other user.
+	Counterparty string
+	Sum          decimal.Decimal
+	CreatedAt    time.Time
+}
+
+type ReferralStatus string
+
+const (
+	ReferralStatusPending  ReferralStatus = "PENDING"
+	ReferralStatusRewarded ReferralStatus = "REWARDED"
+	ReferralStatusRejected ReferralStatus = "REJECTED"
+)
+
+// Referrals are the user's referral code and the users who registered with it.
+type Referrals struct {
+	Code      string
+	Referrals []Referral
+}
+
+type Referral struct {
+	// Referred is the masked login of the referred user.
+	Referred string
+	Status   ReferralStatus
+	// Reason tells why a rejected referral earned nothing.
+	Reason string
+	Reward decimal.Decimal
+	// SettledAt is zero while the referred user has no processed orders.
+	SettledAt time.Time
+	CreatedAt time.Time
+}
+
+// NewGiftCodeBatch describes the codes an admin asks to issue.
+type NewGiftCodeBatch struct {
+	Name           string
+	Count          int
+	Sum            decimal.Decimal
+	MaxRedemptions int
+	// ExpiresAt is zero for codes that never expire.
+	ExpiresAt time.Time
+}
+
+type GiftCodeBatch struct {
+	ID             string
+	Name           string
+	Sum            decimal.Decimal
+	MaxRedemptions int
+	// ExpiresAt is zero for codes that never expire.
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	Codes     []GiftCode
+}
+
+type GiftCode struct {
+	Code        string
+	Redemptions int
+}
+
+type GiftCodeRedemption struct {
+	ID         string
+	Code       string
+	Sum        decimal.Decimal
+	RedeemedAt time.Time
+}
+
+// Campaign adds points on top of the accrual of orders processed while it runs.
+type Campaign struct {
+	ID   string
+	Name string
+	// Multiplier of 1 leaves the accrual as it is, 2 doubles it.
+	Multiplier decimal.Decimal
+	Bonus      decimal.Decimal
+	// DaysOfWeek limits the campaign to some weekdays in UTC; empty means every day.
+	DaysOfWeek     []time.Weekday
+	FirstOrderOnly bool
+	// Cohort limits the campaign to its members; empty means every user.
+	Cohort   string
+	InCohort bool
+	// BudgetLeft is nil when the campaign budget is unlimited.
+	BudgetLeft *decimal.Decimal
+}
+
+// CampaignOrder is what campaign rules know about the order being processed.
+type CampaignOrder struct {
+	Accrual     decimal.Decimal
+	ProcessedAt time.Time
+	FirstOrder  bool
+}
+
+type CampaignBonus struct {
+	CampaignID     string
+	CampaignName   string
+	Amount         decimal.Decimal
+	FirstOrderOnly bool
+}
+
+// Tier is a loyalty level a user reaches by the points accrued over the policy window.
+type Tier struct {
+	Name      string
+	Threshold decimal.Decimal
+	// AccrualMultiplier of 1 leaves accruals as they are.
+	AccrualMultiplier decimal.Decimal
+	// WithdrawalLimit caps the points withdrawn or held per 24 hours; zero is unlimited.
+	WithdrawalLimit decimal.Decimal
+}
+
+type TierPolicy struct {
+	Window time.Duration
+	// Tiers are ordered by threshold, the first one starts at zero.
+	Tiers []Tier
+}
+
+type TierStatus struct {
+	Tier   Tier
+	Volume decimal.Decimal
+	// Next is nil for users in the top tier.
+	Next   *Tier
+	ToNext decimal.Decimal
+}
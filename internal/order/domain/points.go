@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	repository "github.com/aifedorov/gophermart/internal/order/repository/db"
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/aifedorov/gophermart/internal/pkg/metrics"
+	"go.uber.org/zap"
+)
+
+const expirePointsBatchSize = 100
+
+type PointExpirer interface {
+	Run() error
+}
+
+type pointExpirer struct {
+	ctx      context.Context
+	repo     repository.Repository
+	interval time.Duration
+}
+
+// NewPointExpirer creates a job that takes the unspent points of expired lots out of user balances.
+func NewPointExpirer(ctx context.Context, repo repository.Repository, interval time.Duration) PointExpirer {
+	return &pointExpirer{
+		ctx:      ctx,
+		repo:     repo,
+		interval: interval,
+	}
+}
+
+// Run expires points until the context is cancelled.
+// It returns nil when it was stopped by the cancellation.
+func (e *pointExpirer) Run() error {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.ctx.Done():
+			logger.Log.Debug("pointexpirer: context was cancelled, stopped expiring points")
+			return nil
+		case <-ticker.C:
+			err := e.expirePoints()
+			if err != nil {
+				logger.Log.Error("pointexpirer: failed to expire points", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (e *pointExpirer) expirePoints() error {
+	for {
+		lots, expired, err := e.repo.ExpirePoints(e.ctx, expirePointsBatchSize)
+		if expired.IsPositive() {
+			metrics.PointsExpired.Add(expired.InexactFloat64())
+			logger.Log.Info("pointexpirer: points expired", zap.Int("lots", lots), zap.String("sum", expired.String()))
+		}
+		if err != nil {
+			return err
+		}
+		if lots == 0 {
+			return nil
+		}
+	}
+}
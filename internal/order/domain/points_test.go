@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"context"
+	"testing"
+
+	orderMocks "github.com/aifedorov/gophermart/internal/order/repository/mocks"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPointExpirerExpirePoints(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		mock    func(mockRepo *orderMocks.MockRepository)
+		wantErr bool
+	}{
+		{
+			name: "nothing to expire",
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					ExpirePoints(gomock.Any(), int32(expirePointsBatchSize)).
+					Return(0, decimal.Zero, nil).
+					Times(1)
+			},
+		},
+		{
+			name: "repeats until no lots are left",
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				gomock.InOrder(
+					mockRepo.EXPECT().
+						ExpirePoints(gomock.Any(), int32(expirePointsBatchSize)).
+						Return(150, decimal.NewFromInt(900), nil),
+					mockRepo.EXPECT().
+						ExpirePoints(gomock.Any(), int32(expirePointsBatchSize)).
+						Return(3, decimal.NewFromInt(12), nil),
+					mockRepo.EXPECT().
+						ExpirePoints(gomock.Any(), int32(expirePointsBatchSize)).
+						Return(0, decimal.Zero, nil),
+				)
+			},
+		},
+		{
+			name: "stops on error",
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					ExpirePoints(gomock.Any(), int32(expirePointsBatchSize)).
+					Return(1, decimal.NewFromInt(5), assert.AnError).
+					Times(1)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := orderMocks.NewMockRepository(ctrl)
+			tt.mock(mockRepo)
+
+			expirer := NewPointExpirer(context.Background(), mockRepo, 0).(*pointExpirer)
+			err := expirer.expirePoints()
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
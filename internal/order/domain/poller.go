@@ -2,91 +2,130 @@ package domain
 
 import (
 	"context"
-	"time"
+	"errors"
+	"fmt"
+	"strconv"
 
 	"github.com/aifedorov/gophermart/internal/client/accrual"
 	repository "github.com/aifedorov/gophermart/internal/order/repository/db"
 	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/aifedorov/gophermart/internal/pkg/metrics"
+	"github.com/aifedorov/gophermart/internal/pkg/tracing"
 	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
+// Poller asks the accrual system about a single order once.
+// It returns ErrAccrualPending when the order has not reached a final status yet
+// and ErrAccrualUnavailable when the accrual circuit breaker is open.
 type Poller interface {
-	StartPollingWithOrderNumber(number string) error
+	CheckOrder(ctx context.Context, number string) error
+	Available() bool
 }
 
 type poller struct {
-	ctx           context.Context
 	repo          repository.Repository
 	accrualClient accrual.HTTPClient
+	limiter       RateLimiter
 }
 
-func NewPoller(ctx context.Context, repo repository.Repository, accrualClient accrual.HTTPClient) Poller {
+func NewPoller(repo repository.Repository, accrualClient accrual.HTTPClient, limiter RateLimiter) Poller {
 	return &poller{
-		ctx:           ctx,
 		repo:          repo,
 		accrualClient: accrualClient,
+		limiter:       limiter,
 	}
 }
 
-var pollingTimeouts = []time.Duration{
-	200 * time.Millisecond,
-	400 * time.Millisecond,
-	600 * time.Millisecond,
-	1000 * time.Millisecond,
-	1600 * time.Millisecond,
-	2600 * time.Millisecond,
-	4200 * time.Millisecond,
+func (p *poller) Available() bool {
+	return p.accrualClient.BreakerState() != accrual.BreakerOpen
 }
 
-func (p *poller) StartPollingWithOrderNumber(number string) error {
-	for _, timeout := range pollingTimeouts {
-		select {
-		case <-p.ctx.Done():
-			logger.Log.Debug("poller: context was cancelled")
-			return p.ctx.Err()
-		default:
+func (p *poller) CheckOrder(ctx context.Context, number string) (err error) {
+	ctx, span := tracing.Start(ctx, "poller.CheckOrder", tracing.OrderNumber(number))
+	defer func() {
+		if !errors.Is(err, ErrAccrualPending) {
+			tracing.RecordError(span, err)
+		}
+		span.End()
+	}()
+
+	order, err := p.repo.GetOrderByNumber(ctx, number)
+	if err != nil {
+		return err
+	}
+	if IsFinalStatus(convertStatusToDomain(order.Status)) {
+		logger.Log.Debug("poller: order is already final", zap.String("orderNumber", number), zap.Any("order status", order.Status))
+		return nil
+	}
+
+	for {
+		if err := p.limiter.Wait(ctx); err != nil {
+			return err
 		}
 
-		logger.Log.Debug("poller: star polling", zap.String("orderNumber", number))
-		res, ok, err := p.accrualClient.GetAccrualByOrderNumber(number)
+		logger.Log.Debug("poller: checking order", zap.String("orderNumber", number))
+		res, err := p.accrualClient.GetAccrualByOrderNumber(ctx, number)
+		recordAccrualCall(res, err)
+		if errors.Is(err, accrual.ErrCircuitOpen) {
+			return ErrAccrualUnavailable
+		}
 		if err != nil {
 			return err
 		}
-		if !ok {
+
+		switch res.Outcome {
+		case accrual.OutcomeThrottled:
+			// Throttling is not the order's fault, so wait for the window instead of failing the attempt.
+			p.limiter.Pause(res.RetryAfter)
 			continue
+		case accrual.OutcomeNotRegistered:
+			logger.Log.Debug("poller: order is not registered in accrual yet", zap.String("orderNumber", number))
+			return ErrAccrualPending
+		case accrual.OutcomeServerError:
+			return fmt.Errorf("poller: accrual system responded with status %d", res.StatusCode)
 		}
-		switch res.Status {
+
+		switch res.Order.Status {
 		case accrual.StatusRegistered, accrual.StatusProcessing:
-			logger.Log.Debug("poller: status isn't terminated", zap.Any("order status", res.Status))
+			logger.Log.Debug("poller: status isn't terminated", zap.Any("order status", res.Order.Status))
+			if order.Status == repository.OrderstatusNEW {
+				if err := transitionOrder(ctx, p.repo, order, StatusProcessing, nil); err != nil {
+					return err
+				}
+			}
+			return ErrAccrualPending
 		case accrual.StatusInvalid:
-			err := p.repo.UpdateOrderStatusByNumber(number, repository.OrderstatusINVALID, nil)
-			if err != nil {
+			if err := transitionOrder(ctx, p.repo, order, StatusInvalid, nil); err != nil {
 				return err
 			}
-			logger.Log.Debug("poller: finish polling", zap.String("orderNumber", number), zap.Any("order status", res.Status))
+			logger.Log.Debug("poller: finish polling", zap.String("orderNumber", number), zap.Any("order status", res.Order.Status))
 			return nil
 		case accrual.StatusProcessed:
 			var amount decimal.Decimal
-			if res.Amount != nil {
-				amount = decimal.NewFromFloat(*res.Amount)
+			if res.Order.Amount != nil {
+				amount = decimal.NewFromFloat(*res.Order.Amount)
 			}
 
-			err := p.repo.UpdateOrderStatusByNumber(number, repository.OrderstatusPROCESSED, &amount)
-			if err != nil {
+			if err := transitionOrder(ctx, p.repo, order, StatusProcessed, &amount); err != nil {
 				return err
 			}
-			logger.Log.Debug("poller: finish polling", zap.String("orderNumber", number), zap.Any("order status", res.Status), zap.Any("amount", res.Amount))
+			logger.Log.Debug("poller: finish polling", zap.String("orderNumber", number), zap.Any("order status", res.Order.Status), zap.Any("amount", res.Order.Amount))
 			return nil
+		default:
+			logger.Log.Warn("poller: unknown accrual status", zap.String("orderNumber", number), zap.Any("order status", res.Order.Status))
+			return ErrAccrualPending
 		}
-		time.Sleep(timeout)
 	}
-	logger.Log.Debug("poller: finish unsuccessful polling", zap.String("orderNumber", number))
+}
 
-	err := p.repo.UpdateOrderStatusByNumber(number, repository.OrderstatusINVALID, nil)
-	if err != nil {
-		return err
+func recordAccrualCall(res accrual.Result, err error) {
+	switch {
+	case errors.Is(err, accrual.ErrCircuitOpen):
+		metrics.AccrualRequests.WithLabelValues("none", "circuit_open").Inc()
+	case err != nil:
+		metrics.AccrualRequests.WithLabelValues("none", "error").Inc()
+	default:
+		metrics.AccrualRequests.WithLabelValues(strconv.Itoa(res.StatusCode), res.Outcome.String()).Inc()
 	}
-
-	return nil
 }
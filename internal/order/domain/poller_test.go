@@ -0,0 +1,217 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aifedorov/gophermart/internal/client/accrual"
+	repository "github.com/aifedorov/gophermart/internal/order/repository/db"
+	orderMocks "github.com/aifedorov/gophermart/internal/order/repository/mocks"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+type accrualResult struct {
+	res accrual.Result
+	err error
+}
+
+type stubAccrualClient struct {
+	results []accrualResult
+	calls   int
+}
+
+func (c *stubAccrualClient) GetAccrualByOrderNumber(ctx context.Context, orderNumber string) (accrual.Result, error) {
+	r := c.results[c.calls]
+	c.calls++
+	return r.res, r.err
+}
+
+func (c *stubAccrualClient) BreakerState() accrual.BreakerState {
+	return accrual.BreakerClosed
+}
+
+func (c *stubAccrualClient) Close() error {
+	return nil
+}
+
+func TestPollerCheckOrder(t *testing.T) {
+	t.Parallel()
+
+	amount := 500.0
+
+	tests := []struct {
+		name    string
+		status  repository.Orderstatus
+		results []accrualResult
+		wantErr error
+		mock    func(mockRepo *orderMocks.MockRepository)
+	}{
+		{
+			name: "processed order is stored with accrual",
+			results: []accrualResult{
+				{res: accrual.Result{Outcome: accrual.OutcomeOK, Order: accrual.OrderResponse{Number: testOrderNumber, Status: accrual.StatusProcessed, Amount: &amount}}},
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				accrualAmount := decimal.NewFromFloat(amount)
+				mockRepo.EXPECT().
+					GetActiveCampaigns(gomock.Any(), uuid.Nil.String(), gomock.Any()).
+					Return(nil, nil).
+					Times(1)
+				mockRepo.EXPECT().
+					GetUserTier(gomock.Any(), uuid.Nil.String()).
+					Return(repository.UserTier{}, nil).
+					Times(1)
+				mockRepo.EXPECT().
+					UpdateOrderStatus(gomock.Any(), testOrderNumber, repository.OrderstatusNEW, repository.OrderstatusPROCESSED, &accrualAmount, repository.OrderBonuses{}).
+					Return(repository.OrderCredits{Accrual: accrualAmount}, nil).
+					Times(1)
+			},
+		},
+		{
+			name: "processed order gets campaign and tier bonuses",
+			results: []accrualResult{
+				{res: accrual.Result{Outcome: accrual.OutcomeOK, Order: accrual.OrderResponse{Number: testOrderNumber, Status: accrual.StatusProcessed, Amount: &amount}}},
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				accrualAmount := decimal.NewFromFloat(amount)
+				doublePoints := uuid.MustParse("00000000-0000-0000-0000-00000000000a")
+				welcome := uuid.MustParse("00000000-0000-0000-0000-00000000000b")
+				mockRepo.EXPECT().
+					GetActiveCampaigns(gomock.Any(), uuid.Nil.String(), gomock.Any()).
+					Return([]repository.GetActiveCampaignsByUserIDRow{
+						{Campaign: repository.Campaign{ID: doublePoints, Multiplier: decimal.NewFromInt(2)}},
+						{Campaign: repository.Campaign{ID: welcome, Multiplier: decimal.NewFromInt(1), Bonus: decimal.NewFromInt(100), FirstOrderOnly: true}},
+					}, nil).
+					Times(1)
+				mockRepo.EXPECT().
+					CountProcessedOrders(gomock.Any(), uuid.Nil.String(), uuid.Nil).
+					Return(int64(0), nil).
+					Times(1)
+				mockRepo.EXPECT().
+					GetUserTier(gomock.Any(), uuid.Nil.String()).
+					Return(repository.UserTier{Tier: "GOLD", AccrualMultiplier: decimal.NewFromFloat(1.25)}, nil).
+					Times(1)
+				mockRepo.EXPECT().
+					UpdateOrderStatus(gomock.Any(), testOrderNumber, repository.OrderstatusNEW, repository.OrderstatusPROCESSED, &accrualAmount, repository.OrderBonuses{
+						Campaigns: []repository.CampaignBonus{
+							{CampaignID: doublePoints, Amount: decimal.NewFromInt(500)},
+							{CampaignID: welcome, Amount: decimal.NewFromInt(100), FirstOrderOnly: true},
+						},
+						Tier: &repository.TierBonus{Tier: "GOLD", Amount: decimal.NewFromInt(125)},
+					}).
+					Return(repository.OrderCredits{
+						Accrual:  accrualAmount,
+						Campaign: decimal.NewFromInt(600),
+						Tier:     decimal.NewFromInt(125),
+					}, nil).
+					Times(1)
+			},
+		},
+		{
+			name: "invalid order from accrual",
+			results: []accrualResult{
+				{res: accrual.Result{Outcome: accrual.OutcomeOK, Order: accrual.OrderResponse{Number: testOrderNumber, Status: accrual.StatusInvalid}}},
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					UpdateOrderStatus(gomock.Any(), testOrderNumber, repository.OrderstatusNEW, repository.OrderstatusINVALID, nil, repository.OrderBonuses{}).
+					Return(repository.OrderCredits{}, nil).
+					Times(1)
+			},
+		},
+		{
+			name: "registered order moves to processing",
+			results: []accrualResult{
+				{res: accrual.Result{Outcome: accrual.OutcomeOK, Order: accrual.OrderResponse{Number: testOrderNumber, Status: accrual.StatusRegistered}}},
+			},
+			wantErr: ErrAccrualPending,
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					UpdateOrderStatus(gomock.Any(), testOrderNumber, repository.OrderstatusNEW, repository.OrderstatusPROCESSING, nil, repository.OrderBonuses{}).
+					Return(repository.OrderCredits{}, nil).
+					Times(1)
+			},
+		},
+		{
+			name:   "processing order is not transitioned again",
+			status: repository.OrderstatusPROCESSING,
+			results: []accrualResult{
+				{res: accrual.Result{Outcome: accrual.OutcomeOK, Order: accrual.OrderResponse{Number: testOrderNumber, Status: accrual.StatusProcessing}}},
+			},
+			wantErr: ErrAccrualPending,
+			mock:    func(mockRepo *orderMocks.MockRepository) {},
+		},
+		{
+			name:    "final order is not polled",
+			status:  repository.OrderstatusPROCESSED,
+			results: nil,
+			mock:    func(mockRepo *orderMocks.MockRepository) {},
+		},
+		{
+			name: "not registered order stays pending",
+			results: []accrualResult{
+				{res: accrual.Result{Outcome: accrual.OutcomeNotRegistered}},
+			},
+			wantErr: ErrAccrualPending,
+			mock:    func(mockRepo *orderMocks.MockRepository) {},
+		},
+		{
+			name: "open circuit breaker makes accrual unavailable",
+			results: []accrualResult{
+				{err: accrual.ErrCircuitOpen},
+			},
+			wantErr: ErrAccrualUnavailable,
+			mock:    func(mockRepo *orderMocks.MockRepository) {},
+		},
+		{
+			name: "rate limit is retried after pause",
+			results: []accrualResult{
+				{res: accrual.Result{Outcome: accrual.OutcomeThrottled, RetryAfter: 10 * time.Millisecond}},
+				{res: accrual.Result{Outcome: accrual.OutcomeOK, Order: accrual.OrderResponse{Number: testOrderNumber, Status: accrual.StatusInvalid}}},
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					UpdateOrderStatus(gomock.Any(), testOrderNumber, repository.OrderstatusNEW, repository.OrderstatusINVALID, nil, repository.OrderBonuses{}).
+					Return(repository.OrderCredits{}, nil).
+					Times(1)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			status := tt.status
+			if status == "" {
+				status = repository.OrderstatusNEW
+			}
+
+			mockRepo := orderMocks.NewMockRepository(ctrl)
+			mockRepo.EXPECT().
+				GetOrderByNumber(gomock.Any(), testOrderNumber).
+				Return(repository.Order{Number: testOrderNumber, Status: status}, nil).
+				Times(1)
+			tt.mock(mockRepo)
+
+			client := &stubAccrualClient{results: tt.results}
+			p := NewPoller(mockRepo, client, NewRateLimiter())
+
+			err := p.CheckOrder(context.Background(), testOrderNumber)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, len(tt.results), client.calls)
+		})
+	}
+}
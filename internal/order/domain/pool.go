@@ -0,0 +1,189 @@
+package domain
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Task func(ctx context.Context)
+
+type PoolStats struct {
+	Workers     int
+	BusyWorkers int
+	QueueLen    int
+	QueueCap    int
+}
+
+// WorkerPool runs accrual polling tasks on a fixed number of workers.
+// Every task gets its own deadline derived from the pool context.
+type WorkerPool interface {
+	Submit(task Task) bool
+	Stats() PoolStats
+	Wait()
+	Shutdown(ctx context.Context) error
+}
+
+type workerPool struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	tasks       chan Task
+	workers     int
+	busy        atomic.Int32
+	taskTimeout time.Duration
+	wg          sync.WaitGroup
+
+	stopOnce sync.Once
+	draining chan struct{}
+
+	// mu lets a stopping pool wait for Submit calls in flight, so no task is queued
+	// after the queue has been drained.
+	mu     sync.RWMutex
+	closed bool
+}
+
+func NewWorkerPool(ctx context.Context, workers, queueSize int, taskTimeout time.Duration) WorkerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	p := &workerPool{
+		ctx:         ctx,
+		cancel:      cancel,
+		tasks:       make(chan Task, queueSize),
+		workers:     workers,
+		taskTimeout: taskTimeout,
+		draining:    make(chan struct{}),
+	}
+
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// Submit enqueues the task without blocking. It returns false when the queue is full
+// or the pool is stopped, so the caller can leave the job for later.
+func (p *workerPool) Submit(task Task) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed || p.ctx.Err() != nil || p.isDraining() {
+		return false
+	}
+
+	select {
+	case p.tasks <- task:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *workerPool) Stats() PoolStats {
+	return PoolStats{
+		Workers:     p.workers,
+		BusyWorkers: int(p.busy.Load()),
+		QueueLen:    len(p.tasks),
+		QueueCap:    cap(p.tasks),
+	}
+}
+
+// Wait blocks until all workers have exited after the pool context is cancelled.
+// Tasks still in the queue are run with the cancelled context before the workers exit.
+func (p *workerPool) Wait() {
+	p.wg.Wait()
+}
+
+// Shutdown stops accepting tasks and waits for running ones to finish. Tasks still
+// waiting in the queue are run with a cancelled context so they can hand their work
+// back. When ctx expires first, running tasks are cancelled as well.
+func (p *workerPool) Shutdown(ctx context.Context) error {
+	p.stopOnce.Do(func() {
+		p.close()
+		close(p.draining)
+	})
+
+	stopped := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		<-stopped
+		return ctx.Err()
+	}
+}
+
+// close stops Submit from queueing tasks.
+func (p *workerPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+}
+
+func (p *workerPool) isDraining() bool {
+	select {
+	case <-p.draining:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *workerPool) work() {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			// Queued tasks still hold claimed jobs, so they get the cancelled context to hand them back.
+			p.close()
+			p.drain()
+			return
+		case <-p.draining:
+			p.drain()
+			return
+		case task := <-p.tasks:
+			p.run(task)
+		}
+	}
+}
+
+// drain hands every queued task a cancelled context until the queue is empty.
+func (p *workerPool) drain() {
+	for {
+		select {
+		case task := <-p.tasks:
+			p.run(task)
+		default:
+			return
+		}
+	}
+}
+
+func (p *workerPool) run(task Task) {
+	p.busy.Add(1)
+	defer p.busy.Add(-1)
+
+	ctx, cancel := context.WithTimeout(p.ctx, p.taskTimeout)
+	defer cancel()
+	if p.isDraining() {
+		cancel()
+	}
+
+	task(ctx)
+}
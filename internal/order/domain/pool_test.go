@@ -0,0 +1,180 @@
+package domain
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerPool(t *testing.T) {
+	t.Parallel()
+
+	t.Run("runs submitted tasks", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		pool := NewWorkerPool(ctx, 2, 4, time.Second)
+		var done atomic.Int32
+		finished := make(chan struct{}, 4)
+		for i := 0; i < 4; i++ {
+			require.True(t, pool.Submit(func(ctx context.Context) {
+				done.Add(1)
+				finished <- struct{}{}
+			}))
+		}
+		for i := 0; i < 4; i++ {
+			<-finished
+		}
+		assert.Equal(t, int32(4), done.Load())
+	})
+
+	t.Run("rejects tasks when queue is full", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		pool := NewWorkerPool(ctx, 1, 1, time.Second)
+		started := make(chan struct{})
+		release := make(chan struct{})
+		require.True(t, pool.Submit(func(ctx context.Context) {
+			close(started)
+			<-release
+		}))
+		<-started
+
+		require.True(t, pool.Submit(func(ctx context.Context) {}))
+		assert.False(t, pool.Submit(func(ctx context.Context) {}))
+
+		stats := pool.Stats()
+		assert.Equal(t, PoolStats{Workers: 1, BusyWorkers: 1, QueueLen: 1, QueueCap: 1}, stats)
+		close(release)
+	})
+
+	t.Run("applies per task deadline", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		pool := NewWorkerPool(ctx, 1, 1, 20*time.Millisecond)
+		result := make(chan error, 1)
+		require.True(t, pool.Submit(func(ctx context.Context) {
+			<-ctx.Done()
+			result <- ctx.Err()
+		}))
+		assert.ErrorIs(t, <-result, context.DeadlineExceeded)
+	})
+
+	t.Run("stops on context cancellation", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		pool := NewWorkerPool(ctx, 3, 1, time.Minute)
+		started := make(chan struct{})
+		require.True(t, pool.Submit(func(ctx context.Context) {
+			close(started)
+			<-ctx.Done()
+		}))
+		<-started
+
+		cancel()
+		stopped := make(chan struct{})
+		go func() {
+			pool.Wait()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-time.After(time.Second):
+			t.Fatal("worker pool did not stop")
+		}
+		assert.False(t, pool.Submit(func(ctx context.Context) {}))
+	})
+
+	t.Run("runs queued tasks with cancelled context on context cancellation", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		pool := NewWorkerPool(ctx, 1, 2, time.Minute)
+		started := make(chan struct{})
+		require.True(t, pool.Submit(func(ctx context.Context) {
+			close(started)
+			<-ctx.Done()
+		}))
+		<-started
+
+		queued := make(chan error, 2)
+		for i := 0; i < 2; i++ {
+			require.True(t, pool.Submit(func(ctx context.Context) {
+				queued <- ctx.Err()
+			}))
+		}
+
+		cancel()
+		pool.Wait()
+		require.Len(t, queued, 2)
+		assert.ErrorIs(t, <-queued, context.Canceled)
+		assert.ErrorIs(t, <-queued, context.Canceled)
+		assert.Equal(t, 0, pool.Stats().QueueLen)
+	})
+
+	t.Run("shutdown waits for running tasks and cancels queued ones", func(t *testing.T) {
+		t.Parallel()
+
+		pool := NewWorkerPool(context.Background(), 1, 2, time.Minute)
+		started := make(chan struct{})
+		release := make(chan struct{})
+		var finished atomic.Bool
+		require.True(t, pool.Submit(func(ctx context.Context) {
+			close(started)
+			<-release
+			finished.Store(ctx.Err() == nil)
+		}))
+		<-started
+
+		queued := make(chan error, 1)
+		require.True(t, pool.Submit(func(ctx context.Context) {
+			queued <- ctx.Err()
+		}))
+
+		shutdownErr := make(chan error, 1)
+		go func() {
+			shutdownErr <- pool.Shutdown(context.Background())
+		}()
+		assert.Eventually(t, func() bool {
+			return !pool.Submit(func(ctx context.Context) {})
+		}, time.Second, time.Millisecond)
+
+		close(release)
+		assert.NoError(t, <-shutdownErr)
+		assert.True(t, finished.Load())
+		assert.ErrorIs(t, <-queued, context.Canceled)
+	})
+
+	t.Run("shutdown cancels running tasks after deadline", func(t *testing.T) {
+		t.Parallel()
+
+		pool := NewWorkerPool(context.Background(), 1, 1, time.Minute)
+		started := make(chan struct{})
+		result := make(chan error, 1)
+		require.True(t, pool.Submit(func(ctx context.Context) {
+			close(started)
+			<-ctx.Done()
+			result <- ctx.Err()
+		}))
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, pool.Shutdown(ctx), context.DeadlineExceeded)
+		assert.ErrorIs(t, <-result, context.Canceled)
+	})
+}
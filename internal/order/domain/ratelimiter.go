@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// RateLimiter is shared by all pollers so that a single 429 from the accrual system
+// pauses every in-flight request until the window reopens.
+type RateLimiter interface {
+	Wait(ctx context.Context) error
+	Pause(d time.Duration)
+}
+
+type rateLimiter struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+func NewRateLimiter() RateLimiter {
+	return &rateLimiter{}
+}
+
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	for {
+		delay := l.delay()
+		if delay <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (l *rateLimiter) Pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	until := time.Now().Add(d)
+	if until.After(l.until) {
+		l.until = until
+		logger.Log.Info("ratelimiter: accrual requests paused", zap.Time("until", until))
+	}
+}
+
+func (l *rateLimiter) delay() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return time.Until(l.until)
+}
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("does not block without pause", func(t *testing.T) {
+		t.Parallel()
+
+		limiter := NewRateLimiter()
+		start := time.Now()
+		require.NoError(t, limiter.Wait(context.Background()))
+		assert.Less(t, time.Since(start), 50*time.Millisecond)
+	})
+
+	t.Run("blocks all waiters until pause is over", func(t *testing.T) {
+		t.Parallel()
+
+		limiter := NewRateLimiter()
+		limiter.Pause(100 * time.Millisecond)
+
+		start := time.Now()
+		done := make(chan time.Duration, 3)
+		for i := 0; i < 3; i++ {
+			go func() {
+				_ = limiter.Wait(context.Background())
+				done <- time.Since(start)
+			}()
+		}
+		for i := 0; i < 3; i++ {
+			assert.GreaterOrEqual(t, <-done, 90*time.Millisecond)
+		}
+	})
+
+	t.Run("shorter pause does not shrink the window", func(t *testing.T) {
+		t.Parallel()
+
+		limiter := NewRateLimiter()
+		limiter.Pause(100 * time.Millisecond)
+		limiter.Pause(10 * time.Millisecond)
+
+		start := time.Now()
+		require.NoError(t, limiter.Wait(context.Background()))
+		assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+	})
+
+	t.Run("returns on context cancellation", func(t *testing.T) {
+		t.Parallel()
+
+		limiter := NewRateLimiter()
+		limiter.Pause(time.Minute)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, limiter.Wait(ctx), context.DeadlineExceeded)
+	})
+}
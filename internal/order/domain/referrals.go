@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aifedorov/gophermart/internal/pkg/tracing"
+)
+
+func (s *service) GetReferrals(ctx context.Context, userID string) (Referrals, error) {
+	ctx, span := tracing.Start(ctx, "orderservice.GetReferrals")
+	defer span.End()
+
+	code, dbReferrals, err := s.repo.GetReferrals(ctx, userID)
+	if err != nil {
+		return Referrals{}, fmt.Errorf("orderservice: failed to get referrals: %w", err)
+	}
+
+	referrals := make([]Referral, len(dbReferrals))
+	for i, dbReferral := range dbReferrals {
+		referrals[i] = convertReferralToDomain(dbReferral)
+	}
+	return Referrals{
+		Code:      code,
+		Referrals: referrals,
+	}, nil
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy controls how accrual jobs are rescheduled while the accrual system
+// has no final status for an order.
+type RetryPolicy struct {
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// MaxAge is a hard deadline after which a pending order is marked INVALID.
+	// Zero means orders are retried forever.
+	MaxAge time.Duration
+}
+
+// Backoff returns an exponential delay with equal jitter for the given attempt, starting from 1.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+func (p RetryPolicy) Expired(createdAt, now time.Time) bool {
+	return p.MaxAge > 0 && now.Sub(createdAt) > p.MaxAge
+}
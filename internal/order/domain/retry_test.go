@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	t.Parallel()
+
+	policy := RetryPolicy{
+		BaseDelay: time.Second,
+		MaxDelay:  10 * time.Second,
+	}
+
+	tests := []struct {
+		name    string
+		attempt int
+		min     time.Duration
+		max     time.Duration
+	}{
+		{name: "first attempt", attempt: 1, min: 500 * time.Millisecond, max: time.Second},
+		{name: "third attempt", attempt: 3, min: 2 * time.Second, max: 4 * time.Second},
+		{name: "capped by max delay", attempt: 10, min: 5 * time.Second, max: 10 * time.Second},
+		{name: "huge attempt does not overflow", attempt: 1000, min: 5 * time.Second, max: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			for i := 0; i < 100; i++ {
+				delay := policy.Backoff(tt.attempt)
+				assert.GreaterOrEqual(t, delay, tt.min)
+				assert.LessOrEqual(t, delay, tt.max)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyExpired(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	assert.False(t, RetryPolicy{}.Expired(now.Add(-24*time.Hour), now), "zero max age never expires")
+	assert.False(t, RetryPolicy{MaxAge: time.Hour}.Expired(now.Add(-time.Minute), now))
+	assert.True(t, RetryPolicy{MaxAge: time.Hour}.Expired(now.Add(-2*time.Hour), now))
+}
@@ -1,52 +1,89 @@
 package domain
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/aifedorov/gophermart/internal/order/repository/db"
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/aifedorov/gophermart/internal/pkg/metrics"
+	"github.com/aifedorov/gophermart/internal/pkg/tracing"
 	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
 )
 
 type Service interface {
-	CreateOrder(userID, number string) (*Order, CreateStatus, error)
-	GetUserOrders(userID string) ([]Order, error)
-	GetUserBalance(userID string) (Balance, error)
-	Withdraw(userID, orderNumber string, amount decimal.Decimal) (Withdrawal, CreateStatus, error)
-	GetWithdrawals(userID string) ([]Withdrawal, error)
+	CreateOrder(ctx context.Context, userID, number string) (*Order, CreateStatus, error)
+	GetUserOrders(ctx context.Context, userID string) ([]Order, error)
+	GetUserBalance(ctx context.Context, userID string) (Balance, error)
+	Withdraw(ctx context.Context, userID, orderNumber string, amount decimal.Decimal) (Withdrawal, CreateStatus, error)
+	GetWithdrawals(ctx context.Context, userID string) ([]Withdrawal, error)
+	RefundWithdrawal(ctx context.Context, userID, orderNumber string, amount decimal.Decimal) (Withdrawal, error)
+	CreateHold(ctx context.Context, userID, orderNumber string, amount decimal.Decimal, ttl time.Duration) (Hold, error)
+	CaptureHold(ctx context.Context, userID, holdID string) (Hold, error)
+	VoidHold(ctx context.Context, userID, holdID string) (Hold, error)
+	GetUserTier(ctx context.Context, userID string) (TierStatus, error)
+	Transfer(ctx context.Context, userID, recipientLogin string, amount decimal.Decimal) (Transfer, error)
+	GetTransfers(ctx context.Context, userID string) ([]Transfer, error)
+	CreateGiftCodeBatch(ctx context.Context, batch NewGiftCodeBatch) (GiftCodeBatch, error)
+	GetGiftCodeBatch(ctx context.Context, batchID string) (GiftCodeBatch, error)
+	RedeemGiftCode(ctx context.Context, userID, code string) (GiftCodeRedemption, error)
+	GetReferrals(ctx context.Context, userID string) (Referrals, error)
 }
+
+// Config holds the business policies the service applies.
+type Config struct {
+	Tiers TierPolicy
+	// TransferDailyLimit caps the points a user sends to others per 24 hours; zero is unlimited.
+	TransferDailyLimit decimal.Decimal
+}
+
 type service struct {
 	repo repository.Repository
+	cfg  Config
 }
 
-func NewService(repo repository.Repository) Service {
+func NewService(repo repository.Repository, cfg Config) Service {
 	return &service{
 		repo: repo,
+		cfg:  cfg,
 	}
 }
 
-func (s *service) CreateOrder(userID, number string) (*Order, CreateStatus, error) {
+func (s *service) CreateOrder(ctx context.Context, userID, number string) (*Order, CreateStatus, error) {
+	ctx, span := tracing.Start(ctx, "orderservice.CreateOrder", tracing.OrderNumber(number))
+	defer span.End()
+
 	if !IsValidOrderNumber(number) {
 		return nil, CreateStatusFailed, ErrInvalidOrderNumber
 	}
 
-	dbOrder, isCreated, err := s.repo.CreateTopUpOrder(userID, number)
+	dbOrder, isCreated, err := s.repo.CreateTopUpOrder(ctx, userID, number)
 	if err != nil {
 		return nil, CreateStatusFailed, fmt.Errorf("orderservice: failed to create order: %w", err)
 	}
 
+	log := logger.FromContext(ctx)
 	domainOrder := convertOrderToDomain(dbOrder)
 	if !isCreated {
 		if dbOrder.UserID.String() == userID {
+			log.Debug("orderservice: order already uploaded by the user", zap.String("orderNumber", number))
 			return &domainOrder, CreateStatusAlreadyUploaded, nil
 		}
+		log.Info("orderservice: order uploaded by another user", zap.String("orderNumber", number))
 		return &domainOrder, CreateStatusUploadedByAnotherUser, nil
 	}
+	log.Info("orderservice: order created", zap.String("orderNumber", number))
 	return &domainOrder, CreateStatusSuccess, nil
 }
 
-func (s *service) GetUserOrders(userID string) ([]Order, error) {
-	dbOrders, err := s.repo.GetOrdersByUserID(userID)
+func (s *service) GetUserOrders(ctx context.Context, userID string) ([]Order, error) {
+	ctx, span := tracing.Start(ctx, "orderservice.GetUserOrders")
+	defer span.End()
+
+	dbOrders, err := s.repo.GetOrdersByUserID(ctx, userID)
 	if errors.Is(err, repository.ErrOrderAlreadyExists) {
 		return nil, ErrOrderNotFound
 	}
@@ -63,24 +100,33 @@ func (s *service) GetUserOrders(userID string) ([]Order, error) {
 	return result, nil
 }
 
-func (s *service) GetUserBalance(userID string) (Balance, error) {
-	balance, err := s.repo.GetUserBalanceByUserID(userID)
+func (s *service) GetUserBalance(ctx context.Context, userID string) (Balance, error) {
+	ctx, span := tracing.Start(ctx, "orderservice.GetUserBalance")
+	defer span.End()
+
+	balance, err := s.repo.GetUserBalanceByUserID(ctx, userID)
 	if err != nil {
 		return Balance{}, fmt.Errorf("orderservice: failed to get user balance: %w", err)
 	}
 
-	withdrawn, err := s.repo.GetUserWithdrawByUserID(userID)
+	expiry, err := s.repo.GetPointsExpiry(ctx, userID)
 	if err != nil {
-		return Balance{}, fmt.Errorf("orderservice: failed to get user withdrawn: %w", err)
+		return Balance{}, fmt.Errorf("orderservice: failed to get points expiry: %w", err)
 	}
 
 	return Balance{
-		Current:   balance,
-		Withdrawn: withdrawn,
+		Current:      balance.Current,
+		Withdrawn:    balance.Withdrawn,
+		Held:         balance.Held,
+		ExpiringSoon: expiry.ExpiringSoon,
+		NextExpiry:   expiry.NextExpiry.Time,
 	}, nil
 }
 
-func (s *service) Withdraw(userID, orderNumber string, amount decimal.Decimal) (Withdrawal, CreateStatus, error) {
+func (s *service) Withdraw(ctx context.Context, userID, orderNumber string, amount decimal.Decimal) (Withdrawal, CreateStatus, error) {
+	ctx, span := tracing.Start(ctx, "orderservice.Withdraw", tracing.OrderNumber(orderNumber))
+	defer span.End()
+
 	if !IsValidOrderNumber(orderNumber) {
 		return Withdrawal{}, CreateStatusFailed, ErrInvalidOrderNumber
 	}
@@ -88,16 +134,24 @@ func (s *service) Withdraw(userID, orderNumber string, amount decimal.Decimal) (
 		return Withdrawal{}, CreateStatusFailed, ErrWithdrawNegativeAmount
 	}
 
-	order, err := s.repo.CreateWithdrawalOrder(userID, orderNumber, amount)
+	order, err := s.repo.CreateWithdrawalOrder(ctx, userID, orderNumber, amount)
 	if errors.Is(err, repository.ErrOrderAlreadyExists) {
 		return Withdrawal{}, CreateStatusAlreadyUploaded, nil
 	}
 	if errors.Is(err, repository.ErrWithdrawInsufficientFunds) {
+		logger.FromContext(ctx).Info("orderservice: insufficient funds to withdraw", zap.String("orderNumber", orderNumber))
 		return Withdrawal{}, CreateStatusFailed, ErrWithdrawInsufficientFunds
 	}
+	if errors.Is(err, repository.ErrWithdrawLimitExceeded) {
+		logger.FromContext(ctx).Info("orderservice: withdrawal limit exceeded", zap.String("orderNumber", orderNumber))
+		return Withdrawal{}, CreateStatusFailed, ErrWithdrawLimitExceeded
+	}
 	if err != nil {
 		return Withdrawal{}, CreateStatusFailed, fmt.Errorf("orderservice: failed to create order: %w", err)
 	}
+
+	logger.FromContext(ctx).Info("orderservice: points withdrawn", zap.String("orderNumber", orderNumber), zap.String("sum", amount.String()))
+	metrics.PointsWithdrawn.Add(amount.InexactFloat64())
 	return Withdrawal{
 		ID:          order.ID.String(),
 		UserID:      order.UserID.String(),
@@ -107,8 +161,11 @@ func (s *service) Withdraw(userID, orderNumber string, amount decimal.Decimal) (
 	}, CreateStatusSuccess, nil
 }
 
-func (s *service) GetWithdrawals(userID string) ([]Withdrawal, error) {
-	dbOrders, err := s.repo.GetWithdrawalsByUserID(userID)
+func (s *service) GetWithdrawals(ctx context.Context, userID string) ([]Withdrawal, error) {
+	ctx, span := tracing.Start(ctx, "orderservice.GetWithdrawals")
+	defer span.End()
+
+	dbOrders, err := s.repo.GetWithdrawalsByUserID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("orderservice: failed to get user orders: %w", err)
 	}
@@ -122,3 +179,28 @@ func (s *service) GetWithdrawals(userID string) ([]Withdrawal, error) {
 	}
 	return domainWithdrawals, nil
 }
+
+func (s *service) RefundWithdrawal(ctx context.Context, userID, orderNumber string, amount decimal.Decimal) (Withdrawal, error) {
+	ctx, span := tracing.Start(ctx, "orderservice.RefundWithdrawal", tracing.OrderNumber(orderNumber))
+	defer span.End()
+
+	if !amount.IsPositive() {
+		return Withdrawal{}, ErrRefundNegativeAmount
+	}
+
+	dbWithdrawal, err := s.repo.RefundWithdrawal(ctx, userID, orderNumber, amount)
+	if errors.Is(err, repository.ErrWithdrawalNotFound) {
+		return Withdrawal{}, ErrWithdrawalNotFound
+	}
+	if errors.Is(err, repository.ErrRefundExceedsWithdrawal) {
+		logger.FromContext(ctx).Info("orderservice: refund exceeds withdrawal", zap.String("orderNumber", orderNumber))
+		return Withdrawal{}, ErrRefundExceedsWithdrawal
+	}
+	if err != nil {
+		return Withdrawal{}, fmt.Errorf("orderservice: failed to refund withdrawal: %w", err)
+	}
+
+	logger.FromContext(ctx).Info("orderservice: withdrawal refunded", zap.String("orderNumber", orderNumber), zap.String("sum", amount.String()))
+	metrics.PointsRefunded.Add(amount.InexactFloat64())
+	return convertOrderToWithdrawalDomain(dbWithdrawal)
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	repository "github.com/aifedorov/gophermart/internal/order/repository/db"
+	"github.com/aifedorov/gophermart/internal/pkg/metrics"
+	"github.com/shopspring/decimal"
+)
+
+// allowedTransitions lists the statuses an order may move to. PROCESSED and INVALID are final.
+var allowedTransitions = map[Status][]Status{
+	StatusNew:        {StatusProcessing, StatusProcessed, StatusInvalid},
+	StatusProcessing: {StatusProcessed, StatusInvalid},
+}
+
+func CanTransition(from, to Status) bool {
+	for _, status := range allowedTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+func IsFinalStatus(status Status) bool {
+	return len(allowedTransitions[status]) == 0
+}
+
+// transitionOrder moves the order to the given status if the state machine allows it.
+// Campaign and tier bonuses are evaluated when the order becomes PROCESSED.
+func transitionOrder(ctx context.Context, repo repository.Repository, order repository.Order, to Status, amount *decimal.Decimal) error {
+	from := convertStatusToDomain(order.Status)
+	if !CanTransition(from, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrIllegalStatusTransition, from, to)
+	}
+
+	var bonuses repository.OrderBonuses
+	if to == StatusProcessed && amount != nil {
+		var err error
+		bonuses.Campaigns, err = campaignBonuses(ctx, repo, order, *amount, time.Now())
+		if err != nil {
+			return err
+		}
+		bonuses.Tier, err = tierBonus(ctx, repo, order, *amount)
+		if err != nil {
+			return err
+		}
+	}
+
+	credits, err := repo.UpdateOrderStatus(ctx, order.Number, order.Status, convertStatusToRepository(to), amount, bonuses)
+	if err != nil {
+		return err
+	}
+
+	metrics.OrderStatusTransitions.WithLabelValues(string(from), string(to)).Inc()
+	if to == StatusProcessed {
+		metrics.PointsCredited.WithLabelValues("accrual").Add(credits.Accrual.InexactFloat64())
+		metrics.PointsCredited.WithLabelValues("campaign").Add(credits.Campaign.InexactFloat64())
+		metrics.PointsCredited.WithLabelValues("tier").Add(credits.Tier.InexactFloat64())
+		metrics.PointsCredited.WithLabelValues("referral").Add(credits.Referral.InexactFloat64())
+	}
+	return nil
+}
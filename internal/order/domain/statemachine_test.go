@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanTransition(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		from Status
+		to   Status
+		want bool
+	}{
+		{name: "new to processing", from: StatusNew, to: StatusProcessing, want: true},
+		{name: "new to processed", from: StatusNew, to: StatusProcessed, want: true},
+		{name: "new to invalid", from: StatusNew, to: StatusInvalid, want: true},
+		{name: "processing to processed", from: StatusProcessing, to: StatusProcessed, want: true},
+		{name: "processing to invalid", from: StatusProcessing, to: StatusInvalid, want: true},
+		{name: "processing to new", from: StatusProcessing, to: StatusNew, want: false},
+		{name: "processing to processing", from: StatusProcessing, to: StatusProcessing, want: false},
+		{name: "processed to new", from: StatusProcessed, to: StatusNew, want: false},
+		{name: "processed to invalid", from: StatusProcessed, to: StatusInvalid, want: false},
+		{name: "invalid to processed", from: StatusInvalid, to: StatusProcessed, want: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, CanTransition(tt.from, tt.to))
+		})
+	}
+}
+
+func TestIsFinalStatus(t *testing.T) {
+	t.Parallel()
+
+	assert.False(t, IsFinalStatus(StatusNew))
+	assert.False(t, IsFinalStatus(StatusProcessing))
+	assert.True(t, IsFinalStatus(StatusProcessed))
+	assert.True(t, IsFinalStatus(StatusInvalid))
+}
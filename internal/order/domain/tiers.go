@@ -0,0 +1,230 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	repository "github.com/aifedorov/gophermart/internal/order/repository/db"
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/aifedorov/gophermart/internal/pkg/metrics"
+	"github.com/aifedorov/gophermart/internal/pkg/tracing"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const recalculateTiersBatchSize = 100
+
+// ParseTiers reads tiers written as NAME:THRESHOLD:ACCRUAL_MULTIPLIER:WITHDRAWAL_LIMIT,
+// e.g. SILVER:1000:1.1:5000. The tiers must be listed by threshold and start at zero.
+func ParseTiers(specs []string) ([]Tier, error) {
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("tiers: at least one tier is required")
+	}
+
+	tiers := make([]Tier, 0, len(specs))
+	for i, spec := range specs {
+		parts := strings.Split(strings.TrimSpace(spec), ":")
+		if len(parts) != 4 || parts[0] == "" {
+			return nil, fmt.Errorf("tiers: %q is not NAME:THRESHOLD:ACCRUAL_MULTIPLIER:WITHDRAWAL_LIMIT", spec)
+		}
+
+		var values [3]decimal.Decimal
+		for j, part := range parts[1:] {
+			value, err := decimal.NewFromString(part)
+			if err != nil {
+				return nil, fmt.Errorf("tiers: %q: %w", spec, err)
+			}
+			values[j] = value
+		}
+		tier := Tier{
+			Name:              parts[0],
+			Threshold:         values[0],
+			AccrualMultiplier: values[1],
+			WithdrawalLimit:   values[2],
+		}
+
+		switch {
+		case i == 0 && !tier.Threshold.IsZero():
+			return nil, fmt.Errorf("tiers: the first tier %s must start at 0", tier.Name)
+		case i > 0 && !tier.Threshold.GreaterThan(tiers[i-1].Threshold):
+			return nil, fmt.Errorf("tiers: %s must have a higher threshold than %s", tier.Name, tiers[i-1].Name)
+		case tier.AccrualMultiplier.LessThan(decimal.NewFromInt(1)):
+			return nil, fmt.Errorf("tiers: accrual multiplier of %s is below 1", tier.Name)
+		case tier.WithdrawalLimit.IsNegative():
+			return nil, fmt.Errorf("tiers: withdrawal limit of %s is negative", tier.Name)
+		}
+		tiers = append(tiers, tier)
+	}
+	return tiers, nil
+}
+
+// ForVolume returns the highest tier whose threshold the volume reaches.
+func (p TierPolicy) ForVolume(volume decimal.Decimal) Tier {
+	var tier Tier
+	for _, t := range p.Tiers {
+		if volume.LessThan(t.Threshold) {
+			break
+		}
+		tier = t
+	}
+	return tier
+}
+
+// next returns the tier after the named one, or nil for the top tier.
+// An unknown name is treated as the bottom tier.
+func (p TierPolicy) next(name string) *Tier {
+	for i, t := range p.Tiers {
+		if t.Name == name {
+			if i+1 < len(p.Tiers) {
+				return &p.Tiers[i+1]
+			}
+			return nil
+		}
+	}
+	if len(p.Tiers) > 1 {
+		return &p.Tiers[1]
+	}
+	return nil
+}
+
+// GetUserTier returns the tier the recalculation job assigned to the user and the
+// progress towards the next tier, measured by the points accrued over the policy window.
+func (s *service) GetUserTier(ctx context.Context, userID string) (TierStatus, error) {
+	ctx, span := tracing.Start(ctx, "orderservice.GetUserTier")
+	defer span.End()
+
+	policy := s.cfg.Tiers
+	dbTier, err := s.repo.GetUserTier(ctx, userID)
+	if err != nil {
+		return TierStatus{}, fmt.Errorf("orderservice: failed to get user tier: %w", err)
+	}
+
+	volume, err := s.repo.GetAccrualVolume(ctx, userID, time.Now().Add(-policy.Window))
+	if err != nil {
+		return TierStatus{}, fmt.Errorf("orderservice: failed to get accrual volume: %w", err)
+	}
+
+	var tier Tier
+	if dbTier.Tier == "" {
+		tier = policy.ForVolume(decimal.Zero)
+	} else {
+		tier = convertTierToDomain(dbTier)
+	}
+
+	status := TierStatus{
+		Tier:   tier,
+		Volume: volume,
+		Next:   policy.next(tier.Name),
+	}
+	if status.Next != nil {
+		status.ToNext = decimal.Max(decimal.Zero, status.Next.Threshold.Sub(volume))
+	}
+	return status, nil
+}
+
+// tierBonus returns the points the user's tier multiplier adds to the accrual, or nil if there are none.
+func tierBonus(ctx context.Context, repo repository.Repository, order repository.Order, accrual decimal.Decimal) (*repository.TierBonus, error) {
+	if !accrual.IsPositive() {
+		return nil, nil
+	}
+
+	dbTier, err := repo.GetUserTier(ctx, order.UserID.String())
+	if err != nil {
+		return nil, err
+	}
+	if dbTier.Tier == "" {
+		return nil, nil
+	}
+
+	amount := accrual.Mul(dbTier.AccrualMultiplier.Sub(decimal.NewFromInt(1))).Truncate(2)
+	if !amount.IsPositive() {
+		return nil, nil
+	}
+
+	logger.FromContext(ctx).Debug("orderservice: tier multiplies accrual",
+		zap.String("orderNumber", order.Number), zap.String("tier", dbTier.Tier), zap.String("bonus", amount.String()))
+	return &repository.TierBonus{
+		Tier:   dbTier.Tier,
+		Amount: amount,
+	}, nil
+}
+
+type TierRecalculator interface {
+	Run() error
+}
+
+type tierRecalculator struct {
+	ctx      context.Context
+	repo     repository.Repository
+	policy   TierPolicy
+	interval time.Duration
+}
+
+// NewTierRecalculator creates a job that assigns every user the tier of their accrual volume.
+func NewTierRecalculator(ctx context.Context, repo repository.Repository, policy TierPolicy, interval time.Duration) TierRecalculator {
+	return &tierRecalculator{
+		ctx:      ctx,
+		repo:     repo,
+		policy:   policy,
+		interval: interval,
+	}
+}
+
+// Run recalculates tiers right away and then on every tick until the context is cancelled.
+// It returns nil when it was stopped by the cancellation.
+func (r *tierRecalculator) Run() error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		err := r.recalculate()
+		if err != nil {
+			logger.Log.Error("tierrecalculator: failed to recalculate tiers", zap.Error(err))
+		}
+
+		select {
+		case <-r.ctx.Done():
+			logger.Log.Debug("tierrecalculator: context was cancelled, stopped recalculating tiers")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *tierRecalculator) recalculate() error {
+	since := time.Now().Add(-r.policy.Window)
+	after := uuid.Nil
+	for {
+		volumes, err := r.repo.GetAccrualVolumes(r.ctx, since, after, recalculateTiersBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, volume := range volumes {
+			tier := r.policy.ForVolume(volume.Volume)
+			changed, err := r.repo.SetUserTier(r.ctx, repository.UserTier{
+				UserID:            volume.UserID,
+				Tier:              tier.Name,
+				Volume:            volume.Volume,
+				AccrualMultiplier: tier.AccrualMultiplier,
+				WithdrawalLimit:   tier.WithdrawalLimit,
+			})
+			if err != nil {
+				return err
+			}
+			if changed {
+				metrics.TierChanges.WithLabelValues(tier.Name).Inc()
+				logger.Log.Info("tierrecalculator: user tier changed",
+					zap.String("userID", volume.UserID.String()), zap.String("tier", tier.Name), zap.String("volume", volume.Volume.String()))
+			}
+			after = volume.UserID
+		}
+
+		if len(volumes) < recalculateTiersBatchSize {
+			return nil
+		}
+	}
+}
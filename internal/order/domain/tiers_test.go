@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	repository "github.com/aifedorov/gophermart/internal/order/repository/db"
+	orderMocks "github.com/aifedorov/gophermart/internal/order/repository/mocks"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+var testTierSpecs = []string{"BRONZE:0:1:0", "SILVER:1000:1.1:5000", "GOLD:5000:1.25:0"}
+
+func TestParseTiers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		specs   []string
+		wantErr bool
+	}{
+		{name: "valid tiers", specs: testTierSpecs},
+		{name: "single tier", specs: []string{"MEMBER:0:1:0"}},
+		{name: "no tiers", specs: nil, wantErr: true},
+		{name: "missing field", specs: []string{"BRONZE:0:1"}, wantErr: true},
+		{name: "empty name", specs: []string{":0:1:0"}, wantErr: true},
+		{name: "not a number", specs: []string{"BRONZE:zero:1:0"}, wantErr: true},
+		{name: "first tier above zero", specs: []string{"BRONZE:10:1:0"}, wantErr: true},
+		{name: "thresholds out of order", specs: []string{"BRONZE:0:1:0", "GOLD:5000:1.25:0", "SILVER:1000:1.1:0"}, wantErr: true},
+		{name: "multiplier below one", specs: []string{"BRONZE:0:0.5:0"}, wantErr: true},
+		{name: "negative limit", specs: []string{"BRONZE:0:1:-1"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tiers, err := ParseTiers(tt.specs)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, tiers, len(tt.specs))
+		})
+	}
+}
+
+func TestTierPolicyForVolume(t *testing.T) {
+	t.Parallel()
+
+	tiers, err := ParseTiers(testTierSpecs)
+	require.NoError(t, err)
+	policy := TierPolicy{Tiers: tiers}
+
+	assert.Equal(t, "BRONZE", policy.ForVolume(decimal.Zero).Name)
+	assert.Equal(t, "BRONZE", policy.ForVolume(decimal.NewFromFloat(999.99)).Name)
+	assert.Equal(t, "SILVER", policy.ForVolume(decimal.NewFromInt(1000)).Name)
+	assert.Equal(t, "GOLD", policy.ForVolume(decimal.NewFromInt(100000)).Name)
+}
+
+func TestTierRecalculatorRecalculate(t *testing.T) {
+	t.Parallel()
+
+	tiers, err := ParseTiers(testTierSpecs)
+	require.NoError(t, err)
+	policy := TierPolicy{Window: 90 * 24 * time.Hour, Tiers: tiers}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := orderMocks.NewMockRepository(ctrl)
+
+	firstBatch := make([]repository.GetAccrualVolumesRow, recalculateTiersBatchSize)
+	for i := range firstBatch {
+		firstBatch[i] = repository.GetAccrualVolumesRow{UserID: uuid.New(), Volume: decimal.NewFromInt(10)}
+	}
+	lastInFirstBatch := firstBatch[len(firstBatch)-1].UserID
+	silverUser := uuid.New()
+
+	gomock.InOrder(
+		mockRepo.EXPECT().
+			GetAccrualVolumes(gomock.Any(), gomock.Any(), uuid.Nil, int32(recalculateTiersBatchSize)).
+			Return(firstBatch, nil),
+		mockRepo.EXPECT().
+			GetAccrualVolumes(gomock.Any(), gomock.Any(), lastInFirstBatch, int32(recalculateTiersBatchSize)).
+			Return([]repository.GetAccrualVolumesRow{{UserID: silverUser, Volume: decimal.NewFromInt(1500)}}, nil),
+	)
+	mockRepo.EXPECT().
+		SetUserTier(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, tier repository.UserTier) (bool, error) {
+			assert.Equal(t, "BRONZE", tier.Tier)
+			return false, nil
+		}).
+		Times(recalculateTiersBatchSize)
+	mockRepo.EXPECT().
+		SetUserTier(gomock.Any(), repository.UserTier{
+			UserID:            silverUser,
+			Tier:              "SILVER",
+			Volume:            decimal.NewFromInt(1500),
+			AccrualMultiplier: tiers[1].AccrualMultiplier,
+			WithdrawalLimit:   tiers[1].WithdrawalLimit,
+		}).
+		Return(true, nil).
+		Times(1)
+
+	recalculator := NewTierRecalculator(context.Background(), mockRepo, policy, time.Hour).(*tierRecalculator)
+	assert.NoError(t, recalculator.recalculate())
+}
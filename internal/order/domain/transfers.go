@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	repository "github.com/aifedorov/gophermart/internal/order/repository/db"
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/aifedorov/gophermart/internal/pkg/metrics"
+	"github.com/aifedorov/gophermart/internal/pkg/tracing"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// Transfer moves points from the user to the user with the recipient login,
+// within the configured daily transfer limit.
+func (s *service) Transfer(ctx context.Context, userID, recipientLogin string, amount decimal.Decimal) (Transfer, error) {
+	ctx, span := tracing.Start(ctx, "orderservice.Transfer")
+	defer span.End()
+
+	if !amount.IsPositive() {
+		return Transfer{}, ErrTransferNegativeAmount
+	}
+
+	log := logger.FromContext(ctx)
+	dbTransfer, err := s.repo.CreateTransfer(ctx, userID, recipientLogin, amount, s.cfg.TransferDailyLimit)
+	switch {
+	case errors.Is(err, repository.ErrRecipientNotFound):
+		log.Debug("orderservice: transfer recipient not found")
+		return Transfer{}, ErrRecipientNotFound
+	case errors.Is(err, repository.ErrTransferToSelf):
+		return Transfer{}, ErrTransferToSelf
+	case errors.Is(err, repository.ErrWithdrawInsufficientFunds):
+		log.Info("orderservice: insufficient funds to transfer", zap.String("sum", amount.String()))
+		return Transfer{}, ErrWithdrawInsufficientFunds
+	case errors.Is(err, repository.ErrTransferLimitExceeded):
+		log.Info("orderservice: daily transfer limit exceeded", zap.String("sum", amount.String()))
+		return Transfer{}, ErrTransferLimitExceeded
+	case err != nil:
+		return Transfer{}, fmt.Errorf("orderservice: failed to transfer points: %w", err)
+	}
+
+	log.Info("orderservice: points transferred",
+		zap.String("transferID", dbTransfer.ID.String()), zap.String("sum", amount.String()))
+	metrics.PointsTransferred.Add(amount.InexactFloat64())
+	return convertTransferToDomain(dbTransfer), nil
+}
+
+func (s *service) GetTransfers(ctx context.Context, userID string) ([]Transfer, error) {
+	ctx, span := tracing.Start(ctx, "orderservice.GetTransfers")
+	defer span.End()
+
+	dbTransfers, err := s.repo.GetTransfers(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("orderservice: failed to get transfers: %w", err)
+	}
+
+	transfers := make([]Transfer, len(dbTransfers))
+	for i, dbTransfer := range dbTransfers {
+		transfers[i] = convertTransferToDomain(dbTransfer)
+	}
+	return transfers, nil
+}
+
+// MaskLogin keeps the first and the last characters of the login, so users can recognise
+// a counterparty without learning its login.
+func MaskLogin(login string) string {
+	runes := []rune(login)
+	switch {
+	case len(runes) == 0:
+		return ""
+	case len(runes) <= 2:
+		return string(runes[0]) + "*"
+	default:
+		return string(runes[0]) + "***" + string(runes[len(runes)-1])
+	}
+}
@@ -0,0 +1,164 @@
+package domain
+
+import (
+	"context"
+	"testing"
+
+	repository "github.com/aifedorov/gophermart/internal/order/repository/db"
+	orderMocks "github.com/aifedorov/gophermart/internal/order/repository/mocks"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMaskLogin(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		login string
+		want  string
+	}{
+		{login: "", want: ""},
+		{login: "a", want: "a*"},
+		{login: "ab", want: "a*"},
+		{login: "abc", want: "a***c"},
+		{login: "alexander", want: "a***r"},
+		{login: "пётр", want: "п***р"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.login, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, MaskLogin(tt.login))
+		})
+	}
+}
+
+func TestServiceTransfer(t *testing.T) {
+	t.Parallel()
+
+	const (
+		userID         = "7f0c1f36-3b7a-4a43-9b5e-0d6f3c1b2a10"
+		recipientLogin = "recipient"
+	)
+	dailyLimit := decimal.NewFromInt(1000)
+
+	tests := []struct {
+		name    string
+		amount  decimal.Decimal
+		mock    func(mockRepo *orderMocks.MockRepository)
+		want    Transfer
+		wantErr error
+	}{
+		{
+			name:   "transferred",
+			amount: decimal.NewFromInt(100),
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					CreateTransfer(gomock.Any(), userID, recipientLogin, decimal.NewFromInt(100), dailyLimit).
+					Return(repository.GetTransfersByUserIDRow{
+						ID:           uuid.Nil,
+						Amount:       decimal.NewFromInt(100),
+						Outgoing:     true,
+						Counterparty: recipientLogin,
+					}, nil).
+					Times(1)
+			},
+			want: Transfer{
+				ID:           uuid.Nil.String(),
+				Direction:    TransferDirectionOut,
+				Counterparty: "r***t",
+				Sum:          decimal.NewFromInt(100),
+			},
+		},
+		{
+			name:    "zero amount",
+			amount:  decimal.Zero,
+			mock:    func(mockRepo *orderMocks.MockRepository) {},
+			wantErr: ErrTransferNegativeAmount,
+		},
+		{
+			name:   "recipient not found",
+			amount: decimal.NewFromInt(100),
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					CreateTransfer(gomock.Any(), userID, recipientLogin, decimal.NewFromInt(100), dailyLimit).
+					Return(repository.GetTransfersByUserIDRow{}, repository.ErrRecipientNotFound).
+					Times(1)
+			},
+			wantErr: ErrRecipientNotFound,
+		},
+		{
+			name:   "transfer to self",
+			amount: decimal.NewFromInt(100),
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					CreateTransfer(gomock.Any(), userID, recipientLogin, decimal.NewFromInt(100), dailyLimit).
+					Return(repository.GetTransfersByUserIDRow{}, repository.ErrTransferToSelf).
+					Times(1)
+			},
+			wantErr: ErrTransferToSelf,
+		},
+		{
+			name:   "insufficient funds",
+			amount: decimal.NewFromInt(100),
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					CreateTransfer(gomock.Any(), userID, recipientLogin, decimal.NewFromInt(100), dailyLimit).
+					Return(repository.GetTransfersByUserIDRow{}, repository.ErrWithdrawInsufficientFunds).
+					Times(1)
+			},
+			wantErr: ErrWithdrawInsufficientFunds,
+		},
+		{
+			name:   "limit exceeded",
+			amount: decimal.NewFromInt(100),
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					CreateTransfer(gomock.Any(), userID, recipientLogin, decimal.NewFromInt(100), dailyLimit).
+					Return(repository.GetTransfersByUserIDRow{}, repository.ErrTransferLimitExceeded).
+					Times(1)
+			},
+			wantErr: ErrTransferLimitExceeded,
+		},
+		{
+			name:   "repository error",
+			amount: decimal.NewFromInt(100),
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					CreateTransfer(gomock.Any(), userID, recipientLogin, decimal.NewFromInt(100), dailyLimit).
+					Return(repository.GetTransfersByUserIDRow{}, assert.AnError).
+					Times(1)
+			},
+			wantErr: assert.AnError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := orderMocks.NewMockRepository(ctrl)
+			tt.mock(mockRepo)
+
+			svc := NewService(mockRepo, Config{TransferDailyLimit: dailyLimit})
+			transfer, err := svc.Transfer(context.Background(), userID, recipientLogin, tt.amount)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.ID, transfer.ID)
+			assert.Equal(t, tt.want.Direction, transfer.Direction)
+			assert.Equal(t, tt.want.Counterparty, transfer.Counterparty)
+			assert.True(t, tt.want.Sum.Equal(transfer.Sum))
+		})
+	}
+}
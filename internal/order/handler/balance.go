@@ -11,22 +11,28 @@ import (
 
 func NewBalanceHandler(orderService domain.Service) http.HandlerFunc {
 	return func(rw http.ResponseWriter, req *http.Request) {
+		log := logger.FromContext(req.Context())
 		rw.Header().Set("Content-Type", "application/json")
 
 		userID, _ := middleware.GetUserID(req)
-		balance, err := orderService.GetUserBalance(userID)
+		balance, err := orderService.GetUserBalance(req.Context(), userID)
 		if err != nil {
-			logger.Log.Error("failed to get balance", zap.Error(err))
+			log.Error("failed to get balance", zap.Error(err))
 			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 			return
 		}
 
 		rw.WriteHeader(http.StatusOK)
 		response := BalanceResponse{
-			Current:   float32(balance.Current.InexactFloat64()),
-			Withdrawn: float32(balance.Withdrawn.InexactFloat64()),
+			Current:      float32(balance.Current.InexactFloat64()),
+			Withdrawn:    float32(balance.Withdrawn.InexactFloat64()),
+			Held:         float32(balance.Held.InexactFloat64()),
+			ExpiringSoon: float32(balance.ExpiringSoon.InexactFloat64()),
 		}
-		if err := encodeJSONResponse(rw, response); err != nil {
+		if !balance.NextExpiry.IsZero() {
+			response.NextExpiry = &balance.NextExpiry
+		}
+		if err := encodeJSONResponse(req.Context(), rw, response); err != nil {
 			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 			return
 		}
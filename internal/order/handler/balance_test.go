@@ -6,11 +6,13 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	orderDomain "github.com/aifedorov/gophermart/internal/order/domain"
 	repository "github.com/aifedorov/gophermart/internal/order/repository/db"
 	orderMocks "github.com/aifedorov/gophermart/internal/order/repository/mocks"
 	"github.com/aifedorov/gophermart/internal/pkg/middleware"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/shopspring/decimal"
 
 	"github.com/stretchr/testify/assert"
@@ -24,7 +26,7 @@ func TestBalanceHandler(t *testing.T) {
 	defer ctrl.Finish()
 
 	repo := newMockStorageBalanceHandler(ctrl)
-	orderService := orderDomain.NewService(repo)
+	orderService := orderDomain.NewService(repo, orderDomain.Config{})
 	handlerFunc := NewBalanceHandler(orderService)
 
 	type want struct {
@@ -48,7 +50,7 @@ func TestBalanceHandler(t *testing.T) {
 			want: want{
 				statusCode:  http.StatusOK,
 				contentType: "application/json",
-				body:        `{"current":100,"withdrawn":0}` + "\n",
+				body:        `{"current":100,"withdrawn":0,"held":0,"expiring_soon":0}` + "\n",
 			},
 		},
 		{
@@ -59,7 +61,18 @@ func TestBalanceHandler(t *testing.T) {
 			want: want{
 				statusCode:  http.StatusOK,
 				contentType: "application/json",
-				body:        `{"current":0,"withdrawn":0}` + "\n",
+				body:        `{"current":0,"withdrawn":0,"held":0,"expiring_soon":0}` + "\n",
+			},
+		},
+		{
+			name:   "balance with active holds",
+			method: http.MethodGet,
+			path:   "/api/user/balance",
+			userID: "550e8400-e29b-41d4-a716-446655440003",
+			want: want{
+				statusCode:  http.StatusOK,
+				contentType: "application/json",
+				body:        `{"current":60,"withdrawn":10,"held":30,"expiring_soon":20,"next_expiry":"2026-03-01T00:00:00Z"}` + "\n",
 			},
 		},
 	}
@@ -94,43 +107,45 @@ func newMockStorageBalanceHandler(ctrl *gomock.Controller) repository.Repository
 	mockRepo := orderMocks.NewMockRepository(ctrl)
 
 	mockRepo.EXPECT().
-		GetUserBalanceByUserID(TestUserID1.String()).
-		Return(decimal.NewFromInt(100), nil).
-		AnyTimes()
-
-	mockRepo.EXPECT().
-		GetUserWithdrawByUserID(TestUserID1.String()).
-		Return(decimal.NewFromInt(0), nil).
+		GetUserBalanceByUserID(gomock.Any(), TestUserID1.String()).
+		Return(repository.UserBalance{Current: decimal.NewFromInt(100)}, nil).
 		AnyTimes()
 
 	mockRepo.EXPECT().
-		GetUserBalanceByUserID("550e8400-e29b-41d4-a716-446655440002").
-		Return(decimal.NewFromInt(0), nil).
+		GetUserBalanceByUserID(gomock.Any(), "550e8400-e29b-41d4-a716-446655440002").
+		Return(repository.UserBalance{}, nil).
 		AnyTimes()
 
 	mockRepo.EXPECT().
-		GetUserWithdrawByUserID("550e8400-e29b-41d4-a716-446655440002").
-		Return(decimal.NewFromInt(0), nil).
+		GetUserBalanceByUserID(gomock.Any(), "550e8400-e29b-41d4-a716-446655440003").
+		Return(repository.UserBalance{
+			Current:   decimal.NewFromInt(60),
+			Withdrawn: decimal.NewFromInt(10),
+			Held:      decimal.NewFromInt(30),
+		}, nil).
 		AnyTimes()
 
 	mockRepo.EXPECT().
-		GetUserBalanceByUserID("4").
-		Return(decimal.Decimal{}, orderDomain.ErrOrderNotFound).
+		GetPointsExpiry(gomock.Any(), "550e8400-e29b-41d4-a716-446655440003").
+		Return(repository.GetPointsExpiryByUserIDRow{
+			ExpiringSoon: decimal.NewFromInt(20),
+			NextExpiry:   pgtype.Timestamptz{Time: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+		}, nil).
 		AnyTimes()
 
 	mockRepo.EXPECT().
-		GetUserWithdrawByUserID("4").
-		Return(decimal.Decimal{}, orderDomain.ErrOrderNotFound).
+		GetPointsExpiry(gomock.Any(), gomock.Any()).
+		Return(repository.GetPointsExpiryByUserIDRow{}, nil).
 		AnyTimes()
 
 	mockRepo.EXPECT().
-		GetUserBalanceByUserID("5").
-		Return(decimal.Decimal{}, assert.AnError).
+		GetUserBalanceByUserID(gomock.Any(), "4").
+		Return(repository.UserBalance{}, orderDomain.ErrOrderNotFound).
 		AnyTimes()
 
 	mockRepo.EXPECT().
-		GetUserWithdrawByUserID("5").
-		Return(decimal.Decimal{}, assert.AnError).
+		GetUserBalanceByUserID(gomock.Any(), "5").
+		Return(repository.UserBalance{}, assert.AnError).
 		AnyTimes()
 
 	return mockRepo
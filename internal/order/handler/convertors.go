@@ -28,3 +28,95 @@ func ToOrdersResponse(orders []domain.Order) []OrderResponse {
 	}
 	return respOrders
 }
+
+func ToHoldResponse(hold domain.Hold) HoldResponse {
+	return HoldResponse{
+		ID:        hold.ID,
+		Order:     hold.OrderNumber,
+		Sum:       float32(hold.Sum.InexactFloat64()),
+		Status:    string(hold.Status),
+		ExpiresAt: hold.ExpiresAt,
+	}
+}
+
+func ToWithdrawalResponse(withdrawal domain.Withdrawal) WithdrawalResponse {
+	return WithdrawalResponse{
+		Order:       withdrawal.OrderNumber,
+		Sum:         float32(withdrawal.Sum.InexactFloat64()),
+		Status:      string(withdrawal.Status()),
+		Refunded:    float32(withdrawal.Refunded.InexactFloat64()),
+		ProcessedAt: withdrawal.ProcessedAt,
+	}
+}
+
+func ToTransferResponse(transfer domain.Transfer) TransferResponse {
+	return TransferResponse{
+		ID:           transfer.ID,
+		Direction:    string(transfer.Direction),
+		Counterparty: transfer.Counterparty,
+		Sum:          float32(transfer.Sum.InexactFloat64()),
+		CreatedAt:    transfer.CreatedAt,
+	}
+}
+
+func ToGiftCodeBatchResponse(batch domain.GiftCodeBatch) GiftCodeBatchResponse {
+	resp := GiftCodeBatchResponse{
+		ID:             batch.ID,
+		Name:           batch.Name,
+		Sum:            float32(batch.Sum.InexactFloat64()),
+		MaxRedemptions: batch.MaxRedemptions,
+		Codes:          make([]string, len(batch.Codes)),
+	}
+	if !batch.ExpiresAt.IsZero() {
+		resp.ExpiresAt = &batch.ExpiresAt
+	}
+	for i, code := range batch.Codes {
+		resp.Codes[i] = code.Code
+	}
+	return resp
+}
+
+func ToGiftCodeRedemptionResponse(redemption domain.GiftCodeRedemption) GiftCodeRedemptionResponse {
+	return GiftCodeRedemptionResponse{
+		Code:       redemption.Code,
+		Sum:        float32(redemption.Sum.InexactFloat64()),
+		RedeemedAt: redemption.RedeemedAt,
+	}
+}
+
+func ToReferralsResponse(referrals domain.Referrals) ReferralsResponse {
+	resp := ReferralsResponse{
+		Code:      referrals.Code,
+		Referrals: make([]ReferralResponse, len(referrals.Referrals)),
+	}
+	for i, referral := range referrals.Referrals {
+		resp.Referrals[i] = ReferralResponse{
+			Login:        referral.Referred,
+			Status:       string(referral.Status),
+			Reason:       referral.Reason,
+			Reward:       float32(referral.Reward.InexactFloat64()),
+			RegisteredAt: referral.CreatedAt,
+		}
+		if !referral.SettledAt.IsZero() {
+			resp.Referrals[i].SettledAt = &referral.SettledAt
+		}
+	}
+	return resp
+}
+
+func ToTierResponse(status domain.TierStatus) TierResponse {
+	resp := TierResponse{
+		Tier:              status.Tier.Name,
+		Volume:            float32(status.Volume.InexactFloat64()),
+		AccrualMultiplier: float32(status.Tier.AccrualMultiplier.InexactFloat64()),
+		WithdrawalLimit:   float32(status.Tier.WithdrawalLimit.InexactFloat64()),
+	}
+	if status.Next != nil {
+		resp.Next = &NextTierResponse{
+			Tier:      status.Next.Name,
+			Threshold: float32(status.Next.Threshold.InexactFloat64()),
+			Remaining: float32(status.ToNext.InexactFloat64()),
+		}
+	}
+	return resp
+}
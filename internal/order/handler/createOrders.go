@@ -14,31 +14,32 @@ import (
 
 func NewCreateOrdersHandler(orderService domain.Service) http.HandlerFunc {
 	return func(rw http.ResponseWriter, req *http.Request) {
+		log := logger.FromContext(req.Context())
 		rw.Header().Set("Content-Type", "text/plain")
 
 		userID, err := middleware.GetUserID(req)
 		if err != nil {
-			logger.Log.Info("user not authenticated", zap.Error(err))
+			log.Info("user not authenticated", zap.Error(err))
 			http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
 			return
 		}
 
 		orderNumber, err := io.ReadAll(req.Body)
 		if err != nil {
-			logger.Log.Info("failed to read request body", zap.Error(err))
+			log.Info("failed to read request body", zap.Error(err))
 			http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 			return
 		}
 
 		if len(orderNumber) == 0 {
-			logger.Log.Info("empty order number")
+			log.Info("empty order number")
 			http.Error(rw, "empty order number", http.StatusBadRequest)
 			return
 		}
 
-		_, status, err := orderService.CreateOrder(userID, string(orderNumber))
+		_, status, err := orderService.CreateOrder(req.Context(), userID, string(orderNumber))
 		if errors.Is(err, domain.ErrInvalidOrderNumber) {
-			logger.Log.Info("invalid order number", zap.String("order", string(orderNumber)))
+			log.Info("invalid order number", zap.String("order", string(orderNumber)))
 			http.Error(rw, "invalid order number", http.StatusUnprocessableEntity)
 			return
 		}
@@ -23,7 +23,7 @@ func TestCreateOrdersHandler(t *testing.T) {
 	defer ctrl.Finish()
 
 	repo := newMockStorageForCreateOrders(ctrl)
-	orderService := domain.NewService(repo)
+	orderService := domain.NewService(repo, domain.Config{})
 	handlerFunc := NewCreateOrdersHandler(orderService)
 
 	type want struct {
@@ -128,31 +128,31 @@ func newMockStorageForCreateOrders(ctrl *gomock.Controller) repository.Repositor
 
 	// Success case - order created
 	mockRepo.EXPECT().
-		GetOrderByNumber("4532015112830366").
+		GetOrderByNumber(gomock.Any(), "4532015112830366").
 		Return(repository.Order{}, domain.ErrOrderNotFound).
 		AnyTimes()
 	mockRepo.EXPECT().
-		CreateTopUpOrder(TestUserID1.String(), "4532015112830366").
+		CreateTopUpOrder(gomock.Any(), TestUserID1.String(), "4532015112830366").
 		Return(repository.Order{}, true, nil).
 		AnyTimes()
 
 	// OrderNumber already uploaded case - same user
 	mockRepo.EXPECT().
-		GetOrderByNumber("5555555555554444").
+		GetOrderByNumber(gomock.Any(), "5555555555554444").
 		Return(repository.Order{UserID: TestUserID1, Number: "5555555555554444"}, nil).
 		AnyTimes()
 	mockRepo.EXPECT().
-		CreateTopUpOrder(TestUserID1.String(), "5555555555554444").
+		CreateTopUpOrder(gomock.Any(), TestUserID1.String(), "5555555555554444").
 		Return(repository.Order{UserID: TestUserID1, Number: "5555555555554444"}, false, nil).
 		AnyTimes()
 
 	// OrderNumber uploaded by another user
 	mockRepo.EXPECT().
-		GetOrderByNumber("4111111111111111").
+		GetOrderByNumber(gomock.Any(), "4111111111111111").
 		Return(repository.Order{UserID: TestUserID2, Number: "4111111111111111"}, nil).
 		AnyTimes()
 	mockRepo.EXPECT().
-		CreateTopUpOrder(TestUserID1.String(), "4111111111111111").
+		CreateTopUpOrder(gomock.Any(), TestUserID1.String(), "4111111111111111").
 		Return(repository.Order{UserID: TestUserID2, Number: "4111111111111111"}, false, nil).
 		AnyTimes()
 
@@ -11,18 +11,19 @@ import (
 
 func NewGetOrdersHandler(orderService domain.Service) http.HandlerFunc {
 	return func(rw http.ResponseWriter, req *http.Request) {
+		log := logger.FromContext(req.Context())
 		rw.Header().Set("Content-Type", "application/json")
 
 		userID, err := middleware.GetUserID(req)
 		if err != nil {
-			logger.Log.Info("user not authenticated", zap.Error(err))
+			log.Info("user not authenticated", zap.Error(err))
 			http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
 			return
 		}
 
-		orders, err := orderService.GetUserOrders(userID)
+		orders, err := orderService.GetUserOrders(req.Context(), userID)
 		if err != nil {
-			logger.Log.Error("failed to get orders", zap.Error(err))
+			log.Error("failed to get orders", zap.Error(err))
 			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 			return
 		}
@@ -33,7 +34,7 @@ func NewGetOrdersHandler(orderService domain.Service) http.HandlerFunc {
 		}
 
 		rw.WriteHeader(http.StatusOK)
-		if err := encodeResponse(rw, ToOrdersResponse(orders)); err != nil {
+		if err := encodeResponse(req.Context(), rw, ToOrdersResponse(orders)); err != nil {
 			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 			return
 		}
@@ -23,7 +23,7 @@ func TestGetOrdersHandler(t *testing.T) {
 	defer ctrl.Finish()
 
 	repo := newMockStorageForGetOrders(ctrl)
-	orderService := domain.NewService(repo)
+	orderService := domain.NewService(repo, domain.Config{})
 	handlerFunc := NewGetOrdersHandler(orderService)
 
 	type want struct {
@@ -78,14 +78,14 @@ func newMockStorageForGetOrders(ctrl *gomock.Controller) repository.Repository {
 	mockRepo := orderMocks.NewMockRepository(ctrl)
 
 	mockRepo.EXPECT().
-		GetOrdersByUserID(TestUserID1.String()).
+		GetOrdersByUserID(gomock.Any(), TestUserID1.String()).
 		Return([]repository.Order{
 			{Number: "4532015112830366", Status: repository.OrderstatusNEW},
 		}, nil).
 		AnyTimes()
 
 	mockRepo.EXPECT().
-		GetOrdersByUserID("550e8400-e29b-41d4-a716-446655440002").
+		GetOrdersByUserID(gomock.Any(), "550e8400-e29b-41d4-a716-446655440002").
 		Return(nil, domain.ErrOrderNotFound).
 		AnyTimes()
 
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aifedorov/gophermart/internal/order/domain"
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/aifedorov/gophermart/internal/pkg/middleware"
+	"github.com/go-chi/chi/v5"
+
+	"go.uber.org/zap"
+)
+
+// GiftCodeBatchIDParam is the route parameter that carries the gift code batch id.
+const GiftCodeBatchIDParam = "batchID"
+
+func NewCreateGiftCodesHandler(orderService domain.Service) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		log := logger.FromContext(req.Context())
+		rw.Header().Set("Content-Type", "application/json")
+
+		body, err := decodeGiftCodeBatch(req)
+		if err != nil {
+			log.Info("failed to decode request", zap.Error(err))
+			http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		newBatch := domain.NewGiftCodeBatch{
+			Name:           body.Name,
+			Count:          body.Count,
+			Sum:            body.Sum,
+			MaxRedemptions: body.MaxRedemptions,
+		}
+		if body.ExpiresAt != nil {
+			newBatch.ExpiresAt = *body.ExpiresAt
+		}
+
+		batch, err := orderService.CreateGiftCodeBatch(req.Context(), newBatch)
+		if errors.Is(err, domain.ErrInvalidGiftCodeBatch) {
+			log.Info("invalid gift code batch", zap.Error(err))
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Error("failed to create gift codes", zap.Error(err))
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		rw.WriteHeader(http.StatusCreated)
+		if err := encodeJSONResponse(req.Context(), rw, ToGiftCodeBatchResponse(batch)); err != nil {
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// NewExportGiftCodesHandler writes the codes of a batch as CSV, one code per row.
+func NewExportGiftCodesHandler(orderService domain.Service) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		log := logger.FromContext(req.Context())
+
+		batchID := chi.URLParam(req, GiftCodeBatchIDParam)
+		batch, err := orderService.GetGiftCodeBatch(req.Context(), batchID)
+		if errors.Is(err, domain.ErrGiftCodeBatchNotFound) {
+			log.Info("gift code batch not found", zap.String("batchID", batchID))
+			http.Error(rw, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Error("failed to get gift code batch", zap.Error(err))
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		var expiresAt string
+		if !batch.ExpiresAt.IsZero() {
+			expiresAt = batch.ExpiresAt.UTC().Format(time.RFC3339)
+		}
+
+		rw.Header().Set("Content-Type", "text/csv")
+		rw.Header().Set("Content-Disposition", `attachment; filename="gift-codes-`+batch.ID+`.csv"`)
+		rw.WriteHeader(http.StatusOK)
+
+		writer := csv.NewWriter(rw)
+		_ = writer.Write([]string{"code", "sum", "max_redemptions", "redemptions", "expires_at"})
+		for _, code := range batch.Codes {
+			_ = writer.Write([]string{
+				code.Code,
+				batch.Sum.StringFixed(2),
+				strconv.Itoa(batch.MaxRedemptions),
+				strconv.Itoa(code.Redemptions),
+				expiresAt,
+			})
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			log.Error("failed to write gift codes", zap.Error(err))
+		}
+	}
+}
+
+func NewRedeemGiftCodeHandler(orderService domain.Service) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		log := logger.FromContext(req.Context())
+		rw.Header().Set("Content-Type", "application/json")
+
+		body, err := decodeRedeemGiftCode(req)
+		if err != nil {
+			log.Info("failed to decode request", zap.Error(err))
+			http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		userID, _ := middleware.GetUserID(req)
+		redemption, err := orderService.RedeemGiftCode(req.Context(), userID, body.Code)
+		switch {
+		case errors.Is(err, domain.ErrGiftCodeNotFound):
+			log.Info("gift code not found")
+			http.Error(rw, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		case errors.Is(err, domain.ErrGiftCodeExpired):
+			log.Info("gift code has expired")
+			http.Error(rw, http.StatusText(http.StatusGone), http.StatusGone)
+			return
+		case errors.Is(err, domain.ErrGiftCodeExhausted), errors.Is(err, domain.ErrGiftCodeAlreadyRedeemed):
+			log.Info("gift code cannot be redeemed", zap.Error(err))
+			http.Error(rw, http.StatusText(http.StatusConflict), http.StatusConflict)
+			return
+		case err != nil:
+			log.Error("failed to redeem gift code", zap.Error(err))
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		rw.WriteHeader(http.StatusOK)
+		if err := encodeJSONResponse(req.Context(), rw, ToGiftCodeRedemptionResponse(redemption)); err != nil {
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	orderDomain "github.com/aifedorov/gophermart/internal/order/domain"
+	repository "github.com/aifedorov/gophermart/internal/order/repository/db"
+	orderMocks "github.com/aifedorov/gophermart/internal/order/repository/mocks"
+	"github.com/aifedorov/gophermart/internal/pkg/middleware"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+var testGiftCodeBatchID = uuid.MustParse("0b5d7c1a-6f2e-4d8b-9a3c-5e7f1b2d4c60")
+
+var testGiftCodeExpiresAt = time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newTestGiftCodeBatch() repository.GiftCodeBatch {
+	return repository.GiftCodeBatch{
+		ID:             testGiftCodeBatchID,
+		Name:           "new year",
+		Amount:         decimal.NewFromInt(50),
+		MaxRedemptions: 10,
+		ExpiresAt:      pgtype.Timestamptz{Time: testGiftCodeExpiresAt, Valid: true},
+	}
+}
+
+func TestCreateGiftCodesHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		request    string
+		statusCode int
+		mock       func(mockRepo *orderMocks.MockRepository)
+	}{
+		{
+			name:       "batch created",
+			request:    `{"name":"new year","count":2,"sum":50,"max_redemptions":10,"expires_at":"2099-01-01T00:00:00Z"}`,
+			statusCode: http.StatusCreated,
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					CreateGiftCodeBatch(gomock.Any(), repository.CreateGiftCodeBatchParams{
+						Name:           "new year",
+						Amount:         decimal.NewFromInt(50),
+						MaxRedemptions: 10,
+						ExpiresAt:      pgtype.Timestamptz{Time: testGiftCodeExpiresAt, Valid: true},
+					}, gomock.Len(2)).
+					Return(newTestGiftCodeBatch(), nil).
+					Times(1)
+			},
+		},
+		{
+			name:       "empty body",
+			request:    ``,
+			statusCode: http.StatusBadRequest,
+			mock:       func(mockRepo *orderMocks.MockRepository) {},
+		},
+		{
+			name:       "too many codes",
+			request:    `{"name":"new year","count":100000,"sum":50,"max_redemptions":1}`,
+			statusCode: http.StatusBadRequest,
+			mock:       func(mockRepo *orderMocks.MockRepository) {},
+		},
+		{
+			name:       "repository error",
+			request:    `{"name":"new year","count":2,"sum":50,"max_redemptions":1}`,
+			statusCode: http.StatusInternalServerError,
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					CreateGiftCodeBatch(gomock.Any(), gomock.Any(), gomock.Len(2)).
+					Return(repository.GiftCodeBatch{}, assert.AnError).
+					Times(1)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockOrderRepo := orderMocks.NewMockRepository(ctrl)
+			tt.mock(mockOrderRepo)
+
+			handlerFunc := NewCreateGiftCodesHandler(orderDomain.NewService(mockOrderRepo, orderDomain.Config{}))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/gift-codes", strings.NewReader(tt.request))
+			req.Header.Set("Content-Type", "application/json")
+			res := httptest.NewRecorder()
+
+			handlerFunc(res, req)
+
+			assert.Equal(t, tt.statusCode, res.Code)
+			if tt.statusCode == http.StatusCreated {
+				var got GiftCodeBatchResponse
+				require.NoError(t, json.NewDecoder(res.Body).Decode(&got))
+				assert.Equal(t, testGiftCodeBatchID.String(), got.ID)
+				assert.Len(t, got.Codes, 2)
+				assert.Equal(t, &testGiftCodeExpiresAt, got.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestExportGiftCodesHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		batchID    string
+		statusCode int
+		body       string
+		mock       func(mockRepo *orderMocks.MockRepository)
+	}{
+		{
+			name:       "codes exported",
+			batchID:    testGiftCodeBatchID.String(),
+			statusCode: http.StatusOK,
+			body: "code,sum,max_redemptions,redemptions,expires_at\n" +
+				"ABCD2345EFGH,50.00,10,3,2099-01-01T00:00:00Z\n" +
+				"ZXCV6789BNMQ,50.00,10,0,2099-01-01T00:00:00Z\n",
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					GetGiftCodeBatch(gomock.Any(), testGiftCodeBatchID.String()).
+					Return(newTestGiftCodeBatch(), []repository.GiftCode{
+						{BatchID: testGiftCodeBatchID, Code: "ABCD2345EFGH", Redemptions: 3},
+						{BatchID: testGiftCodeBatchID, Code: "ZXCV6789BNMQ"},
+					}, nil).
+					Times(1)
+			},
+		},
+		{
+			name:       "batch not found",
+			batchID:    "unknown",
+			statusCode: http.StatusNotFound,
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					GetGiftCodeBatch(gomock.Any(), "unknown").
+					Return(repository.GiftCodeBatch{}, nil, repository.ErrGiftCodeBatchNotFound).
+					Times(1)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockOrderRepo := orderMocks.NewMockRepository(ctrl)
+			tt.mock(mockOrderRepo)
+
+			router := chi.NewRouter()
+			router.Get("/api/admin/gift-codes/{"+GiftCodeBatchIDParam+"}/export", NewExportGiftCodesHandler(orderDomain.NewService(mockOrderRepo, orderDomain.Config{})))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/gift-codes/"+tt.batchID+"/export", nil)
+			res := httptest.NewRecorder()
+
+			router.ServeHTTP(res, req)
+
+			assert.Equal(t, tt.statusCode, res.Code)
+			if tt.body != "" {
+				assert.Equal(t, "text/csv", res.Header().Get("Content-Type"))
+				assert.Equal(t, tt.body, res.Body.String())
+			}
+		})
+	}
+}
+
+func TestRedeemGiftCodeHandler(t *testing.T) {
+	t.Parallel()
+
+	const testGiftCode = "ABCD2345EFGH"
+	redeemedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	type want struct {
+		statusCode int
+		body       string
+	}
+
+	tests := []struct {
+		name    string
+		request string
+		want    want
+		mock    func(mockRepo *orderMocks.MockRepository)
+	}{
+		{
+			name:    "code redeemed",
+			request: `{"code":"abcd-2345-efgh"}`,
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"code":"ABCD2345EFGH","sum":50,"redeemed_at":"2025-01-01T12:00:00Z"}` + "\n",
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					RedeemGiftCode(gomock.Any(), TestUserID1.String(), testGiftCode).
+					Return(repository.GiftCodeRedemption{
+						ID:        uuid.New(),
+						UserID:    TestUserID1,
+						Amount:    decimal.NewFromInt(50),
+						CreatedAt: pgtype.Timestamptz{Time: redeemedAt, Valid: true},
+					}, nil).
+					Times(1)
+			},
+		},
+		{
+			name:    "empty body",
+			request: ``,
+			want: want{
+				statusCode: http.StatusBadRequest,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {},
+		},
+		{
+			name:    "unknown code",
+			request: `{"code":"ABCD2345EFGH"}`,
+			want: want{
+				statusCode: http.StatusNotFound,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					RedeemGiftCode(gomock.Any(), TestUserID1.String(), testGiftCode).
+					Return(repository.GiftCodeRedemption{}, repository.ErrGiftCodeNotFound).
+					Times(1)
+			},
+		},
+		{
+			name:    "expired code",
+			request: `{"code":"ABCD2345EFGH"}`,
+			want: want{
+				statusCode: http.StatusGone,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					RedeemGiftCode(gomock.Any(), TestUserID1.String(), testGiftCode).
+					Return(repository.GiftCodeRedemption{}, repository.ErrGiftCodeExpired).
+					Times(1)
+			},
+		},
+		{
+			name:    "no redemptions left",
+			request: `{"code":"ABCD2345EFGH"}`,
+			want: want{
+				statusCode: http.StatusConflict,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					RedeemGiftCode(gomock.Any(), TestUserID1.String(), testGiftCode).
+					Return(repository.GiftCodeRedemption{}, repository.ErrGiftCodeExhausted).
+					Times(1)
+			},
+		},
+		{
+			name:    "already redeemed by the user",
+			request: `{"code":"ABCD2345EFGH"}`,
+			want: want{
+				statusCode: http.StatusConflict,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					RedeemGiftCode(gomock.Any(), TestUserID1.String(), testGiftCode).
+					Return(repository.GiftCodeRedemption{}, repository.ErrGiftCodeAlreadyRedeemed).
+					Times(1)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockOrderRepo := orderMocks.NewMockRepository(ctrl)
+			tt.mock(mockOrderRepo)
+
+			handlerFunc := NewRedeemGiftCodeHandler(orderDomain.NewService(mockOrderRepo, orderDomain.Config{}))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/user/gift-codes/redeem", strings.NewReader(tt.request))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, TestUserID1.String()))
+			res := httptest.NewRecorder()
+
+			handlerFunc(res, req)
+
+			assert.Equal(t, tt.want.statusCode, res.Code)
+			if tt.want.body != "" {
+				assert.Equal(t, tt.want.body, res.Body.String())
+			}
+		})
+	}
+}
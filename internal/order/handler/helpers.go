@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,11 +11,11 @@ import (
 	"net/http"
 )
 
-func encodeResponse(rw http.ResponseWriter, orders []OrderResponse) error {
+func encodeResponse(ctx context.Context, rw http.ResponseWriter, orders []OrderResponse) error {
 	encoder := json.NewEncoder(rw)
 
 	if err := encoder.Encode(orders); err != nil {
-		logger.Log.Error("failed to encode response", zap.Error(err))
+		logger.FromContext(ctx).Error("failed to encode response", zap.Error(err))
 		return errors.New("failed to encode response")
 	}
 	return nil
@@ -32,11 +33,71 @@ func decodeWithdraw(r *http.Request) (WithdrawRequest, error) {
 	return body, nil
 }
 
-func encodeJSONResponse(rw http.ResponseWriter, data interface{}) error {
+func decodeHold(r *http.Request) (HoldRequest, error) {
+	var body HoldRequest
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		return HoldRequest{}, errors.New("request body is empty")
+	}
+	if err != nil {
+		return HoldRequest{}, fmt.Errorf("failed to decode request: %w", err)
+	}
+	return body, nil
+}
+
+func decodeRefund(r *http.Request) (RefundRequest, error) {
+	var body RefundRequest
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		return RefundRequest{}, errors.New("request body is empty")
+	}
+	if err != nil {
+		return RefundRequest{}, fmt.Errorf("failed to decode request: %w", err)
+	}
+	return body, nil
+}
+
+func decodeTransfer(r *http.Request) (TransferRequest, error) {
+	var body TransferRequest
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		return TransferRequest{}, errors.New("request body is empty")
+	}
+	if err != nil {
+		return TransferRequest{}, fmt.Errorf("failed to decode request: %w", err)
+	}
+	return body, nil
+}
+
+func decodeGiftCodeBatch(r *http.Request) (GiftCodeBatchRequest, error) {
+	var body GiftCodeBatchRequest
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		return GiftCodeBatchRequest{}, errors.New("request body is empty")
+	}
+	if err != nil {
+		return GiftCodeBatchRequest{}, fmt.Errorf("failed to decode request: %w", err)
+	}
+	return body, nil
+}
+
+func decodeRedeemGiftCode(r *http.Request) (RedeemGiftCodeRequest, error) {
+	var body RedeemGiftCodeRequest
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if errors.Is(err, io.EOF) {
+		return RedeemGiftCodeRequest{}, errors.New("request body is empty")
+	}
+	if err != nil {
+		return RedeemGiftCodeRequest{}, fmt.Errorf("failed to decode request: %w", err)
+	}
+	return body, nil
+}
+
+func encodeJSONResponse(ctx context.Context, rw http.ResponseWriter, data interface{}) error {
 	encoder := json.NewEncoder(rw)
 
 	if err := encoder.Encode(data); err != nil {
-		logger.Log.Error("failed to encode response", zap.Error(err))
+		logger.FromContext(ctx).Error("failed to encode response", zap.Error(err))
 		return errors.New("failed to encode response")
 	}
 	return nil
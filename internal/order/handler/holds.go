@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/aifedorov/gophermart/internal/order/domain"
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/aifedorov/gophermart/internal/pkg/middleware"
+	"github.com/go-chi/chi/v5"
+
+	"go.uber.org/zap"
+)
+
+// HoldIDParam is the route parameter that carries the hold id.
+const HoldIDParam = "holdID"
+
+func NewCreateHoldHandler(orderService domain.Service, ttl time.Duration) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		log := logger.FromContext(req.Context())
+		rw.Header().Set("Content-Type", "application/json")
+
+		body, err := decodeHold(req)
+		if err != nil {
+			log.Info("failed to decode request", zap.Error(err))
+			http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		userID, _ := middleware.GetUserID(req)
+		hold, err := orderService.CreateHold(req.Context(), userID, body.Order, body.Sum, ttl)
+		switch {
+		case errors.Is(err, domain.ErrInvalidOrderNumber):
+			log.Info("invalid order number", zap.String("order", body.Order))
+			http.Error(rw, http.StatusText(http.StatusUnprocessableEntity), http.StatusUnprocessableEntity)
+			return
+		case errors.Is(err, domain.ErrHoldAlreadyExists):
+			log.Info("order number already used", zap.String("order", body.Order))
+			http.Error(rw, http.StatusText(http.StatusUnprocessableEntity), http.StatusUnprocessableEntity)
+			return
+		case errors.Is(err, domain.ErrWithdrawNegativeAmount), errors.Is(err, domain.ErrWithdrawInsufficientFunds):
+			log.Info("failed to hold points", zap.Error(err))
+			http.Error(rw, http.StatusText(http.StatusPaymentRequired), http.StatusPaymentRequired)
+			return
+		case errors.Is(err, domain.ErrWithdrawLimitExceeded):
+			log.Info("withdrawal limit exceeded by hold")
+			http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		case err != nil:
+			log.Error("failed to hold points", zap.Error(err))
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		rw.WriteHeader(http.StatusCreated)
+		if err := encodeJSONResponse(req.Context(), rw, ToHoldResponse(hold)); err != nil {
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func NewCaptureHoldHandler(orderService domain.Service) http.HandlerFunc {
+	return newHoldActionHandler(orderService.CaptureHold)
+}
+
+func NewVoidHoldHandler(orderService domain.Service) http.HandlerFunc {
+	return newHoldActionHandler(orderService.VoidHold)
+}
+
+func newHoldActionHandler(action func(ctx context.Context, userID, holdID string) (domain.Hold, error)) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		log := logger.FromContext(req.Context())
+		rw.Header().Set("Content-Type", "application/json")
+
+		userID, _ := middleware.GetUserID(req)
+		holdID := chi.URLParam(req, HoldIDParam)
+		hold, err := action(req.Context(), userID, holdID)
+		switch {
+		case errors.Is(err, domain.ErrHoldNotFound):
+			log.Info("hold not found", zap.String("holdID", holdID))
+			http.Error(rw, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		case errors.Is(err, domain.ErrHoldNotActive):
+			log.Info("hold is not active", zap.String("holdID", holdID))
+			http.Error(rw, http.StatusText(http.StatusConflict), http.StatusConflict)
+			return
+		case errors.Is(err, domain.ErrHoldExpired):
+			log.Info("hold has expired", zap.String("holdID", holdID))
+			http.Error(rw, http.StatusText(http.StatusGone), http.StatusGone)
+			return
+		case errors.Is(err, domain.ErrHoldAlreadyExists):
+			log.Info("order number already used", zap.String("holdID", holdID))
+			http.Error(rw, http.StatusText(http.StatusUnprocessableEntity), http.StatusUnprocessableEntity)
+			return
+		case err != nil:
+			log.Error("failed to update hold", zap.Error(err))
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		rw.WriteHeader(http.StatusOK)
+		if err := encodeJSONResponse(req.Context(), rw, ToHoldResponse(hold)); err != nil {
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	orderDomain "github.com/aifedorov/gophermart/internal/order/domain"
+	repository "github.com/aifedorov/gophermart/internal/order/repository/db"
+	orderMocks "github.com/aifedorov/gophermart/internal/order/repository/mocks"
+	"github.com/aifedorov/gophermart/internal/pkg/middleware"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+var testHoldID = uuid.MustParse("7c9e6679-7425-40de-944b-e07fc1f90ae7")
+
+var testExpiresAt = time.Date(2025, 1, 1, 12, 15, 0, 0, time.UTC)
+
+func newTestHold(status repository.Holdstatus) repository.BalanceHold {
+	return repository.BalanceHold{
+		ID:          testHoldID,
+		UserID:      TestUserID1,
+		OrderNumber: testOrderNumber,
+		Amount:      decimal.NewFromInt(30),
+		Status:      status,
+		ExpiresAt:   pgtype.Timestamptz{Time: testExpiresAt, Valid: true},
+	}
+}
+
+func TestCreateHoldHandler(t *testing.T) {
+	t.Parallel()
+
+	type want struct {
+		statusCode int
+		body       string
+	}
+
+	tests := []struct {
+		name    string
+		request HoldRequest
+		want    want
+		mock    func(mockRepo *orderMocks.MockRepository)
+	}{
+		{
+			name: "hold created",
+			request: HoldRequest{
+				Order: testOrderNumber,
+				Sum:   decimal.NewFromInt(30),
+			},
+			want: want{
+				statusCode: http.StatusCreated,
+				body:       `{"id":"7c9e6679-7425-40de-944b-e07fc1f90ae7","order":"2377225624","sum":30,"status":"ACTIVE","expires_at":"2025-01-01T12:15:00Z"}` + "\n",
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					CreateHold(gomock.Any(), TestUserID1.String(), testOrderNumber, decimal.NewFromInt(30), gomock.Any()).
+					Return(newTestHold(repository.HoldstatusACTIVE), nil).
+					Times(1)
+			},
+		},
+		{
+			name: "insufficient funds",
+			request: HoldRequest{
+				Order: testOrderNumber,
+				Sum:   decimal.NewFromInt(300),
+			},
+			want: want{
+				statusCode: http.StatusPaymentRequired,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					CreateHold(gomock.Any(), TestUserID1.String(), testOrderNumber, decimal.NewFromInt(300), gomock.Any()).
+					Return(repository.BalanceHold{}, repository.ErrWithdrawInsufficientFunds).
+					Times(1)
+			},
+		},
+		{
+			name: "withdrawal limit of the tier exceeded",
+			request: HoldRequest{
+				Order: testOrderNumber,
+				Sum:   decimal.NewFromInt(30),
+			},
+			want: want{
+				statusCode: http.StatusForbidden,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					CreateHold(gomock.Any(), TestUserID1.String(), testOrderNumber, decimal.NewFromInt(30), gomock.Any()).
+					Return(repository.BalanceHold{}, repository.ErrWithdrawLimitExceeded).
+					Times(1)
+			},
+		},
+		{
+			name: "order number already used",
+			request: HoldRequest{
+				Order: testOrderNumber,
+				Sum:   decimal.NewFromInt(30),
+			},
+			want: want{
+				statusCode: http.StatusUnprocessableEntity,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					CreateHold(gomock.Any(), TestUserID1.String(), testOrderNumber, decimal.NewFromInt(30), gomock.Any()).
+					Return(repository.BalanceHold{}, repository.ErrOrderAlreadyExists).
+					Times(1)
+			},
+		},
+		{
+			name: "invalid order number",
+			request: HoldRequest{
+				Order: "123",
+				Sum:   decimal.NewFromInt(30),
+			},
+			want: want{
+				statusCode: http.StatusUnprocessableEntity,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {},
+		},
+		{
+			name: "negative amount",
+			request: HoldRequest{
+				Order: testOrderNumber,
+				Sum:   decimal.NewFromInt(-1),
+			},
+			want: want{
+				statusCode: http.StatusPaymentRequired,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockOrderRepo := orderMocks.NewMockRepository(ctrl)
+			tt.mock(mockOrderRepo)
+
+			handlerFunc := NewCreateHoldHandler(orderDomain.NewService(mockOrderRepo, orderDomain.Config{}), 15*time.Minute)
+
+			reqJSON, _ := json.Marshal(tt.request)
+			req := httptest.NewRequest(http.MethodPost, "/api/user/balance/holds", strings.NewReader(string(reqJSON)))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, TestUserID1.String()))
+			res := httptest.NewRecorder()
+
+			handlerFunc(res, req)
+
+			assert.Equal(t, tt.want.statusCode, res.Code)
+			if tt.want.body != "" {
+				assert.Equal(t, tt.want.body, res.Body.String())
+			}
+		})
+	}
+}
+
+func TestHoldActionHandlers(t *testing.T) {
+	t.Parallel()
+
+	type want struct {
+		statusCode int
+		body       string
+	}
+
+	tests := []struct {
+		name   string
+		action string
+		want   want
+		mock   func(mockRepo *orderMocks.MockRepository)
+	}{
+		{
+			name:   "hold captured",
+			action: "capture",
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"id":"7c9e6679-7425-40de-944b-e07fc1f90ae7","order":"2377225624","sum":30,"status":"CAPTURED","expires_at":"2025-01-01T12:15:00Z"}` + "\n",
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					CaptureHold(gomock.Any(), TestUserID1.String(), testHoldID.String()).
+					Return(newTestHold(repository.HoldstatusCAPTURED), repository.Order{}, nil).
+					Times(1)
+			},
+		},
+		{
+			name:   "capture expired hold",
+			action: "capture",
+			want: want{
+				statusCode: http.StatusGone,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					CaptureHold(gomock.Any(), TestUserID1.String(), testHoldID.String()).
+					Return(newTestHold(repository.HoldstatusEXPIRED), repository.Order{}, repository.ErrHoldExpired).
+					Times(1)
+			},
+		},
+		{
+			name:   "capture voided hold",
+			action: "capture",
+			want: want{
+				statusCode: http.StatusConflict,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					CaptureHold(gomock.Any(), TestUserID1.String(), testHoldID.String()).
+					Return(newTestHold(repository.HoldstatusVOIDED), repository.Order{}, repository.ErrHoldNotActive).
+					Times(1)
+			},
+		},
+		{
+			name:   "hold voided",
+			action: "void",
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"id":"7c9e6679-7425-40de-944b-e07fc1f90ae7","order":"2377225624","sum":30,"status":"VOIDED","expires_at":"2025-01-01T12:15:00Z"}` + "\n",
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					VoidHold(gomock.Any(), TestUserID1.String(), testHoldID.String()).
+					Return(newTestHold(repository.HoldstatusVOIDED), nil).
+					Times(1)
+			},
+		},
+		{
+			name:   "void unknown hold",
+			action: "void",
+			want: want{
+				statusCode: http.StatusNotFound,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					VoidHold(gomock.Any(), TestUserID1.String(), testHoldID.String()).
+					Return(repository.BalanceHold{}, repository.ErrHoldNotFound).
+					Times(1)
+			},
+		},
+		{
+			name:   "void fails",
+			action: "void",
+			want: want{
+				statusCode: http.StatusInternalServerError,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					VoidHold(gomock.Any(), TestUserID1.String(), testHoldID.String()).
+					Return(repository.BalanceHold{}, assert.AnError).
+					Times(1)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockOrderRepo := orderMocks.NewMockRepository(ctrl)
+			tt.mock(mockOrderRepo)
+
+			orderService := orderDomain.NewService(mockOrderRepo, orderDomain.Config{})
+			router := chi.NewRouter()
+			router.Post("/api/user/balance/holds/{"+HoldIDParam+"}/capture", NewCaptureHoldHandler(orderService))
+			router.Post("/api/user/balance/holds/{"+HoldIDParam+"}/void", NewVoidHoldHandler(orderService))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/user/balance/holds/"+testHoldID.String()+"/"+tt.action, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, TestUserID1.String()))
+			res := httptest.NewRecorder()
+
+			router.ServeHTTP(res, req)
+
+			assert.Equal(t, tt.want.statusCode, res.Code)
+			if tt.want.body != "" {
+				assert.Equal(t, tt.want.body, res.Body.String())
+			}
+		})
+	}
+}
@@ -25,10 +25,104 @@ type WithdrawRequest struct {
 type WithdrawalResponse struct {
 	Order       string    `json:"order"`
 	Sum         float32   `json:"sum"`
+	Status      string    `json:"status"`
+	Refunded    float32   `json:"refunded,omitempty"`
 	ProcessedAt time.Time `json:"processed_at"`
 }
 
+type RefundRequest struct {
+	Sum decimal.Decimal `json:"sum"`
+}
+
 type BalanceResponse struct {
-	Current   float32 `json:"current"`
-	Withdrawn float32 `json:"withdrawn"`
+	Current      float32    `json:"current"`
+	Withdrawn    float32    `json:"withdrawn"`
+	Held         float32    `json:"held"`
+	ExpiringSoon float32    `json:"expiring_soon"`
+	NextExpiry   *time.Time `json:"next_expiry,omitempty"`
+}
+
+type HoldRequest struct {
+	Order string          `json:"order"`
+	Sum   decimal.Decimal `json:"sum"`
+}
+
+type HoldResponse struct {
+	ID        string    `json:"id"`
+	Order     string    `json:"order"`
+	Sum       float32   `json:"sum"`
+	Status    string    `json:"status"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type TierResponse struct {
+	Tier              string  `json:"tier"`
+	Volume            float32 `json:"volume"`
+	AccrualMultiplier float32 `json:"accrual_multiplier"`
+	// WithdrawalLimit is left out when withdrawals are unlimited.
+	WithdrawalLimit float32           `json:"withdrawal_limit,omitempty"`
+	Next            *NextTierResponse `json:"next,omitempty"`
+}
+
+type NextTierResponse struct {
+	Tier      string  `json:"tier"`
+	Threshold float32 `json:"threshold"`
+	Remaining float32 `json:"remaining"`
+}
+
+type TransferRequest struct {
+	Login string          `json:"login"`
+	Sum   decimal.Decimal `json:"sum"`
+}
+
+type TransferResponse struct {
+	ID        string `json:"id"`
+	Direction string `json:"direction"`
+	// Counterparty is the masked login of the other user.
+	Counterparty string    `json:"counterparty"`
+	Sum          float32   `json:"sum"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type GiftCodeBatchRequest struct {
+	Name           string          `json:"name"`
+	Count          int             `json:"count"`
+	Sum            decimal.Decimal `json:"sum"`
+	MaxRedemptions int             `json:"max_redemptions"`
+	// ExpiresAt is left out for codes that never expire.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type GiftCodeBatchResponse struct {
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	Sum            float32    `json:"sum"`
+	MaxRedemptions int        `json:"max_redemptions"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	Codes          []string   `json:"codes"`
+}
+
+type RedeemGiftCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type GiftCodeRedemptionResponse struct {
+	Code       string    `json:"code"`
+	Sum        float32   `json:"sum"`
+	RedeemedAt time.Time `json:"redeemed_at"`
+}
+
+type ReferralsResponse struct {
+	Code      string             `json:"code"`
+	Referrals []ReferralResponse `json:"referrals"`
+}
+
+type ReferralResponse struct {
+	Login  string  `json:"login"`
+	Status string  `json:"status"`
+	Reason string  `json:"reason,omitempty"`
+	Reward float32 `json:"reward"`
+	// SettledAt is left out while the referral is pending.
+	SettledAt    *time.Time `json:"settled_at,omitempty"`
+	RegisteredAt time.Time  `json:"registered_at"`
 }
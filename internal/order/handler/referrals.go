@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/aifedorov/gophermart/internal/order/domain"
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/aifedorov/gophermart/internal/pkg/middleware"
+
+	"go.uber.org/zap"
+)
+
+func NewReferralsHandler(orderService domain.Service) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		log := logger.FromContext(req.Context())
+		rw.Header().Set("Content-Type", "application/json")
+
+		userID, _ := middleware.GetUserID(req)
+		referrals, err := orderService.GetReferrals(req.Context(), userID)
+		if err != nil {
+			log.Error("failed to get referrals", zap.Error(err))
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		rw.WriteHeader(http.StatusOK)
+		if err := encodeJSONResponse(req.Context(), rw, ToReferralsResponse(referrals)); err != nil {
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+	}
+}
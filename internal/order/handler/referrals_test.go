@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	orderDomain "github.com/aifedorov/gophermart/internal/order/domain"
+	repository "github.com/aifedorov/gophermart/internal/order/repository/db"
+	orderMocks "github.com/aifedorov/gophermart/internal/order/repository/mocks"
+	"github.com/aifedorov/gophermart/internal/pkg/middleware"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestReferralsHandler(t *testing.T) {
+	t.Parallel()
+
+	registeredAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	settledAt := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	type want struct {
+		statusCode int
+		body       string
+	}
+
+	tests := []struct {
+		name string
+		want want
+		mock func(mockRepo *orderMocks.MockRepository)
+	}{
+		{
+			name: "referrals with every status",
+			want: want{
+				statusCode: http.StatusOK,
+				body: `{"code":"ABCD2345","referrals":[` +
+					`{"login":"p***g","status":"PENDING","reward":0,"registered_at":"2025-01-01T12:00:00Z"},` +
+					`{"login":"r***d","status":"REWARDED","reward":100,"settled_at":"2025-01-02T12:00:00Z","registered_at":"2025-01-01T12:00:00Z"},` +
+					`{"login":"c***d","status":"REJECTED","reason":"REFERRER_CAP_REACHED","reward":0,"settled_at":"2025-01-02T12:00:00Z","registered_at":"2025-01-01T12:00:00Z"}` +
+					`]}` + "\n",
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					GetReferrals(gomock.Any(), TestUserID1.String()).
+					Return("ABCD2345", []repository.GetReferralsByReferrerIDRow{
+						{
+							Referred:  "pending",
+							Status:    repository.ReferralstatusPENDING,
+							CreatedAt: pgtype.Timestamptz{Time: registeredAt, Valid: true},
+						},
+						{
+							Referred:       "rewarded",
+							Status:         repository.ReferralstatusREWARDED,
+							ReferrerReward: decimal.NewFromInt(100),
+							CreatedAt:      pgtype.Timestamptz{Time: registeredAt, Valid: true},
+							SettledAt:      pgtype.Timestamptz{Time: settledAt, Valid: true},
+						},
+						{
+							Referred:  "capped",
+							Status:    repository.ReferralstatusREJECTED,
+							Reason:    pgtype.Text{String: repository.ReferralReasonReferrerCapReached, Valid: true},
+							CreatedAt: pgtype.Timestamptz{Time: registeredAt, Valid: true},
+							SettledAt: pgtype.Timestamptz{Time: settledAt, Valid: true},
+						},
+					}, nil).
+					Times(1)
+			},
+		},
+		{
+			name: "no referrals",
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"code":"ABCD2345","referrals":[]}` + "\n",
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					GetReferrals(gomock.Any(), TestUserID1.String()).
+					Return("ABCD2345", nil, nil).
+					Times(1)
+			},
+		},
+		{
+			name: "repository error",
+			want: want{
+				statusCode: http.StatusInternalServerError,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					GetReferrals(gomock.Any(), TestUserID1.String()).
+					Return("", nil, assert.AnError).
+					Times(1)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockOrderRepo := orderMocks.NewMockRepository(ctrl)
+			tt.mock(mockOrderRepo)
+
+			handlerFunc := NewReferralsHandler(orderDomain.NewService(mockOrderRepo, orderDomain.Config{}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/user/referrals", nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, TestUserID1.String()))
+			res := httptest.NewRecorder()
+
+			handlerFunc(res, req)
+
+			assert.Equal(t, tt.want.statusCode, res.Code)
+			if tt.want.body != "" {
+				assert.Equal(t, tt.want.body, res.Body.String())
+			}
+		})
+	}
+}
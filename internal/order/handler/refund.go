@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/aifedorov/gophermart/internal/order/domain"
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// UserIDParam is the route parameter that carries the id of the user an admin request is about.
+	UserIDParam = "userID"
+	// OrderNumberParam is the route parameter that carries the withdrawal order number.
+	OrderNumberParam = "orderNumber"
+)
+
+// RefundUserID scopes the idempotency keys of a refund to the refunded user, because admin
+// requests carry no user of their own.
+func RefundUserID(req *http.Request) (string, error) {
+	userID := chi.URLParam(req, UserIDParam)
+	if _, err := uuid.Parse(userID); err != nil {
+		return "", err
+	}
+	return userID, nil
+}
+
+// NewRefundHandler returns points of a user's withdrawal once the merchant has refunded the
+// purchase. It belongs to the admin API: the user is taken from the path, never from the caller.
+func NewRefundHandler(orderService domain.Service) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		log := logger.FromContext(req.Context())
+		rw.Header().Set("Content-Type", "application/json")
+
+		body, err := decodeRefund(req)
+		if err != nil {
+			log.Info("failed to decode request", zap.Error(err))
+			http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		userID := chi.URLParam(req, UserIDParam)
+		orderNumber := chi.URLParam(req, OrderNumberParam)
+		withdrawal, err := orderService.RefundWithdrawal(req.Context(), userID, orderNumber, body.Sum)
+		switch {
+		case errors.Is(err, domain.ErrRefundNegativeAmount):
+			log.Info("non-positive refund amount")
+			http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		case errors.Is(err, domain.ErrWithdrawalNotFound):
+			log.Info("withdrawal not found", zap.String("userID", userID), zap.String("order", orderNumber))
+			http.Error(rw, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		case errors.Is(err, domain.ErrRefundExceedsWithdrawal):
+			log.Info("refund exceeds withdrawal", zap.String("order", orderNumber))
+			http.Error(rw, http.StatusText(http.StatusUnprocessableEntity), http.StatusUnprocessableEntity)
+			return
+		case err != nil:
+			log.Error("failed to refund withdrawal", zap.Error(err))
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		rw.WriteHeader(http.StatusOK)
+		if err := encodeJSONResponse(req.Context(), rw, ToWithdrawalResponse(withdrawal)); err != nil {
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+	}
+}
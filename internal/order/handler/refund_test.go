@@ -0,0 +1,272 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	orderDomain "github.com/aifedorov/gophermart/internal/order/domain"
+	repository "github.com/aifedorov/gophermart/internal/order/repository/db"
+	orderMocks "github.com/aifedorov/gophermart/internal/order/repository/mocks"
+	"github.com/aifedorov/gophermart/internal/pkg/middleware"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRefundHandler(t *testing.T) {
+	t.Parallel()
+
+	fixedTime := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	withdrawal := repository.Order{
+		Number:      testOrderNumber,
+		Amount:      decimal.NewFromInt(100),
+		ProcessedAt: pgtype.Timestamptz{Time: fixedTime, Valid: true},
+	}
+
+	type want struct {
+		statusCode int
+		body       string
+	}
+
+	tests := []struct {
+		name string
+		body string
+		user string
+		want want
+		mock func(mockRepo *orderMocks.MockRepository)
+	}{
+		{
+			name: "partial refund",
+			body: `{"sum":40}`,
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"order":"2377225624","sum":100,"status":"PARTIALLY_REVERSED","refunded":40,"processed_at":"2023-01-01T12:00:00Z"}` + "\n",
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					RefundWithdrawal(gomock.Any(), TestUserID1.String(), testOrderNumber, decimal.NewFromInt(40)).
+					Return(repository.GetWithdrawalsByUserIDRow{Order: withdrawal, Refunded: decimal.NewFromInt(40)}, nil).
+					Times(1)
+			},
+		},
+		{
+			name: "full refund",
+			body: `{"sum":100}`,
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"order":"2377225624","sum":100,"status":"REVERSED","refunded":100,"processed_at":"2023-01-01T12:00:00Z"}` + "\n",
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					RefundWithdrawal(gomock.Any(), TestUserID1.String(), testOrderNumber, decimal.NewFromInt(100)).
+					Return(repository.GetWithdrawalsByUserIDRow{Order: withdrawal, Refunded: decimal.NewFromInt(100)}, nil).
+					Times(1)
+			},
+		},
+		{
+			name: "refund exceeds withdrawal",
+			body: `{"sum":101}`,
+			want: want{
+				statusCode: http.StatusUnprocessableEntity,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					RefundWithdrawal(gomock.Any(), TestUserID1.String(), testOrderNumber, decimal.NewFromInt(101)).
+					Return(repository.GetWithdrawalsByUserIDRow{}, repository.ErrRefundExceedsWithdrawal).
+					Times(1)
+			},
+		},
+		{
+			name: "unknown withdrawal",
+			body: `{"sum":10}`,
+			want: want{
+				statusCode: http.StatusNotFound,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					RefundWithdrawal(gomock.Any(), TestUserID1.String(), testOrderNumber, decimal.NewFromInt(10)).
+					Return(repository.GetWithdrawalsByUserIDRow{}, repository.ErrWithdrawalNotFound).
+					Times(1)
+			},
+		},
+		{
+			name: "invalid user id",
+			body: `{"sum":10}`,
+			user: "not-a-uuid",
+			want: want{
+				statusCode: http.StatusNotFound,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					RefundWithdrawal(gomock.Any(), "not-a-uuid", testOrderNumber, decimal.NewFromInt(10)).
+					Return(repository.GetWithdrawalsByUserIDRow{}, repository.ErrWithdrawalNotFound).
+					Times(1)
+			},
+		},
+		{
+			name: "non-positive amount",
+			body: `{"sum":0}`,
+			want: want{
+				statusCode: http.StatusBadRequest,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {},
+		},
+		{
+			name: "empty body",
+			want: want{
+				statusCode: http.StatusBadRequest,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {},
+		},
+		{
+			name: "repository error",
+			body: `{"sum":10}`,
+			want: want{
+				statusCode: http.StatusInternalServerError,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					RefundWithdrawal(gomock.Any(), TestUserID1.String(), testOrderNumber, decimal.NewFromInt(10)).
+					Return(repository.GetWithdrawalsByUserIDRow{}, assert.AnError).
+					Times(1)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockOrderRepo := orderMocks.NewMockRepository(ctrl)
+			tt.mock(mockOrderRepo)
+
+			if tt.user == "" {
+				tt.user = TestUserID1.String()
+			}
+			router := newRefundRouter(mockOrderRepo)
+
+			req := httptest.NewRequest(http.MethodPost, refundPath(tt.user), strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(middleware.AdminKeyHeader, testAdminKey)
+			res := httptest.NewRecorder()
+
+			router.ServeHTTP(res, req)
+
+			assert.Equal(t, tt.want.statusCode, res.Code)
+			if tt.want.body != "" {
+				assert.Equal(t, tt.want.body, res.Body.String())
+			}
+		})
+	}
+}
+
+func TestRefundHandlerRequiresAdmin(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		apiKey string
+	}{
+		{
+			name: "user token without api key",
+		},
+		{
+			name:   "wrong api key",
+			apiKey: "wrong",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// The repository mock has no expectations, so any refund attempt fails the test.
+			router := newRefundRouter(orderMocks.NewMockRepository(ctrl))
+
+			req := httptest.NewRequest(http.MethodPost, refundPath(TestUserID1.String()), strings.NewReader(`{"sum":10}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.apiKey != "" {
+				req.Header.Set(middleware.AdminKeyHeader, tt.apiKey)
+			}
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, TestUserID1.String()))
+			res := httptest.NewRecorder()
+
+			router.ServeHTTP(res, req)
+
+			assert.Equal(t, http.StatusUnauthorized, res.Code)
+		})
+	}
+}
+
+func TestRefundUserID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		userID  string
+		wantErr bool
+	}{
+		{
+			name:   "valid user id",
+			userID: TestUserID1.String(),
+		},
+		{
+			name:    "invalid user id",
+			userID:  "not-a-uuid",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				userID string
+				err    error
+			)
+			router := chi.NewRouter()
+			router.Post("/api/admin/users/{"+UserIDParam+"}/withdrawals/{"+OrderNumberParam+"}/refund", func(rw http.ResponseWriter, req *http.Request) {
+				userID, err = RefundUserID(req)
+			})
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, refundPath(tt.userID), nil))
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.userID, userID)
+		})
+	}
+}
+
+const testAdminKey = "admin-secret"
+
+func newRefundRouter(mockRepo *orderMocks.MockRepository) http.Handler {
+	router := chi.NewRouter()
+	router.Use(middleware.NewAdminMiddleware(testAdminKey).RequireAdmin)
+	router.Post("/api/admin/users/{"+UserIDParam+"}/withdrawals/{"+OrderNumberParam+"}/refund", NewRefundHandler(orderDomain.NewService(mockRepo, orderDomain.Config{})))
+	return router
+}
+
+func refundPath(userID string) string {
+	return "/api/admin/users/" + userID + "/withdrawals/" + testOrderNumber + "/refund"
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	orderDomain "github.com/aifedorov/gophermart/internal/order/domain"
+	repository "github.com/aifedorov/gophermart/internal/order/repository/db"
+	orderMocks "github.com/aifedorov/gophermart/internal/order/repository/mocks"
+	"github.com/aifedorov/gophermart/internal/pkg/middleware"
+	"github.com/shopspring/decimal"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestTierHandler(t *testing.T) {
+	t.Parallel()
+
+	tiers, err := orderDomain.ParseTiers([]string{"BRONZE:0:1:0", "SILVER:1000:1.1:5000", "GOLD:5000:1.25:0"})
+	assert.NoError(t, err)
+	policy := orderDomain.TierPolicy{Window: 90 * 24 * time.Hour, Tiers: tiers}
+
+	type want struct {
+		statusCode int
+		body       string
+	}
+
+	tests := []struct {
+		name string
+		want want
+		mock func(mockRepo *orderMocks.MockRepository)
+	}{
+		{
+			name: "user without a tier yet",
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"tier":"BRONZE","volume":250,"accrual_multiplier":1,"next":{"tier":"SILVER","threshold":1000,"remaining":750}}` + "\n",
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					GetUserTier(gomock.Any(), TestUserID1.String()).
+					Return(repository.UserTier{UserID: TestUserID1}, nil).
+					Times(1)
+				mockRepo.EXPECT().
+					GetAccrualVolume(gomock.Any(), TestUserID1.String(), gomock.Any()).
+					Return(decimal.NewFromInt(250), nil).
+					Times(1)
+			},
+		},
+		{
+			name: "silver user past the gold threshold",
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"tier":"SILVER","volume":5200,"accrual_multiplier":1.1,"withdrawal_limit":5000,"next":{"tier":"GOLD","threshold":5000,"remaining":0}}` + "\n",
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					GetUserTier(gomock.Any(), TestUserID1.String()).
+					Return(repository.UserTier{
+						UserID:            TestUserID1,
+						Tier:              "SILVER",
+						AccrualMultiplier: decimal.NewFromFloat(1.1),
+						WithdrawalLimit:   decimal.NewFromInt(5000),
+					}, nil).
+					Times(1)
+				mockRepo.EXPECT().
+					GetAccrualVolume(gomock.Any(), TestUserID1.String(), gomock.Any()).
+					Return(decimal.NewFromInt(5200), nil).
+					Times(1)
+			},
+		},
+		{
+			name: "top tier has no next tier",
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"tier":"GOLD","volume":8000,"accrual_multiplier":1.25}` + "\n",
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					GetUserTier(gomock.Any(), TestUserID1.String()).
+					Return(repository.UserTier{UserID: TestUserID1, Tier: "GOLD", AccrualMultiplier: decimal.NewFromFloat(1.25)}, nil).
+					Times(1)
+				mockRepo.EXPECT().
+					GetAccrualVolume(gomock.Any(), TestUserID1.String(), gomock.Any()).
+					Return(decimal.NewFromInt(8000), nil).
+					Times(1)
+			},
+		},
+		{
+			name: "repository error",
+			want: want{
+				statusCode: http.StatusInternalServerError,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					GetUserTier(gomock.Any(), TestUserID1.String()).
+					Return(repository.UserTier{}, assert.AnError).
+					Times(1)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockOrderRepo := orderMocks.NewMockRepository(ctrl)
+			tt.mock(mockOrderRepo)
+
+			handlerFunc := NewTierHandler(orderDomain.NewService(mockOrderRepo, orderDomain.Config{Tiers: policy}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/user/tier", nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, TestUserID1.String()))
+			res := httptest.NewRecorder()
+
+			handlerFunc(res, req)
+
+			assert.Equal(t, tt.want.statusCode, res.Code)
+			if tt.want.body != "" {
+				assert.Equal(t, tt.want.body, res.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/aifedorov/gophermart/internal/order/domain"
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/aifedorov/gophermart/internal/pkg/middleware"
+
+	"go.uber.org/zap"
+)
+
+func NewTransferHandler(orderService domain.Service) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		log := logger.FromContext(req.Context())
+		rw.Header().Set("Content-Type", "application/json")
+
+		body, err := decodeTransfer(req)
+		if err != nil || body.Login == "" {
+			log.Info("failed to decode request", zap.Error(err))
+			http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		userID, _ := middleware.GetUserID(req)
+		transfer, err := orderService.Transfer(req.Context(), userID, body.Login, body.Sum)
+		switch {
+		case errors.Is(err, domain.ErrTransferNegativeAmount):
+			log.Info("non-positive amount of points to transfer")
+			http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		case errors.Is(err, domain.ErrRecipientNotFound):
+			log.Info("transfer recipient not found")
+			http.Error(rw, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		case errors.Is(err, domain.ErrTransferToSelf):
+			log.Info("transfer to yourself")
+			http.Error(rw, http.StatusText(http.StatusUnprocessableEntity), http.StatusUnprocessableEntity)
+			return
+		case errors.Is(err, domain.ErrWithdrawInsufficientFunds):
+			log.Info("insufficient funds to transfer")
+			http.Error(rw, http.StatusText(http.StatusPaymentRequired), http.StatusPaymentRequired)
+			return
+		case errors.Is(err, domain.ErrTransferLimitExceeded):
+			log.Info("daily transfer limit exceeded")
+			http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		case err != nil:
+			log.Error("failed to transfer points", zap.Error(err))
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		rw.WriteHeader(http.StatusOK)
+		if err := encodeJSONResponse(req.Context(), rw, ToTransferResponse(transfer)); err != nil {
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func NewTransfersHandler(orderService domain.Service) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		log := logger.FromContext(req.Context())
+		rw.Header().Set("Content-Type", "application/json")
+
+		userID, _ := middleware.GetUserID(req)
+		transfers, err := orderService.GetTransfers(req.Context(), userID)
+		if err != nil {
+			log.Error("failed to get transfers", zap.Error(err))
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		if len(transfers) == 0 {
+			rw.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		transferResponses := make([]TransferResponse, len(transfers))
+		for i, transfer := range transfers {
+			transferResponses[i] = ToTransferResponse(transfer)
+		}
+
+		rw.WriteHeader(http.StatusOK)
+		if err := encodeJSONResponse(req.Context(), rw, transferResponses); err != nil {
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+	}
+}
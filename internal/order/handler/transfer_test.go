@@ -0,0 +1,279 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	orderDomain "github.com/aifedorov/gophermart/internal/order/domain"
+	repository "github.com/aifedorov/gophermart/internal/order/repository/db"
+	orderMocks "github.com/aifedorov/gophermart/internal/order/repository/mocks"
+	"github.com/aifedorov/gophermart/internal/pkg/middleware"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+var testTransferID = uuid.MustParse("3f2b8c1e-9d4a-4e6b-8a1f-2c5d7e9b0a13")
+
+var testTransferredAt = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+var testTransferLimit = decimal.NewFromInt(1000)
+
+func TestTransferHandler(t *testing.T) {
+	t.Parallel()
+
+	type want struct {
+		statusCode int
+		body       string
+	}
+
+	tests := []struct {
+		name    string
+		request string
+		want    want
+		mock    func(mockRepo *orderMocks.MockRepository)
+	}{
+		{
+			name:    "points transferred",
+			request: `{"login":"recipient","sum":25.5}`,
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"id":"3f2b8c1e-9d4a-4e6b-8a1f-2c5d7e9b0a13","direction":"OUT","counterparty":"r***t","sum":25.5,"created_at":"2025-01-01T12:00:00Z"}` + "\n",
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					CreateTransfer(gomock.Any(), TestUserID1.String(), "recipient", decimal.NewFromFloat(25.5), testTransferLimit).
+					Return(repository.GetTransfersByUserIDRow{
+						ID:           testTransferID,
+						Amount:       decimal.NewFromFloat(25.5),
+						CreatedAt:    pgtype.Timestamptz{Time: testTransferredAt, Valid: true},
+						Outgoing:     true,
+						Counterparty: "recipient",
+					}, nil).
+					Times(1)
+			},
+		},
+		{
+			name:    "empty body",
+			request: ``,
+			want: want{
+				statusCode: http.StatusBadRequest,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {},
+		},
+		{
+			name:    "missing login",
+			request: `{"sum":25}`,
+			want: want{
+				statusCode: http.StatusBadRequest,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {},
+		},
+		{
+			name:    "negative amount",
+			request: `{"login":"recipient","sum":-1}`,
+			want: want{
+				statusCode: http.StatusBadRequest,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {},
+		},
+		{
+			name:    "recipient not found",
+			request: `{"login":"nobody","sum":25}`,
+			want: want{
+				statusCode: http.StatusNotFound,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					CreateTransfer(gomock.Any(), TestUserID1.String(), "nobody", decimal.NewFromInt(25), testTransferLimit).
+					Return(repository.GetTransfersByUserIDRow{}, repository.ErrRecipientNotFound).
+					Times(1)
+			},
+		},
+		{
+			name:    "transfer to yourself",
+			request: `{"login":"sender","sum":25}`,
+			want: want{
+				statusCode: http.StatusUnprocessableEntity,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					CreateTransfer(gomock.Any(), TestUserID1.String(), "sender", decimal.NewFromInt(25), testTransferLimit).
+					Return(repository.GetTransfersByUserIDRow{}, repository.ErrTransferToSelf).
+					Times(1)
+			},
+		},
+		{
+			name:    "insufficient funds",
+			request: `{"login":"recipient","sum":25}`,
+			want: want{
+				statusCode: http.StatusPaymentRequired,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					CreateTransfer(gomock.Any(), TestUserID1.String(), "recipient", decimal.NewFromInt(25), testTransferLimit).
+					Return(repository.GetTransfersByUserIDRow{}, repository.ErrWithdrawInsufficientFunds).
+					Times(1)
+			},
+		},
+		{
+			name:    "daily limit exceeded",
+			request: `{"login":"recipient","sum":25}`,
+			want: want{
+				statusCode: http.StatusForbidden,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					CreateTransfer(gomock.Any(), TestUserID1.String(), "recipient", decimal.NewFromInt(25), testTransferLimit).
+					Return(repository.GetTransfersByUserIDRow{}, repository.ErrTransferLimitExceeded).
+					Times(1)
+			},
+		},
+		{
+			name:    "repository error",
+			request: `{"login":"recipient","sum":25}`,
+			want: want{
+				statusCode: http.StatusInternalServerError,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					CreateTransfer(gomock.Any(), TestUserID1.String(), "recipient", decimal.NewFromInt(25), testTransferLimit).
+					Return(repository.GetTransfersByUserIDRow{}, assert.AnError).
+					Times(1)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockOrderRepo := orderMocks.NewMockRepository(ctrl)
+			tt.mock(mockOrderRepo)
+
+			handlerFunc := NewTransferHandler(orderDomain.NewService(mockOrderRepo, orderDomain.Config{TransferDailyLimit: testTransferLimit}))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/user/transfers", strings.NewReader(tt.request))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, TestUserID1.String()))
+			res := httptest.NewRecorder()
+
+			handlerFunc(res, req)
+
+			assert.Equal(t, tt.want.statusCode, res.Code)
+			if tt.want.body != "" {
+				assert.Equal(t, tt.want.body, res.Body.String())
+			}
+		})
+	}
+}
+
+func TestTransfersHandler(t *testing.T) {
+	t.Parallel()
+
+	type want struct {
+		statusCode int
+		transfers  []TransferResponse
+	}
+
+	tests := []struct {
+		name string
+		want want
+		mock func(mockRepo *orderMocks.MockRepository)
+	}{
+		{
+			name: "both directions with masked logins",
+			want: want{
+				statusCode: http.StatusOK,
+				transfers: []TransferResponse{
+					{ID: testTransferID.String(), Direction: "IN", Counterparty: "b***b", Sum: 10, CreatedAt: testTransferredAt},
+					{ID: testTransferID.String(), Direction: "OUT", Counterparty: "a*", Sum: 5, CreatedAt: testTransferredAt},
+				},
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					GetTransfers(gomock.Any(), TestUserID1.String()).
+					Return([]repository.GetTransfersByUserIDRow{
+						{
+							ID:           testTransferID,
+							Amount:       decimal.NewFromInt(10),
+							CreatedAt:    pgtype.Timestamptz{Time: testTransferredAt, Valid: true},
+							Counterparty: "bob",
+						},
+						{
+							ID:           testTransferID,
+							Amount:       decimal.NewFromInt(5),
+							CreatedAt:    pgtype.Timestamptz{Time: testTransferredAt, Valid: true},
+							Outgoing:     true,
+							Counterparty: "al",
+						},
+					}, nil).
+					Times(1)
+			},
+		},
+		{
+			name: "no transfers",
+			want: want{
+				statusCode: http.StatusNoContent,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					GetTransfers(gomock.Any(), TestUserID1.String()).
+					Return(nil, nil).
+					Times(1)
+			},
+		},
+		{
+			name: "repository error",
+			want: want{
+				statusCode: http.StatusInternalServerError,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					GetTransfers(gomock.Any(), TestUserID1.String()).
+					Return(nil, assert.AnError).
+					Times(1)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockOrderRepo := orderMocks.NewMockRepository(ctrl)
+			tt.mock(mockOrderRepo)
+
+			handlerFunc := NewTransfersHandler(orderDomain.NewService(mockOrderRepo, orderDomain.Config{}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/user/transfers", nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, TestUserID1.String()))
+			res := httptest.NewRecorder()
+
+			handlerFunc(res, req)
+
+			assert.Equal(t, tt.want.statusCode, res.Code)
+			if tt.want.transfers != nil {
+				var got []TransferResponse
+				assert.NoError(t, json.NewDecoder(res.Body).Decode(&got))
+				assert.Equal(t, tt.want.transfers, got)
+			}
+		})
+	}
+}
@@ -14,35 +14,41 @@ import (
 
 func NewWithdrawHandler(orderService domain.Service) http.HandlerFunc {
 	return func(rw http.ResponseWriter, req *http.Request) {
+		log := logger.FromContext(req.Context())
 		rw.Header().Set("Content-Type", "application/json")
 
 		body, err := decodeWithdraw(req)
 		if err != nil {
-			logger.Log.Info("failed to decode request", zap.Error(err))
+			log.Info("failed to decode request", zap.Error(err))
 			http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 			return
 		}
 
 		userID, _ := middleware.GetUserID(req)
 		if !domain.IsValidOrderNumber(body.Order) {
-			logger.Log.Info("invalid order number", zap.String("order", body.Order))
+			log.Info("invalid order number", zap.String("order", body.Order))
 			http.Error(rw, http.StatusText(http.StatusUnprocessableEntity), http.StatusUnprocessableEntity)
 			return
 		}
 
-		_, status, err := orderService.Withdraw(userID, body.Order, body.Sum)
+		_, status, err := orderService.Withdraw(req.Context(), userID, body.Order, body.Sum)
 		if errors.Is(err, domain.ErrWithdrawNegativeAmount) {
-			logger.Log.Info("negative amount of money to withdraw")
+			log.Info("negative amount of money to withdraw")
 			http.Error(rw, http.StatusText(http.StatusPaymentRequired), http.StatusPaymentRequired)
 			return
 		}
 		if errors.Is(err, domain.ErrWithdrawInsufficientFunds) {
-			logger.Log.Info("insufficient funds to withdraw")
+			log.Info("insufficient funds to withdraw")
 			http.Error(rw, http.StatusText(http.StatusPaymentRequired), http.StatusPaymentRequired)
 			return
 		}
+		if errors.Is(err, domain.ErrWithdrawLimitExceeded) {
+			log.Info("withdrawal limit exceeded")
+			http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
 		if err != nil {
-			logger.Log.Error("failed to withdraw money", zap.Error(err))
+			log.Error("failed to withdraw money", zap.Error(err))
 			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 			return
 		}
@@ -51,10 +57,10 @@ func NewWithdrawHandler(orderService domain.Service) http.HandlerFunc {
 		case domain.CreateStatusSuccess:
 			rw.WriteHeader(http.StatusOK)
 		case domain.CreateStatusAlreadyUploaded:
-			logger.Log.Info("order already uploaded", zap.String("order", body.Order))
+			log.Info("order already uploaded", zap.String("order", body.Order))
 			http.Error(rw, http.StatusText(http.StatusUnprocessableEntity), http.StatusUnprocessableEntity)
 		default:
-			logger.Log.Error("failed to withdraw money", zap.Error(err))
+			log.Error("failed to withdraw money", zap.Error(err))
 			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		}
 	}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	orderDomain "github.com/aifedorov/gophermart/internal/order/domain"
+	repository "github.com/aifedorov/gophermart/internal/order/repository/db"
+	"github.com/aifedorov/gophermart/internal/pkg/middleware"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWithdrawHandlerConcurrent needs a migrated database in TEST_DATABASE_URI.
+func TestWithdrawHandlerConcurrent(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URI")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URI is not set")
+	}
+
+	const (
+		initialBalance = 100
+		requests       = 300
+	)
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	userID := uuid.New()
+	t.Cleanup(func() {
+		deleteTestUser(t, pool, userID)
+	})
+	_, err = pool.Exec(ctx, "INSERT INTO users (id, username, password_hash, referral_code) VALUES ($1, $2, 'hash', $3)",
+		userID, "withdraw-race-"+userID.String(), userID.String())
+	require.NoError(t, err)
+	_, err = pool.Exec(ctx, "INSERT INTO user_balances (user_id, current) VALUES ($1, $2)",
+		userID, initialBalance)
+	require.NoError(t, err)
+	_, err = pool.Exec(ctx, "INSERT INTO point_lots (user_id, reference, amount, remaining) VALUES ($1, 'seed', $2, $2)",
+		userID, initialBalance)
+	require.NoError(t, err)
+
+	handlerFunc := NewWithdrawHandler(orderDomain.NewService(repository.NewRepository(pool, repository.Config{}), orderDomain.Config{}))
+	prefix := fmt.Sprintf("%09d", rand.IntN(1_000_000_000))
+
+	var wg sync.WaitGroup
+	codes := make(chan int, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			reqJSON, _ := json.Marshal(WithdrawRequest{
+				Order: withLuhnDigit(fmt.Sprintf("%s%05d", prefix, i)),
+				Sum:   decimal.NewFromInt(1),
+			})
+			req := httptest.NewRequest(http.MethodPost, "/api/user/balance/withdraw", strings.NewReader(string(reqJSON)))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, userID.String()))
+			res := httptest.NewRecorder()
+
+			handlerFunc(res, req)
+			codes <- res.Code
+		}(i)
+	}
+	wg.Wait()
+	close(codes)
+
+	statuses := make(map[int]int)
+	for code := range codes {
+		statuses[code]++
+	}
+	assert.Equal(t, map[int]int{
+		http.StatusOK:              initialBalance,
+		http.StatusPaymentRequired: requests - initialBalance,
+	}, statuses)
+
+	balance, err := repository.NewRepository(pool, repository.Config{}).GetUserBalanceByUserID(ctx, userID.String())
+	require.NoError(t, err)
+	assert.True(t, balance.Current.IsZero(), "current balance is %s", balance.Current)
+	assert.True(t, balance.Withdrawn.Equal(decimal.NewFromInt(initialBalance)), "withdrawn is %s", balance.Withdrawn)
+}
+
+// deleteTestUser removes the user with everything that cascades from it and the user's
+// ledger transactions, which are only linked to the user by the account name.
+func deleteTestUser(t *testing.T, pool *pgxpool.Pool, userID uuid.UUID) {
+	t.Helper()
+
+	ctx := context.Background()
+	_, err := pool.Exec(ctx, `DELETE FROM ledger_entries WHERE transaction_id IN
+		(SELECT transaction_id FROM ledger_entries WHERE account = $1)`, repository.UserAccount(userID))
+	assert.NoError(t, err)
+	_, err = pool.Exec(ctx, "DELETE FROM users WHERE id = $1", userID)
+	assert.NoError(t, err)
+}
+
+func withLuhnDigit(number string) string {
+	sum := 0
+	for i := len(number) - 1; i >= 0; i-- {
+		digit := int(number[i] - '0')
+		if (len(number)-i)%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+	return fmt.Sprintf("%s%d", number, (10-sum%10)%10)
+}
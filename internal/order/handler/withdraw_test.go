@@ -54,7 +54,7 @@ func TestWithdrawHandler(t *testing.T) {
 			},
 			mock: func(mockRepo *orderMocks.MockRepository) {
 				mockRepo.EXPECT().
-					CreateWithdrawalOrder(TestUserID1.String(), testOrderNumber, decimal.NewFromInt(50)).
+					CreateWithdrawalOrder(gomock.Any(), TestUserID1.String(), testOrderNumber, decimal.NewFromInt(50)).
 					Return(repository.Order{}, nil).
 					Times(1)
 			},
@@ -73,7 +73,7 @@ func TestWithdrawHandler(t *testing.T) {
 			},
 			mock: func(mockRepo *orderMocks.MockRepository) {
 				mockRepo.EXPECT().
-					CreateWithdrawalOrder(TestUserID1.String(), testOrderNumber, decimal.NewFromInt(100)).
+					CreateWithdrawalOrder(gomock.Any(), TestUserID1.String(), testOrderNumber, decimal.NewFromInt(100)).
 					Return(repository.Order{}, nil).
 					Times(1)
 			},
@@ -93,11 +93,30 @@ func TestWithdrawHandler(t *testing.T) {
 			},
 			mock: func(mockRepo *orderMocks.MockRepository) {
 				mockRepo.EXPECT().
-					CreateWithdrawalOrder(TestUserID1.String(), testOrderNumber, decimal.NewFromInt(200)).
+					CreateWithdrawalOrder(gomock.Any(), TestUserID1.String(), testOrderNumber, decimal.NewFromInt(200)).
 					Return(repository.Order{}, orderDomain.ErrWithdrawInsufficientFunds).
 					Times(1)
 			},
 		},
+		{
+			name:   "withdrawal limit of the tier exceeded",
+			method: http.MethodPost,
+			path:   "/api/user/balance/withdraw",
+			userID: TestUserID1.String(),
+			request: WithdrawRequest{
+				Order: testOrderNumber,
+				Sum:   decimal.NewFromInt(80),
+			},
+			want: want{
+				statusCode: http.StatusForbidden,
+			},
+			mock: func(mockRepo *orderMocks.MockRepository) {
+				mockRepo.EXPECT().
+					CreateWithdrawalOrder(gomock.Any(), TestUserID1.String(), testOrderNumber, decimal.NewFromInt(80)).
+					Return(repository.Order{}, repository.ErrWithdrawLimitExceeded).
+					Times(1)
+			},
+		},
 		{
 			name:   "insufficient funds	- amount is zero",
 			method: http.MethodPost,
@@ -178,7 +197,7 @@ func TestWithdrawHandler(t *testing.T) {
 			mockOrderRepo := orderMocks.NewMockRepository(ctrl)
 			tt.mock(mockOrderRepo)
 
-			orderService := orderDomain.NewService(mockOrderRepo)
+			orderService := orderDomain.NewService(mockOrderRepo, orderDomain.Config{})
 			handlerFunc := NewWithdrawHandler(orderService)
 
 			reqJSON, _ := json.Marshal(tt.request)
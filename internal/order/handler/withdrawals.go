@@ -12,12 +12,13 @@ import (
 
 func NewWithdrawalsHandler(userService domain.Service) http.HandlerFunc {
 	return func(rw http.ResponseWriter, req *http.Request) {
+		log := logger.FromContext(req.Context())
 		rw.Header().Set("Content-Type", "application/json")
 
 		userID, _ := middleware.GetUserID(req)
-		withdrawals, err := userService.GetWithdrawals(userID)
+		withdrawals, err := userService.GetWithdrawals(req.Context(), userID)
 		if err != nil {
-			logger.Log.Error("failed to get withdrawals", zap.Error(err))
+			log.Error("failed to get withdrawals", zap.Error(err))
 			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 			return
 		}
@@ -29,16 +30,12 @@ func NewWithdrawalsHandler(userService domain.Service) http.HandlerFunc {
 
 		withdrawalResponses := make([]WithdrawalResponse, len(withdrawals))
 		for i, withdrawal := range withdrawals {
-			withdrawalResponses[i] = WithdrawalResponse{
-				Order:       withdrawal.OrderNumber,
-				Sum:         float32(withdrawal.Sum.InexactFloat64()),
-				ProcessedAt: withdrawal.ProcessedAt,
-			}
+			withdrawalResponses[i] = ToWithdrawalResponse(withdrawal)
 		}
 
 		rw.WriteHeader(http.StatusOK)
 		if err := json.NewEncoder(rw).Encode(withdrawalResponses); err != nil {
-			logger.Log.Error("failed to encode withdrawals", zap.Error(err))
+			log.Error("failed to encode withdrawals", zap.Error(err))
 			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 			return
 		}
@@ -30,16 +30,29 @@ func TestWithdrawalsHandler(t *testing.T) {
 	}
 
 	fixedTime := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
-	testWithdrawals := []repository.Order{
+	testWithdrawals := []repository.GetWithdrawalsByUserIDRow{
 		{
-			Number:      "2377225624",
-			Amount:      decimal.NewFromInt(500),
-			ProcessedAt: pgtype.Timestamptz{Time: fixedTime, Valid: true},
+			Order: repository.Order{
+				Number:      "2377225624",
+				Amount:      decimal.NewFromInt(500),
+				ProcessedAt: pgtype.Timestamptz{Time: fixedTime, Valid: true},
+			},
+		},
+		{
+			Order: repository.Order{
+				Number:      "1234567890",
+				Amount:      decimal.NewFromInt(250),
+				ProcessedAt: pgtype.Timestamptz{Time: fixedTime, Valid: true},
+			},
+			Refunded: decimal.NewFromInt(100),
 		},
 		{
-			Number:      "1234567890",
-			Amount:      decimal.NewFromInt(250),
-			ProcessedAt: pgtype.Timestamptz{Time: fixedTime, Valid: true},
+			Order: repository.Order{
+				Number:      "12345678903",
+				Amount:      decimal.NewFromInt(50),
+				ProcessedAt: pgtype.Timestamptz{Time: fixedTime, Valid: true},
+			},
+			Refunded: decimal.NewFromInt(50),
 		},
 	}
 
@@ -47,11 +60,21 @@ func TestWithdrawalsHandler(t *testing.T) {
 		{
 			Order:       "2377225624",
 			Sum:         500,
+			Status:      "PROCESSED",
 			ProcessedAt: fixedTime,
 		},
 		{
 			Order:       "1234567890",
 			Sum:         250,
+			Status:      "PARTIALLY_REVERSED",
+			Refunded:    100,
+			ProcessedAt: fixedTime,
+		},
+		{
+			Order:       "12345678903",
+			Sum:         50,
+			Status:      "REVERSED",
+			Refunded:    50,
 			ProcessedAt: fixedTime,
 		},
 	}
@@ -73,7 +96,7 @@ func TestWithdrawalsHandler(t *testing.T) {
 			userID: TestUserID1.String(),
 			mock: func(mockRepo *orderMocks.MockRepository) {
 				mockRepo.EXPECT().
-					GetWithdrawalsByUserID(TestUserID1.String()).
+					GetWithdrawalsByUserID(gomock.Any(), TestUserID1.String()).
 					Return(testWithdrawals, nil).
 					Times(1)
 			},
@@ -89,8 +112,8 @@ func TestWithdrawalsHandler(t *testing.T) {
 			userID: TestUserID1.String(),
 			mock: func(mockRepo *orderMocks.MockRepository) {
 				mockRepo.EXPECT().
-					GetWithdrawalsByUserID(TestUserID1.String()).
-					Return([]repository.Order{}, nil).
+					GetWithdrawalsByUserID(gomock.Any(), TestUserID1.String()).
+					Return([]repository.GetWithdrawalsByUserIDRow{}, nil).
 					Times(1)
 			},
 			want: want{
@@ -105,7 +128,7 @@ func TestWithdrawalsHandler(t *testing.T) {
 			userID: TestUserID1.String(),
 			mock: func(mockRepo *orderMocks.MockRepository) {
 				mockRepo.EXPECT().
-					GetWithdrawalsByUserID(TestUserID1.String()).
+					GetWithdrawalsByUserID(gomock.Any(), TestUserID1.String()).
 					Return(nil, assert.AnError).
 					Times(1)
 			},
@@ -123,7 +146,7 @@ func TestWithdrawalsHandler(t *testing.T) {
 			mockOrderRepo := orderMocks.NewMockRepository(ctrl)
 			tt.mock(mockOrderRepo)
 
-			orderService := orderDomain.NewService(mockOrderRepo)
+			orderService := orderDomain.NewService(mockOrderRepo, orderDomain.Config{})
 			handlerFunc := NewWithdrawalsHandler(orderService)
 
 			req := httptest.NewRequest(tt.method, tt.path, nil)
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// OrderBonuses are the points credited with a processed order on top of its accrual.
+type OrderBonuses struct {
+	Campaigns []CampaignBonus
+	// Tier is nil when the user's tier does not multiply accruals.
+	Tier *TierBonus
+}
+
+// CampaignBonus is the points a campaign adds on top of an order's accrual.
+type CampaignBonus struct {
+	CampaignID uuid.UUID
+	Amount     decimal.Decimal
+	// FirstOrderOnly bonuses are dropped if another order of the user was processed meanwhile.
+	FirstOrderOnly bool
+}
+
+// GetActiveCampaigns returns the campaigns running at the given time, in the order they were created.
+func (s *service) GetActiveCampaigns(ctx context.Context, userID string, at time.Time) ([]GetActiveCampaignsByUserIDRow, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.queries.GetActiveCampaignsByUserID(ctx, GetActiveCampaignsByUserIDParams{
+		UserID: id,
+		At:     pgtype.Timestamptz{Time: at, Valid: true},
+	})
+}
+
+// CountProcessedOrders returns how many top-up orders of the user are processed, not counting excludeOrderID.
+func (s *service) CountProcessedOrders(ctx context.Context, userID string, excludeOrderID uuid.UUID) (int64, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return 0, err
+	}
+	return s.queries.CountProcessedTopUpOrdersByUserID(ctx, CountProcessedTopUpOrdersByUserIDParams{
+		UserID:         id,
+		ExcludeOrderID: excludeOrderID,
+	})
+}
+
+// applyCampaignBonuses pays the bonuses of a processed order and returns their total.
+// Each bonus is capped by what is left of its campaign budget, so the amount paid can be
+// lower than requested. It must run after the user's balance row is locked; campaigns are
+// then locked in ascending id order, so concurrent orders cannot deadlock on them.
+func applyCampaignBonuses(ctx context.Context, q *Queries, order Order, bonuses []CampaignBonus) (decimal.Decimal, error) {
+	bonuses, err := dropLateFirstOrderBonuses(ctx, q, order, bonuses)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	slices.SortFunc(bonuses, func(a, b CampaignBonus) int {
+		return bytes.Compare(a.CampaignID[:], b.CampaignID[:])
+	})
+
+	total := decimal.Zero
+	for _, bonus := range bonuses {
+		campaign, err := q.LockCampaign(ctx, bonus.CampaignID)
+		if err != nil {
+			return decimal.Zero, err
+		}
+
+		amount := bonus.Amount
+		if campaign.Budget.Valid {
+			amount = decimal.Min(amount, campaign.Budget.Decimal.Sub(campaign.Spent))
+		}
+		if !amount.IsPositive() {
+			logger.FromContext(ctx).Debug("orderrepository: campaign budget is spent",
+				zap.String("campaignID", campaign.ID.String()), zap.String("orderNumber", order.Number))
+			continue
+		}
+
+		err = q.SpendCampaignBudget(ctx, SpendCampaignBudgetParams{
+			ID:    campaign.ID,
+			Spent: amount,
+		})
+		if err != nil {
+			return decimal.Zero, err
+		}
+
+		err = q.CreateOrderCampaignBonus(ctx, CreateOrderCampaignBonusParams{
+			OrderID:    order.ID,
+			CampaignID: campaign.ID,
+			Amount:     amount,
+		})
+		if err != nil {
+			return decimal.Zero, err
+		}
+
+		err = postLedgerTransaction(ctx, q, AccountCampaigns, UserAccount(order.UserID), amount, order.Number)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		total = total.Add(amount)
+	}
+	return total, nil
+}
+
+// dropLateFirstOrderBonuses checks the first order rule again under the user's balance lock,
+// so two orders processed at the same time cannot both get a first order bonus.
+// It returns a new slice, the caller's bonuses are left untouched.
+func dropLateFirstOrderBonuses(ctx context.Context, q *Queries, order Order, bonuses []CampaignBonus) ([]CampaignBonus, error) {
+	firstOrderOnly := false
+	for _, bonus := range bonuses {
+		firstOrderOnly = firstOrderOnly || bonus.FirstOrderOnly
+	}
+	if !firstOrderOnly {
+		return slices.Clone(bonuses), nil
+	}
+
+	processed, err := q.CountProcessedTopUpOrdersByUserID(ctx, CountProcessedTopUpOrdersByUserIDParams{
+		UserID:         order.UserID,
+		ExcludeOrderID: order.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if processed == 0 {
+		return bonuses, nil
+	}
+
+	kept := make([]CampaignBonus, 0, len(bonuses))
+	for _, bonus := range bonuses {
+		if bonus.FirstOrderOnly {
+			logger.FromContext(ctx).Debug("orderrepository: order is no longer the first one",
+				zap.String("campaignID", bonus.CampaignID.String()), zap.String("orderNumber", order.Number))
+			continue
+		}
+		kept = append(kept, bonus)
+	}
+	return kept, nil
+}
@@ -6,4 +6,20 @@ var (
 	ErrOrderAlreadyExists        = errors.New("order already exists")
 	ErrOrderAddedByAnotherUser   = errors.New("order uploaded by another user")
 	ErrWithdrawInsufficientFunds = errors.New("withdraw insufficient funds")
+	ErrOrderStatusConflict       = errors.New("order status was changed concurrently")
+	ErrHoldNotFound              = errors.New("hold not found")
+	ErrHoldNotActive             = errors.New("hold is already captured, voided or expired")
+	ErrHoldExpired               = errors.New("hold has expired")
+	ErrWithdrawalNotFound        = errors.New("withdrawal not found")
+	ErrRefundExceedsWithdrawal   = errors.New("refund exceeds the withdrawn sum")
+	ErrWithdrawLimitExceeded     = errors.New("withdrawal limit of the tier is exceeded")
+	ErrRecipientNotFound         = errors.New("transfer recipient not found")
+	ErrTransferToSelf            = errors.New("transfer to yourself")
+	ErrTransferLimitExceeded     = errors.New("daily transfer limit is exceeded")
+	ErrGiftCodeExists            = errors.New("gift code already exists")
+	ErrGiftCodeBatchNotFound     = errors.New("gift code batch not found")
+	ErrGiftCodeNotFound          = errors.New("gift code not found")
+	ErrGiftCodeExpired           = errors.New("gift code has expired")
+	ErrGiftCodeExhausted         = errors.New("gift code has no redemptions left")
+	ErrGiftCodeAlreadyRedeemed   = errors.New("gift code is already redeemed by the user")
 )
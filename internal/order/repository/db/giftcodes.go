@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+// CreateGiftCodeBatch stores the batch with its codes in one transaction.
+// It returns ErrGiftCodeExists when one of the codes is already taken, so the caller can
+// generate new codes and try again.
+func (s *service) CreateGiftCodeBatch(ctx context.Context, batch CreateGiftCodeBatchParams, codes []string) (GiftCodeBatch, error) {
+	tx, err := s.pgpool.Begin(ctx)
+	if err != nil {
+		return GiftCodeBatch{}, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	qtx := s.queries.WithTx(tx)
+	dbBatch, err := qtx.CreateGiftCodeBatch(ctx, batch)
+	if err != nil {
+		return GiftCodeBatch{}, err
+	}
+
+	for _, code := range codes {
+		err = qtx.CreateGiftCode(ctx, CreateGiftCodeParams{
+			BatchID: dbBatch.ID,
+			Code:    code,
+		})
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return GiftCodeBatch{}, ErrGiftCodeExists
+		}
+		if err != nil {
+			return GiftCodeBatch{}, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return GiftCodeBatch{}, err
+	}
+	return dbBatch, nil
+}
+
+func (s *service) GetGiftCodeBatch(ctx context.Context, batchID string) (GiftCodeBatch, []GiftCode, error) {
+	id, err := uuid.Parse(batchID)
+	if err != nil {
+		return GiftCodeBatch{}, nil, ErrGiftCodeBatchNotFound
+	}
+
+	batch, err := s.queries.GetGiftCodeBatch(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return GiftCodeBatch{}, nil, ErrGiftCodeBatchNotFound
+	}
+	if err != nil {
+		return GiftCodeBatch{}, nil, err
+	}
+
+	codes, err := s.queries.GetGiftCodesByBatchID(ctx, id)
+	if err != nil {
+		return GiftCodeBatch{}, nil, err
+	}
+	return batch, codes, nil
+}
+
+// RedeemGiftCode credits the points of the code to the user. The code row is locked first,
+// so concurrent redemptions cannot go over its redemption limit.
+func (s *service) RedeemGiftCode(ctx context.Context, userID, code string) (GiftCodeRedemption, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return GiftCodeRedemption{}, err
+	}
+
+	tx, err := s.pgpool.Begin(ctx)
+	if err != nil {
+		return GiftCodeRedemption{}, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	qtx := s.queries.WithTx(tx)
+	giftCode, err := qtx.LockGiftCodeByCode(ctx, code)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return GiftCodeRedemption{}, ErrGiftCodeNotFound
+	}
+	if err != nil {
+		return GiftCodeRedemption{}, err
+	}
+
+	if giftCode.ExpiresAt.Valid && !giftCode.ExpiresAt.Time.After(time.Now()) {
+		return GiftCodeRedemption{}, ErrGiftCodeExpired
+	}
+	if giftCode.Redemptions >= giftCode.MaxRedemptions {
+		logger.FromContext(ctx).Debug("orderrepository: gift code has no redemptions left",
+			zap.String("giftCodeID", giftCode.ID.String()), zap.Int32("maxRedemptions", giftCode.MaxRedemptions))
+		return GiftCodeRedemption{}, ErrGiftCodeExhausted
+	}
+
+	redemption, err := qtx.CreateGiftCodeRedemption(ctx, CreateGiftCodeRedemptionParams{
+		GiftCodeID: giftCode.ID,
+		UserID:     id,
+		Amount:     giftCode.Amount,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return GiftCodeRedemption{}, ErrGiftCodeAlreadyRedeemed
+	}
+	if err != nil {
+		return GiftCodeRedemption{}, err
+	}
+
+	err = qtx.IncrementGiftCodeRedemptions(ctx, giftCode.ID)
+	if err != nil {
+		return GiftCodeRedemption{}, err
+	}
+
+	reference := "giftcode:" + redemption.ID.String()
+	err = postLedgerTransaction(ctx, qtx, AccountGiftCodes, UserAccount(id), redemption.Amount, reference)
+	if err != nil {
+		return GiftCodeRedemption{}, err
+	}
+
+	err = qtx.CreditUserBalance(ctx, CreditUserBalanceParams{
+		UserID: id,
+		Amount: redemption.Amount,
+	})
+	if err != nil {
+		return GiftCodeRedemption{}, err
+	}
+
+	err = s.createLot(ctx, qtx, id, reference, redemption.Amount)
+	if err != nil {
+		return GiftCodeRedemption{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return GiftCodeRedemption{}, err
+	}
+	return redemption, nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRepository connects to the migrated database in TEST_DATABASE_URI and skips the test
+// when it is not set.
+func newTestRepository(t *testing.T) (Repository, *pgxpool.Pool) {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URI")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URI is not set")
+	}
+
+	pool, err := pgxpool.New(context.Background(), dsn)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	return NewRepository(pool, Config{}), pool
+}
+
+// createTestUser creates a user with balance accrued points, posted to the ledger and backed
+// by a lot like real accruals, and removes the user when the test ends.
+func createTestUser(t *testing.T, pool *pgxpool.Pool, balance int64) (uuid.UUID, string) {
+	t.Helper()
+
+	ctx := context.Background()
+	userID := uuid.New()
+	login := "repository-test-" + userID.String()
+	t.Cleanup(func() {
+		deleteTestUser(t, pool, userID)
+	})
+
+	_, err := pool.Exec(ctx, "INSERT INTO users (id, username, password_hash, referral_code) VALUES ($1, $2, 'hash', $3)",
+		userID, login, userID.String())
+	require.NoError(t, err)
+	_, err = pool.Exec(ctx, "INSERT INTO user_balances (user_id, current) VALUES ($1, $2)",
+		userID, balance)
+	require.NoError(t, err)
+	if balance == 0 {
+		return userID, login
+	}
+
+	_, err = pool.Exec(ctx, "INSERT INTO point_lots (user_id, reference, amount, remaining) VALUES ($1, 'seed', $2, $2)",
+		userID, balance)
+	require.NoError(t, err)
+	err = postLedgerTransaction(ctx, New(pool), AccountAccrual, UserAccount(userID), decimal.NewFromInt(balance), "seed")
+	require.NoError(t, err)
+	return userID, login
+}
+
+// deleteTestUser removes the user with everything that cascades from it and the user's
+// ledger transactions, which are only linked to the user by the account names.
+func deleteTestUser(t *testing.T, pool *pgxpool.Pool, userID uuid.UUID) {
+	t.Helper()
+
+	ctx := context.Background()
+	_, err := pool.Exec(ctx, `DELETE FROM ledger_entries WHERE transaction_id IN
+		(SELECT transaction_id FROM ledger_entries WHERE account IN ($1, $2))`, UserAccount(userID), HoldAccount(userID))
+	assert.NoError(t, err)
+	_, err = pool.Exec(ctx, "DELETE FROM users WHERE id = $1", userID)
+	assert.NoError(t, err)
+}
+
+// newTestNumber returns an order number no other test uses.
+func newTestNumber() string {
+	return "test-" + uuid.NewString()
+}
+
+// assertBalance checks the user's balance and that the ledger and the spendable lots agree with it.
+func assertBalance(t *testing.T, pool *pgxpool.Pool, userID uuid.UUID, current, held, withdrawn int64) {
+	t.Helper()
+
+	var (
+		balance       UserBalance
+		ledgerCurrent decimal.Decimal
+		ledgerHeld    decimal.Decimal
+		ledgerDrawn   decimal.Decimal
+		lots          decimal.Decimal
+	)
+	err := pool.QueryRow(context.Background(), `SELECT current, held, withdrawn, ledger_current, ledger_held, ledger_withdrawn
+		FROM user_balance_audit WHERE user_id = $1`, userID).
+		Scan(&balance.Current, &balance.Held, &balance.Withdrawn, &ledgerCurrent, &ledgerHeld, &ledgerDrawn)
+	require.NoError(t, err)
+	err = pool.QueryRow(context.Background(), "SELECT COALESCE(SUM(remaining), 0) FROM point_lots WHERE user_id = $1", userID).
+		Scan(&lots)
+	require.NoError(t, err)
+
+	assert.True(t, balance.Current.Equal(decimal.NewFromInt(current)), "current is %s, want %d", balance.Current, current)
+	assert.True(t, balance.Held.Equal(decimal.NewFromInt(held)), "held is %s, want %d", balance.Held, held)
+	assert.True(t, balance.Withdrawn.Equal(decimal.NewFromInt(withdrawn)), "withdrawn is %s, want %d", balance.Withdrawn, withdrawn)
+	assert.True(t, ledgerCurrent.Equal(balance.Current), "ledger current is %s, balance is %s", ledgerCurrent, balance.Current)
+	assert.True(t, ledgerHeld.Equal(balance.Held), "ledger held is %s, balance is %s", ledgerHeld, balance.Held)
+	assert.True(t, ledgerDrawn.Equal(balance.Withdrawn), "ledger withdrawn is %s, balance is %s", ledgerDrawn, balance.Withdrawn)
+	assert.True(t, lots.Equal(balance.Current), "lots hold %s, balance is %s", lots, balance.Current)
+}
@@ -0,0 +1,312 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// CreateHold reserves amount of the user's balance until expiresAt. Held points are
+// moved out of the current balance, so they cannot be withdrawn twice.
+func (s *service) CreateHold(ctx context.Context, userID, orderNumber string, amount decimal.Decimal, expiresAt time.Time) (BalanceHold, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return BalanceHold{}, err
+	}
+
+	tx, err := s.pgpool.Begin(ctx)
+	if err != nil {
+		return BalanceHold{}, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	qtx := s.queries.WithTx(tx)
+	_, err = qtx.GetOrderByNumber(ctx, orderNumber)
+	if err == nil {
+		return BalanceHold{}, ErrOrderAlreadyExists
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return BalanceHold{}, err
+	}
+
+	balance, err := lockSpendableBalance(ctx, qtx, id)
+	if err != nil {
+		return BalanceHold{}, err
+	}
+
+	if balance.Current.LessThan(amount) {
+		logger.FromContext(ctx).Debug("orderrepository: balance is lower than hold",
+			zap.String("balance", balance.Current.String()), zap.String("sum", amount.String()))
+		return BalanceHold{}, ErrWithdrawInsufficientFunds
+	}
+
+	err = checkWithdrawalLimit(ctx, qtx, id, amount)
+	if err != nil {
+		return BalanceHold{}, err
+	}
+
+	hold, err := qtx.CreateBalanceHold(ctx, CreateBalanceHoldParams{
+		UserID:      id,
+		OrderNumber: orderNumber,
+		Amount:      amount,
+		ExpiresAt:   pgtype.Timestamptz{Time: expiresAt, Valid: true},
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return BalanceHold{}, ErrOrderAlreadyExists
+		}
+		return BalanceHold{}, err
+	}
+
+	err = postLedgerTransaction(ctx, qtx, UserAccount(id), HoldAccount(id), amount, orderNumber)
+	if err != nil {
+		return BalanceHold{}, err
+	}
+
+	err = qtx.HoldUserBalance(ctx, HoldUserBalanceParams{
+		Amount: amount,
+		UserID: id,
+	})
+	if err != nil {
+		return BalanceHold{}, err
+	}
+
+	_, err = consumeLots(ctx, qtx, id, orderNumber, amount)
+	if err != nil {
+		return BalanceHold{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return BalanceHold{}, err
+	}
+
+	return hold, nil
+}
+
+// CaptureHold turns an active hold into a processed withdrawal. A hold that has run out of
+// time is expired instead and ErrHoldExpired is returned.
+func (s *service) CaptureHold(ctx context.Context, userID, holdID string) (BalanceHold, Order, error) {
+	tx, err := s.pgpool.Begin(ctx)
+	if err != nil {
+		return BalanceHold{}, Order{}, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	qtx := s.queries.WithTx(tx)
+	hold, err := lockActiveHold(ctx, qtx, userID, holdID)
+	if errors.Is(err, ErrHoldExpired) {
+		hold, err = s.releaseHold(ctx, qtx, hold, HoldstatusEXPIRED)
+		if err != nil {
+			return BalanceHold{}, Order{}, err
+		}
+		if err = tx.Commit(ctx); err != nil {
+			return BalanceHold{}, Order{}, err
+		}
+		return hold, Order{}, ErrHoldExpired
+	}
+	if err != nil {
+		return BalanceHold{}, Order{}, err
+	}
+
+	withdrawal, err := qtx.Withdrawal(ctx, WithdrawalParams{
+		UserID: hold.UserID,
+		Number: hold.OrderNumber,
+		Amount: hold.Amount,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return BalanceHold{}, Order{}, ErrOrderAlreadyExists
+		}
+		return BalanceHold{}, Order{}, err
+	}
+
+	err = postLedgerTransaction(ctx, qtx, HoldAccount(hold.UserID), AccountWithdrawals, hold.Amount, hold.OrderNumber)
+	if err != nil {
+		return BalanceHold{}, Order{}, err
+	}
+
+	err = qtx.CaptureHeldBalance(ctx, CaptureHeldBalanceParams{
+		Amount: hold.Amount,
+		UserID: hold.UserID,
+	})
+	if err != nil {
+		return BalanceHold{}, Order{}, err
+	}
+
+	hold, err = qtx.UpdateBalanceHoldStatus(ctx, UpdateBalanceHoldStatusParams{
+		ID:     hold.ID,
+		Status: HoldstatusCAPTURED,
+	})
+	if err != nil {
+		return BalanceHold{}, Order{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return BalanceHold{}, Order{}, err
+	}
+
+	return hold, withdrawal, nil
+}
+
+// VoidHold returns the held points to the user's current balance.
+// Voiding a hold that has already run out of time marks it expired.
+func (s *service) VoidHold(ctx context.Context, userID, holdID string) (BalanceHold, error) {
+	tx, err := s.pgpool.Begin(ctx)
+	if err != nil {
+		return BalanceHold{}, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	qtx := s.queries.WithTx(tx)
+	status := HoldstatusVOIDED
+	hold, err := lockActiveHold(ctx, qtx, userID, holdID)
+	if errors.Is(err, ErrHoldExpired) {
+		status = HoldstatusEXPIRED
+		err = nil
+	}
+	if err != nil {
+		return BalanceHold{}, err
+	}
+
+	hold, err = s.releaseHold(ctx, qtx, hold, status)
+	if err != nil {
+		return BalanceHold{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return BalanceHold{}, err
+	}
+
+	return hold, nil
+}
+
+// ExpireHolds releases up to limit holds that have run out of time and returns how many were released.
+// Every hold is released in its own transaction, so the expirer locks one hold and one balance
+// at a time, in the same order as capture and void. Holds locked by a concurrent capture or void
+// are skipped.
+func (s *service) ExpireHolds(ctx context.Context, limit int32) (int, error) {
+	released := 0
+	for released < int(limit) {
+		ok, err := s.expireHold(ctx)
+		if err != nil {
+			return released, err
+		}
+		if !ok {
+			break
+		}
+		released++
+	}
+	return released, nil
+}
+
+// expireHold releases the hold that ran out of time first. It returns false when there is none.
+func (s *service) expireHold(ctx context.Context) (bool, error) {
+	tx, err := s.pgpool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	qtx := s.queries.WithTx(tx)
+	hold, err := qtx.LockExpiredBalanceHold(ctx)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	_, err = s.releaseHold(ctx, qtx, hold, HoldstatusEXPIRED)
+	if err != nil {
+		return false, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// lockActiveHold locks the user's hold. It returns the hold together with ErrHoldExpired
+// when the hold is still active but its time is up, so the caller can release it.
+func lockActiveHold(ctx context.Context, q *Queries, userID, holdID string) (BalanceHold, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return BalanceHold{}, err
+	}
+	hid, err := uuid.Parse(holdID)
+	if err != nil {
+		return BalanceHold{}, ErrHoldNotFound
+	}
+
+	hold, err := q.LockBalanceHold(ctx, LockBalanceHoldParams{
+		ID:     hid,
+		UserID: uid,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return BalanceHold{}, ErrHoldNotFound
+	}
+	if err != nil {
+		return BalanceHold{}, err
+	}
+
+	if hold.Status != HoldstatusACTIVE {
+		return hold, ErrHoldNotActive
+	}
+	if !hold.ExpiresAt.Time.After(time.Now()) {
+		return hold, ErrHoldExpired
+	}
+	return hold, nil
+}
+
+// releaseHold locks the balance row before the lots, like every other balance change.
+func (s *service) releaseHold(ctx context.Context, q *Queries, hold BalanceHold, status Holdstatus) (BalanceHold, error) {
+	_, err := q.LockUserBalance(ctx, hold.UserID)
+	if err != nil {
+		return BalanceHold{}, err
+	}
+
+	err = s.restoreLots(ctx, q, hold.UserID, hold.OrderNumber, hold.Amount)
+	if err != nil {
+		return BalanceHold{}, err
+	}
+
+	err = postLedgerTransaction(ctx, q, HoldAccount(hold.UserID), UserAccount(hold.UserID), hold.Amount, hold.OrderNumber)
+	if err != nil {
+		return BalanceHold{}, err
+	}
+
+	err = q.ReleaseHeldBalance(ctx, ReleaseHeldBalanceParams{
+		Amount: hold.Amount,
+		UserID: hold.UserID,
+	})
+	if err != nil {
+		return BalanceHold{}, err
+	}
+
+	logger.FromContext(ctx).Debug("orderrepository: hold released",
+		zap.String("holdID", hold.ID.String()), zap.String("status", string(status)))
+	return q.UpdateBalanceHoldStatus(ctx, UpdateBalanceHoldStatusParams{
+		ID:     hold.ID,
+		Status: status,
+	})
+}
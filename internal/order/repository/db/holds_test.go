@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHolds needs a migrated database in TEST_DATABASE_URI.
+func TestHolds(t *testing.T) {
+	repo, pool := newTestRepository(t)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	t.Run("capture moves held points to withdrawn", func(t *testing.T) {
+		userID, _ := createTestUser(t, pool, 100)
+
+		hold, err := repo.CreateHold(ctx, userID.String(), newTestNumber(), decimal.NewFromInt(30), expiresAt)
+		require.NoError(t, err)
+		assertBalance(t, pool, userID, 70, 30, 0)
+
+		captured, withdrawal, err := repo.CaptureHold(ctx, userID.String(), hold.ID.String())
+		require.NoError(t, err)
+		assert.Equal(t, HoldstatusCAPTURED, captured.Status)
+		assert.Equal(t, hold.OrderNumber, withdrawal.Number)
+		assertBalance(t, pool, userID, 70, 0, 30)
+
+		_, _, err = repo.CaptureHold(ctx, userID.String(), hold.ID.String())
+		assert.ErrorIs(t, err, ErrHoldNotActive)
+		_, err = repo.VoidHold(ctx, userID.String(), hold.ID.String())
+		assert.ErrorIs(t, err, ErrHoldNotActive)
+		assertBalance(t, pool, userID, 70, 0, 30)
+	})
+
+	t.Run("void returns held points", func(t *testing.T) {
+		userID, _ := createTestUser(t, pool, 100)
+
+		hold, err := repo.CreateHold(ctx, userID.String(), newTestNumber(), decimal.NewFromInt(40), expiresAt)
+		require.NoError(t, err)
+
+		voided, err := repo.VoidHold(ctx, userID.String(), hold.ID.String())
+		require.NoError(t, err)
+		assert.Equal(t, HoldstatusVOIDED, voided.Status)
+		assertBalance(t, pool, userID, 100, 0, 0)
+
+		_, _, err = repo.CaptureHold(ctx, userID.String(), hold.ID.String())
+		assert.ErrorIs(t, err, ErrHoldNotActive)
+		assertBalance(t, pool, userID, 100, 0, 0)
+	})
+
+	t.Run("held points cannot be held again", func(t *testing.T) {
+		userID, _ := createTestUser(t, pool, 100)
+
+		_, err := repo.CreateHold(ctx, userID.String(), newTestNumber(), decimal.NewFromInt(60), expiresAt)
+		require.NoError(t, err)
+		_, err = repo.CreateHold(ctx, userID.String(), newTestNumber(), decimal.NewFromInt(60), expiresAt)
+		assert.ErrorIs(t, err, ErrWithdrawInsufficientFunds)
+		assertBalance(t, pool, userID, 40, 60, 0)
+	})
+
+	t.Run("capture of an expired hold releases it", func(t *testing.T) {
+		userID, _ := createTestUser(t, pool, 100)
+
+		hold, err := repo.CreateHold(ctx, userID.String(), newTestNumber(), decimal.NewFromInt(25), time.Now().Add(-time.Second))
+		require.NoError(t, err)
+
+		expired, _, err := repo.CaptureHold(ctx, userID.String(), hold.ID.String())
+		assert.ErrorIs(t, err, ErrHoldExpired)
+		assert.Equal(t, HoldstatusEXPIRED, expired.Status)
+		assertBalance(t, pool, userID, 100, 0, 0)
+	})
+
+	t.Run("hold of another user is not found", func(t *testing.T) {
+		userID, _ := createTestUser(t, pool, 100)
+		otherID, _ := createTestUser(t, pool, 0)
+
+		hold, err := repo.CreateHold(ctx, userID.String(), newTestNumber(), decimal.NewFromInt(10), expiresAt)
+		require.NoError(t, err)
+
+		_, _, err = repo.CaptureHold(ctx, otherID.String(), hold.ID.String())
+		assert.ErrorIs(t, err, ErrHoldNotFound)
+		assertBalance(t, pool, userID, 90, 10, 0)
+	})
+}
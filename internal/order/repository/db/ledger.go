@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// AccountAccrual is the counterparty of every accrual credited to a user.
+	AccountAccrual = "system:accrual"
+	// AccountWithdrawals is the counterparty of every withdrawal made by a user.
+	AccountWithdrawals = "system:withdrawals"
+	// AccountCampaigns is the counterparty of the bonus points paid by campaigns.
+	AccountCampaigns = "system:campaigns"
+	// AccountTiers is the counterparty of the points added by tier multipliers.
+	AccountTiers = "system:tiers"
+	// AccountGiftCodes is the counterparty of the points credited by gift code redemptions.
+	AccountGiftCodes = "system:giftcodes"
+	// AccountReferrals is the counterparty of the rewards paid for referrals.
+	AccountReferrals = "system:referrals"
+	// AccountExpired collects the points of lots that expired unspent.
+	AccountExpired = "system:expired"
+)
+
+func UserAccount(userID uuid.UUID) string {
+	return "user:" + userID.String()
+}
+
+// HoldAccount keeps the points a user has reserved but not spent yet.
+func HoldAccount(userID uuid.UUID) string {
+	return "hold:" + userID.String()
+}
+
+// postLedgerTransaction moves amount from one account to another as a pair of entries.
+// It must run in the same database transaction as the balance update it explains.
+func postLedgerTransaction(ctx context.Context, q *Queries, from, to string, amount decimal.Decimal, reference string) error {
+	transactionID := uuid.New()
+
+	err := q.CreateLedgerEntry(ctx, CreateLedgerEntryParams{
+		TransactionID: transactionID,
+		Account:       from,
+		Direction:     LedgerdirectionDEBIT,
+		Amount:        amount,
+		Reference:     reference,
+	})
+	if err != nil {
+		return err
+	}
+
+	return q.CreateLedgerEntry(ctx, CreateLedgerEntryParams{
+		TransactionID: transactionID,
+		Account:       to,
+		Direction:     LedgerdirectionCREDIT,
+		Amount:        amount,
+		Reference:     reference,
+	})
+}
@@ -0,0 +1,268 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// Lot bookkeeping must run in the transaction that changes the user's current balance,
+// after the balance row is locked. Taking locks in the order balance, then lots keeps
+// withdrawals, refunds and the expiry job from deadlocking each other.
+
+// ExpirePoints expires the overdue lots of up to limit users.
+// It returns the number of expired lots and the points taken from users.
+func (s *service) ExpirePoints(ctx context.Context, limit int32) (int, decimal.Decimal, error) {
+	userIDs, err := s.queries.GetUsersWithExpiredPointLots(ctx, limit)
+	if err != nil {
+		return 0, decimal.Zero, err
+	}
+
+	var lots int
+	total := decimal.Zero
+	for _, userID := range userIDs {
+		expiredLots, expired, err := s.expireUserPoints(ctx, userID)
+		if err != nil {
+			return lots, total, err
+		}
+		lots += expiredLots
+		total = total.Add(expired)
+	}
+	return lots, total, nil
+}
+
+func (s *service) expireUserPoints(ctx context.Context, userID uuid.UUID) (int, decimal.Decimal, error) {
+	tx, err := s.pgpool.Begin(ctx)
+	if err != nil {
+		return 0, decimal.Zero, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	qtx := s.queries.WithTx(tx)
+	_, err = qtx.LockUserBalance(ctx, userID)
+	if err != nil {
+		return 0, decimal.Zero, err
+	}
+
+	lots, expired, err := expireLots(ctx, qtx, userID)
+	if err != nil {
+		return 0, decimal.Zero, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, decimal.Zero, err
+	}
+	return lots, expired, nil
+}
+
+// GetPointsExpiry returns the points expiring within the configured window and the next expiry date.
+func (s *service) GetPointsExpiry(ctx context.Context, userID string) (GetPointsExpiryByUserIDRow, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return GetPointsExpiryByUserIDRow{}, err
+	}
+
+	return s.queries.GetPointsExpiryByUserID(ctx, GetPointsExpiryByUserIDParams{
+		Until:  pgtype.Timestamptz{Time: time.Now().Add(s.cfg.ExpiringSoonWindow), Valid: true},
+		UserID: id,
+	})
+}
+
+func (s *service) createLot(ctx context.Context, q *Queries, userID uuid.UUID, reference string, amount decimal.Decimal) error {
+	var expiresAt pgtype.Timestamptz
+	if s.cfg.PointsTTL > 0 {
+		expiresAt = pgtype.Timestamptz{Time: time.Now().Add(s.cfg.PointsTTL), Valid: true}
+	}
+	return createLotExpiringAt(ctx, q, userID, reference, amount, expiresAt)
+}
+
+func createLotExpiringAt(ctx context.Context, q *Queries, userID uuid.UUID, reference string, amount decimal.Decimal, expiresAt pgtype.Timestamptz) error {
+	return q.CreatePointLot(ctx, CreatePointLotParams{
+		UserID:    userID,
+		Reference: reference,
+		Amount:    amount,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// lotTake is the part of a lot spent by one consumeLots call.
+type lotTake struct {
+	Amount    decimal.Decimal
+	ExpiresAt pgtype.Timestamptz
+}
+
+// consumeLots spends amount from the lots that expire first and returns what it took from each lot.
+func consumeLots(ctx context.Context, q *Queries, userID uuid.UUID, reference string, amount decimal.Decimal) ([]lotTake, error) {
+	lots, err := q.LockSpendablePointLots(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var takes []lotTake
+	left := amount
+	for _, lot := range lots {
+		if !left.IsPositive() {
+			break
+		}
+
+		take := decimal.Min(lot.Remaining, left)
+		err = q.UpdatePointLotRemaining(ctx, UpdatePointLotRemainingParams{
+			ID:        lot.ID,
+			Remaining: lot.Remaining.Sub(take),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		err = q.CreatePointLotAllocation(ctx, CreatePointLotAllocationParams{
+			LotID:     lot.ID,
+			Reference: reference,
+			Amount:    take,
+		})
+		if err != nil {
+			return nil, err
+		}
+		takes = append(takes, lotTake{Amount: take, ExpiresAt: lot.ExpiresAt})
+		left = left.Sub(take)
+	}
+
+	if left.IsPositive() {
+		// The balance check already passed, so the lots are out of sync with the balance.
+		// Spending is not blocked, the difference shows up in the lot totals.
+		logger.FromContext(ctx).Warn("orderrepository: point lots do not cover the balance",
+			zap.String("userID", userID.String()), zap.String("reference", reference), zap.String("missing", left.String()))
+	}
+	return takes, nil
+}
+
+// restoreLots returns amount spent under reference to the lots it was taken from, the
+// lots that expire last first. Points that were not taken from any lot get a new one.
+func (s *service) restoreLots(ctx context.Context, q *Queries, userID uuid.UUID, reference string, amount decimal.Decimal) error {
+	allocations, err := q.LockPointLotAllocationsByReference(ctx, LockPointLotAllocationsByReferenceParams{
+		Reference: reference,
+		UserID:    userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	left := amount
+	for _, allocation := range allocations {
+		if !left.IsPositive() {
+			break
+		}
+
+		give := decimal.Min(allocation.Amount, left)
+		err = q.UpdatePointLotAllocationAmount(ctx, UpdatePointLotAllocationAmountParams{
+			ID:     allocation.ID,
+			Amount: allocation.Amount.Sub(give),
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.RestorePointLot(ctx, RestorePointLotParams{
+			ID:        allocation.LotID,
+			Remaining: give,
+		})
+		if err != nil {
+			return err
+		}
+		left = left.Sub(give)
+	}
+
+	if left.IsPositive() {
+		return s.createLot(ctx, q, userID, reference, left)
+	}
+	return nil
+}
+
+// expireLots takes the remaining points of the user's overdue lots out of the current balance.
+func expireLots(ctx context.Context, q *Queries, userID uuid.UUID) (int, decimal.Decimal, error) {
+	lots, err := q.LockExpiredPointLotsByUser(ctx, userID)
+	if err != nil {
+		return 0, decimal.Zero, err
+	}
+
+	total := decimal.Zero
+	for _, lot := range lots {
+		err = postLedgerTransaction(ctx, q, UserAccount(userID), AccountExpired, lot.Remaining, "expiry:"+lot.ID.String())
+		if err != nil {
+			return 0, decimal.Zero, err
+		}
+
+		err = q.UpdatePointLotRemaining(ctx, UpdatePointLotRemainingParams{
+			ID:        lot.ID,
+			Remaining: decimal.Zero,
+		})
+		if err != nil {
+			return 0, decimal.Zero, err
+		}
+		total = total.Add(lot.Remaining)
+	}
+
+	if total.IsPositive() {
+		err = q.ExpireUserBalance(ctx, ExpireUserBalanceParams{
+			Amount: total,
+			UserID: userID,
+		})
+		if err != nil {
+			return 0, decimal.Zero, err
+		}
+		logger.FromContext(ctx).Debug("orderrepository: points expired",
+			zap.String("userID", userID.String()), zap.Int("lots", len(lots)), zap.String("sum", total.String()))
+	}
+	return len(lots), total, nil
+}
+
+// lockSpendableBalance locks the user's balance row and expires overdue lots first,
+// so the returned current balance only contains points that can still be spent.
+func lockSpendableBalance(ctx context.Context, q *Queries, userID uuid.UUID) (UserBalance, error) {
+	err := q.EnsureUserBalance(ctx, userID)
+	if err != nil {
+		return UserBalance{}, err
+	}
+
+	balance, err := q.LockUserBalance(ctx, userID)
+	if err != nil {
+		return UserBalance{}, err
+	}
+
+	_, expired, err := expireLots(ctx, q, userID)
+	if err != nil {
+		return UserBalance{}, err
+	}
+	balance.Current = balance.Current.Sub(expired)
+	return balance, nil
+}
+
+// lockUserBalances locks the balance rows of the users, lower user id first and every row once.
+// Whoever locks more than one balance row in a transaction must go through it, so two
+// transactions never wait for each other's rows.
+func lockUserBalances(ctx context.Context, q *Queries, userIDs ...uuid.UUID) error {
+	userIDs = slices.Clone(userIDs)
+	slices.SortFunc(userIDs, func(a, b uuid.UUID) int {
+		return bytes.Compare(a[:], b[:])
+	})
+
+	for _, userID := range slices.Compact(userIDs) {
+		err := q.EnsureUserBalance(ctx, userID)
+		if err != nil {
+			return err
+		}
+		_, err = q.LockUserBalance(ctx, userID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
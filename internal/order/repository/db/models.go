@@ -13,6 +13,92 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+type Holdstatus string
+
+const (
+	HoldstatusACTIVE   Holdstatus = "ACTIVE"
+	HoldstatusCAPTURED Holdstatus = "CAPTURED"
+	HoldstatusVOIDED   Holdstatus = "VOIDED"
+	HoldstatusEXPIRED  Holdstatus = "EXPIRED"
+)
+
+func (e *Holdstatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = Holdstatus(s)
+	case string:
+		*e = Holdstatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for Holdstatus: %T", src)
+	}
+	return nil
+}
+
+type NullHoldstatus struct {
+	Holdstatus Holdstatus
+	Valid      bool // Valid is true if Holdstatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullHoldstatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.Holdstatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.Holdstatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullHoldstatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.Holdstatus), nil
+}
+
+type Ledgerdirection string
+
+const (
+	LedgerdirectionDEBIT  Ledgerdirection = "DEBIT"
+	LedgerdirectionCREDIT Ledgerdirection = "CREDIT"
+)
+
+func (e *Ledgerdirection) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = Ledgerdirection(s)
+	case string:
+		*e = Ledgerdirection(s)
+	default:
+		return fmt.Errorf("unsupported scan type for Ledgerdirection: %T", src)
+	}
+	return nil
+}
+
+type NullLedgerdirection struct {
+	Ledgerdirection Ledgerdirection
+	Valid           bool // Valid is true if Ledgerdirection is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullLedgerdirection) Scan(value interface{}) error {
+	if value == nil {
+		ns.Ledgerdirection, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.Ledgerdirection.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullLedgerdirection) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.Ledgerdirection), nil
+}
+
 type Orderstatus string
 
 const (
@@ -99,6 +185,123 @@ func (ns NullOrdertype) Value() (driver.Value, error) {
 	return string(ns.Ordertype), nil
 }
 
+type Referralstatus string
+
+const (
+	ReferralstatusPENDING  Referralstatus = "PENDING"
+	ReferralstatusREWARDED Referralstatus = "REWARDED"
+	ReferralstatusREJECTED Referralstatus = "REJECTED"
+)
+
+func (e *Referralstatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = Referralstatus(s)
+	case string:
+		*e = Referralstatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for Referralstatus: %T", src)
+	}
+	return nil
+}
+
+type NullReferralstatus struct {
+	Referralstatus Referralstatus
+	Valid          bool // Valid is true if Referralstatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullReferralstatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.Referralstatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.Referralstatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullReferralstatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.Referralstatus), nil
+}
+
+type AccrualJob struct {
+	ID          uuid.UUID
+	OrderID     uuid.UUID
+	OrderNumber string
+	Attempts    int32
+	NextRunAt   pgtype.Timestamptz
+	LastError   pgtype.Text
+	LockedBy    pgtype.Text
+	LockedUntil pgtype.Timestamptz
+	CreatedAt   pgtype.Timestamptz
+	UpdatedAt   pgtype.Timestamptz
+}
+
+type BalanceHold struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	OrderNumber string
+	Amount      decimal.Decimal
+	Status      Holdstatus
+	ExpiresAt   pgtype.Timestamptz
+	CreatedAt   pgtype.Timestamptz
+	UpdatedAt   pgtype.Timestamptz
+}
+
+type Campaign struct {
+	ID             uuid.UUID
+	Name           string
+	StartsAt       pgtype.Timestamptz
+	EndsAt         pgtype.Timestamptz
+	DaysOfWeek     []int16
+	FirstOrderOnly bool
+	Cohort         pgtype.Text
+	Multiplier     decimal.Decimal
+	Bonus          decimal.Decimal
+	Budget         decimal.NullDecimal
+	Spent          decimal.Decimal
+	CreatedAt      pgtype.Timestamptz
+}
+
+type GiftCode struct {
+	ID          uuid.UUID
+	BatchID     uuid.UUID
+	Code        string
+	Redemptions int32
+	CreatedAt   pgtype.Timestamptz
+}
+
+type GiftCodeBatch struct {
+	ID             uuid.UUID
+	Name           string
+	Amount         decimal.Decimal
+	MaxRedemptions int32
+	ExpiresAt      pgtype.Timestamptz
+	CreatedAt      pgtype.Timestamptz
+}
+
+type GiftCodeRedemption struct {
+	ID         uuid.UUID
+	GiftCodeID uuid.UUID
+	UserID     uuid.UUID
+	Amount     decimal.Decimal
+	CreatedAt  pgtype.Timestamptz
+}
+
+type LedgerEntry struct {
+	ID            uuid.UUID
+	TransactionID uuid.UUID
+	Account       string
+	Direction     Ledgerdirection
+	Amount        decimal.Decimal
+	Reference     string
+	CreatedAt     pgtype.Timestamptz
+}
+
 type Order struct {
 	ID          uuid.UUID
 	UserID      uuid.UUID
@@ -109,3 +312,110 @@ type Order struct {
 	ProcessedAt pgtype.Timestamptz
 	CreatedAt   pgtype.Timestamptz
 }
+
+type OrderCampaignBonus struct {
+	ID         uuid.UUID
+	OrderID    uuid.UUID
+	CampaignID uuid.UUID
+	Amount     decimal.Decimal
+	CreatedAt  pgtype.Timestamptz
+}
+
+type OrderStatusTransition struct {
+	ID         uuid.UUID
+	OrderID    uuid.UUID
+	FromStatus NullOrderstatus
+	ToStatus   Orderstatus
+	CreatedAt  pgtype.Timestamptz
+}
+
+type OrderTierBonus struct {
+	OrderID   uuid.UUID
+	Tier      string
+	Amount    decimal.Decimal
+	CreatedAt pgtype.Timestamptz
+}
+
+type PointLot struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Reference string
+	Amount    decimal.Decimal
+	Remaining decimal.Decimal
+	ExpiresAt pgtype.Timestamptz
+	CreatedAt pgtype.Timestamptz
+}
+
+type PointLotAllocation struct {
+	ID        uuid.UUID
+	LotID     uuid.UUID
+	Reference string
+	Amount    decimal.Decimal
+	CreatedAt pgtype.Timestamptz
+}
+
+type Referral struct {
+	ReferredID     uuid.UUID
+	ReferrerID     uuid.UUID
+	Status         Referralstatus
+	Reason         pgtype.Text
+	OrderID        pgtype.UUID
+	ReferrerReward decimal.Decimal
+	ReferredReward decimal.Decimal
+	CreatedAt      pgtype.Timestamptz
+	SettledAt      pgtype.Timestamptz
+}
+
+type Transfer struct {
+	ID          uuid.UUID
+	SenderID    uuid.UUID
+	RecipientID uuid.UUID
+	Amount      decimal.Decimal
+	CreatedAt   pgtype.Timestamptz
+}
+
+type User struct {
+	ID           uuid.UUID
+	Username     string
+	PasswordHash string
+	CreatedAt    pgtype.Timestamp
+	ReferralCode string
+}
+
+type UserBalance struct {
+	UserID    uuid.UUID
+	Current   decimal.Decimal
+	Withdrawn decimal.Decimal
+	UpdatedAt pgtype.Timestamptz
+	Held      decimal.Decimal
+}
+
+type UserCohort struct {
+	Cohort string
+	UserID uuid.UUID
+}
+
+type UserTier struct {
+	UserID            uuid.UUID
+	Tier              string
+	Volume            decimal.Decimal
+	AccrualMultiplier decimal.Decimal
+	WithdrawalLimit   decimal.Decimal
+	UpdatedAt         pgtype.Timestamptz
+}
+
+type UserTierHistory struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	FromTier  pgtype.Text
+	ToTier    string
+	Volume    decimal.Decimal
+	CreatedAt pgtype.Timestamptz
+}
+
+type WithdrawalRefund struct {
+	ID           uuid.UUID
+	WithdrawalID uuid.UUID
+	Amount       decimal.Decimal
+	CreatedAt    pgtype.Timestamptz
+}
@@ -13,6 +13,362 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+const captureHeldBalance = `-- name: CaptureHeldBalance :exec
+UPDATE user_balances
+SET held       = held - $1,
+    withdrawn  = withdrawn + $1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE user_id = $2
+`
+
+type CaptureHeldBalanceParams struct {
+	Amount decimal.Decimal
+	UserID uuid.UUID
+}
+
+func (q *Queries) CaptureHeldBalance(ctx context.Context, arg CaptureHeldBalanceParams) error {
+	_, err := q.db.Exec(ctx, captureHeldBalance, arg.Amount, arg.UserID)
+	return err
+}
+
+const claimAccrualJob = `-- name: ClaimAccrualJob :one
+UPDATE accrual_jobs
+SET locked_by    = $1,
+    locked_until = CURRENT_TIMESTAMP + make_interval(secs => $2::FLOAT8),
+    attempts     = attempts + 1,
+    updated_at   = CURRENT_TIMESTAMP
+WHERE id = (SELECT id
+            FROM accrual_jobs
+            WHERE next_run_at <= CURRENT_TIMESTAMP
+              AND (locked_until IS NULL OR locked_until < CURRENT_TIMESTAMP)
+            ORDER BY next_run_at
+            LIMIT 1 FOR UPDATE SKIP LOCKED)
+RETURNING id, order_id, order_number, attempts, next_run_at, last_error, locked_by, locked_until, created_at, updated_at
+`
+
+type ClaimAccrualJobParams struct {
+	LockedBy     pgtype.Text
+	LeaseSeconds float64
+}
+
+func (q *Queries) ClaimAccrualJob(ctx context.Context, arg ClaimAccrualJobParams) (AccrualJob, error) {
+	row := q.db.QueryRow(ctx, claimAccrualJob, arg.LockedBy, arg.LeaseSeconds)
+	var i AccrualJob
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.OrderNumber,
+		&i.Attempts,
+		&i.NextRunAt,
+		&i.LastError,
+		&i.LockedBy,
+		&i.LockedUntil,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const completeAccrualJob = `-- name: CompleteAccrualJob :exec
+DELETE
+FROM accrual_jobs
+WHERE id = $1
+  AND locked_by = $2
+`
+
+type CompleteAccrualJobParams struct {
+	ID       uuid.UUID
+	LockedBy pgtype.Text
+}
+
+func (q *Queries) CompleteAccrualJob(ctx context.Context, arg CompleteAccrualJobParams) error {
+	_, err := q.db.Exec(ctx, completeAccrualJob, arg.ID, arg.LockedBy)
+	return err
+}
+
+const countProcessedTopUpOrdersByUserID = `-- name: CountProcessedTopUpOrdersByUserID :one
+SELECT COUNT(*)
+FROM orders
+WHERE type = 'CREDIT'
+  AND status = 'PROCESSED'
+  AND user_id = $1
+  AND id <> $2
+`
+
+type CountProcessedTopUpOrdersByUserIDParams struct {
+	UserID         uuid.UUID
+	ExcludeOrderID uuid.UUID
+}
+
+func (q *Queries) CountProcessedTopUpOrdersByUserID(ctx context.Context, arg CountProcessedTopUpOrdersByUserIDParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countProcessedTopUpOrdersByUserID, arg.UserID, arg.ExcludeOrderID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countRewardedReferralsByReferrerID = `-- name: CountRewardedReferralsByReferrerID :one
+SELECT COUNT(*)
+FROM referrals
+WHERE referrer_id = $1
+  AND status = 'REWARDED'
+`
+
+func (q *Queries) CountRewardedReferralsByReferrerID(ctx context.Context, referrerID uuid.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countRewardedReferralsByReferrerID, referrerID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAccrualJob = `-- name: CreateAccrualJob :exec
+INSERT INTO accrual_jobs (order_id, order_number)
+VALUES ($1, $2)
+ON CONFLICT (order_id) DO NOTHING
+`
+
+type CreateAccrualJobParams struct {
+	OrderID     uuid.UUID
+	OrderNumber string
+}
+
+func (q *Queries) CreateAccrualJob(ctx context.Context, arg CreateAccrualJobParams) error {
+	_, err := q.db.Exec(ctx, createAccrualJob, arg.OrderID, arg.OrderNumber)
+	return err
+}
+
+const createBalanceHold = `-- name: CreateBalanceHold :one
+INSERT INTO balance_holds (user_id, order_number, amount, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id, user_id, order_number, amount, status, expires_at, created_at, updated_at
+`
+
+type CreateBalanceHoldParams struct {
+	UserID      uuid.UUID
+	OrderNumber string
+	Amount      decimal.Decimal
+	ExpiresAt   pgtype.Timestamptz
+}
+
+func (q *Queries) CreateBalanceHold(ctx context.Context, arg CreateBalanceHoldParams) (BalanceHold, error) {
+	row := q.db.QueryRow(ctx, createBalanceHold,
+		arg.UserID,
+		arg.OrderNumber,
+		arg.Amount,
+		arg.ExpiresAt,
+	)
+	var i BalanceHold
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.OrderNumber,
+		&i.Amount,
+		&i.Status,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createGiftCode = `-- name: CreateGiftCode :exec
+INSERT INTO gift_codes (batch_id, code)
+VALUES ($1, $2)
+`
+
+type CreateGiftCodeParams struct {
+	BatchID uuid.UUID
+	Code    string
+}
+
+func (q *Queries) CreateGiftCode(ctx context.Context, arg CreateGiftCodeParams) error {
+	_, err := q.db.Exec(ctx, createGiftCode, arg.BatchID, arg.Code)
+	return err
+}
+
+const createGiftCodeBatch = `-- name: CreateGiftCodeBatch :one
+INSERT INTO gift_code_batches (name, amount, max_redemptions, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id, name, amount, max_redemptions, expires_at, created_at
+`
+
+type CreateGiftCodeBatchParams struct {
+	Name           string
+	Amount         decimal.Decimal
+	MaxRedemptions int32
+	ExpiresAt      pgtype.Timestamptz
+}
+
+func (q *Queries) CreateGiftCodeBatch(ctx context.Context, arg CreateGiftCodeBatchParams) (GiftCodeBatch, error) {
+	row := q.db.QueryRow(ctx, createGiftCodeBatch,
+		arg.Name,
+		arg.Amount,
+		arg.MaxRedemptions,
+		arg.ExpiresAt,
+	)
+	var i GiftCodeBatch
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Amount,
+		&i.MaxRedemptions,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createGiftCodeRedemption = `-- name: CreateGiftCodeRedemption :one
+INSERT INTO gift_code_redemptions (gift_code_id, user_id, amount)
+VALUES ($1, $2, $3)
+ON CONFLICT (gift_code_id, user_id) DO NOTHING
+RETURNING id, gift_code_id, user_id, amount, created_at
+`
+
+type CreateGiftCodeRedemptionParams struct {
+	GiftCodeID uuid.UUID
+	UserID     uuid.UUID
+	Amount     decimal.Decimal
+}
+
+func (q *Queries) CreateGiftCodeRedemption(ctx context.Context, arg CreateGiftCodeRedemptionParams) (GiftCodeRedemption, error) {
+	row := q.db.QueryRow(ctx, createGiftCodeRedemption, arg.GiftCodeID, arg.UserID, arg.Amount)
+	var i GiftCodeRedemption
+	err := row.Scan(
+		&i.ID,
+		&i.GiftCodeID,
+		&i.UserID,
+		&i.Amount,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createLedgerEntry = `-- name: CreateLedgerEntry :exec
+INSERT INTO ledger_entries (transaction_id, account, direction, amount, reference)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type CreateLedgerEntryParams struct {
+	TransactionID uuid.UUID
+	Account       string
+	Direction     Ledgerdirection
+	Amount        decimal.Decimal
+	Reference     string
+}
+
+func (q *Queries) CreateLedgerEntry(ctx context.Context, arg CreateLedgerEntryParams) error {
+	_, err := q.db.Exec(ctx, createLedgerEntry,
+		arg.TransactionID,
+		arg.Account,
+		arg.Direction,
+		arg.Amount,
+		arg.Reference,
+	)
+	return err
+}
+
+const createOrderCampaignBonus = `-- name: CreateOrderCampaignBonus :exec
+INSERT INTO order_campaign_bonuses (order_id, campaign_id, amount)
+VALUES ($1, $2, $3)
+`
+
+type CreateOrderCampaignBonusParams struct {
+	OrderID    uuid.UUID
+	CampaignID uuid.UUID
+	Amount     decimal.Decimal
+}
+
+func (q *Queries) CreateOrderCampaignBonus(ctx context.Context, arg CreateOrderCampaignBonusParams) error {
+	_, err := q.db.Exec(ctx, createOrderCampaignBonus, arg.OrderID, arg.CampaignID, arg.Amount)
+	return err
+}
+
+const createOrderStatusTransition = `-- name: CreateOrderStatusTransition :exec
+INSERT INTO order_status_transitions (order_id, from_status, to_status)
+VALUES ($1, $2, $3)
+`
+
+type CreateOrderStatusTransitionParams struct {
+	OrderID    uuid.UUID
+	FromStatus NullOrderstatus
+	ToStatus   Orderstatus
+}
+
+func (q *Queries) CreateOrderStatusTransition(ctx context.Context, arg CreateOrderStatusTransitionParams) error {
+	_, err := q.db.Exec(ctx, createOrderStatusTransition, arg.OrderID, arg.FromStatus, arg.ToStatus)
+	return err
+}
+
+const createOrderTierBonus = `-- name: CreateOrderTierBonus :exec
+INSERT INTO order_tier_bonuses (order_id, tier, amount)
+VALUES ($1, $2, $3)
+`
+
+type CreateOrderTierBonusParams struct {
+	OrderID uuid.UUID
+	Tier    string
+	Amount  decimal.Decimal
+}
+
+func (q *Queries) CreateOrderTierBonus(ctx context.Context, arg CreateOrderTierBonusParams) error {
+	_, err := q.db.Exec(ctx, createOrderTierBonus, arg.OrderID, arg.Tier, arg.Amount)
+	return err
+}
+
+const createPointLot = `-- name: CreatePointLot :exec
+INSERT INTO point_lots (user_id, reference, amount, remaining, expires_at)
+VALUES ($1, $2, $3, $3, $4)
+`
+
+type CreatePointLotParams struct {
+	UserID    uuid.UUID
+	Reference string
+	Amount    decimal.Decimal
+	ExpiresAt pgtype.Timestamptz
+}
+
+func (q *Queries) CreatePointLot(ctx context.Context, arg CreatePointLotParams) error {
+	_, err := q.db.Exec(ctx, createPointLot,
+		arg.UserID,
+		arg.Reference,
+		arg.Amount,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const createPointLotAllocation = `-- name: CreatePointLotAllocation :exec
+INSERT INTO point_lot_allocations (lot_id, reference, amount)
+VALUES ($1, $2, $3)
+`
+
+type CreatePointLotAllocationParams struct {
+	LotID     uuid.UUID
+	Reference string
+	Amount    decimal.Decimal
+}
+
+func (q *Queries) CreatePointLotAllocation(ctx context.Context, arg CreatePointLotAllocationParams) error {
+	_, err := q.db.Exec(ctx, createPointLotAllocation, arg.LotID, arg.Reference, arg.Amount)
+	return err
+}
+
+const createReferral = `-- name: CreateReferral :exec
+INSERT INTO referrals (referrer_id, referred_id)
+VALUES ($1, $2)
+`
+
+type CreateReferralParams struct {
+	ReferrerID uuid.UUID
+	ReferredID uuid.UUID
+}
+
+func (q *Queries) CreateReferral(ctx context.Context, arg CreateReferralParams) error {
+	_, err := q.db.Exec(ctx, createReferral, arg.ReferrerID, arg.ReferredID)
+	return err
+}
+
 const createTopUpOrder = `-- name: CreateTopUpOrder :one
 INSERT INTO orders (user_id, number, amount, type)
 VALUES ($1, $2, $3, 'CREDIT')
@@ -41,77 +397,819 @@ func (q *Queries) CreateTopUpOrder(ctx context.Context, arg CreateTopUpOrderPara
 	return i, err
 }
 
-const getNewTopUpOrder = `-- name: GetNewTopUpOrder :one
-SELECT id, user_id, amount, number, type, status, processed_at, created_at
-FROM orders
-WHERE type = 'CREDIT'
-  AND status = 'NEW'
-LIMIT 1
+const createTransfer = `-- name: CreateTransfer :one
+INSERT INTO transfers (sender_id, recipient_id, amount)
+VALUES ($1, $2, $3)
+RETURNING id, sender_id, recipient_id, amount, created_at
+`
+
+type CreateTransferParams struct {
+	SenderID    uuid.UUID
+	RecipientID uuid.UUID
+	Amount      decimal.Decimal
+}
+
+func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
+	row := q.db.QueryRow(ctx, createTransfer, arg.SenderID, arg.RecipientID, arg.Amount)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.SenderID,
+		&i.RecipientID,
+		&i.Amount,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createUserTierHistory = `-- name: CreateUserTierHistory :exec
+INSERT INTO user_tier_history (user_id, from_tier, to_tier, volume)
+VALUES ($1, $2, $3, $4)
+`
+
+type CreateUserTierHistoryParams struct {
+	UserID   uuid.UUID
+	FromTier pgtype.Text
+	ToTier   string
+	Volume   decimal.Decimal
+}
+
+func (q *Queries) CreateUserTierHistory(ctx context.Context, arg CreateUserTierHistoryParams) error {
+	_, err := q.db.Exec(ctx, createUserTierHistory,
+		arg.UserID,
+		arg.FromTier,
+		arg.ToTier,
+		arg.Volume,
+	)
+	return err
+}
+
+const createWithdrawalRefund = `-- name: CreateWithdrawalRefund :exec
+INSERT INTO withdrawal_refunds (withdrawal_id, amount)
+VALUES ($1, $2)
+`
+
+type CreateWithdrawalRefundParams struct {
+	WithdrawalID uuid.UUID
+	Amount       decimal.Decimal
+}
+
+func (q *Queries) CreateWithdrawalRefund(ctx context.Context, arg CreateWithdrawalRefundParams) error {
+	_, err := q.db.Exec(ctx, createWithdrawalRefund, arg.WithdrawalID, arg.Amount)
+	return err
+}
+
+const creditUserBalance = `-- name: CreditUserBalance :exec
+INSERT INTO user_balances (user_id, current)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+    SET current    = user_balances.current + EXCLUDED.current,
+        updated_at = CURRENT_TIMESTAMP
+`
+
+type CreditUserBalanceParams struct {
+	UserID uuid.UUID
+	Amount decimal.Decimal
+}
+
+func (q *Queries) CreditUserBalance(ctx context.Context, arg CreditUserBalanceParams) error {
+	_, err := q.db.Exec(ctx, creditUserBalance, arg.UserID, arg.Amount)
+	return err
+}
+
+const debitUserBalance = `-- name: DebitUserBalance :exec
+UPDATE user_balances
+SET current    = current - $1,
+    withdrawn  = withdrawn + $1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE user_id = $2
+`
+
+type DebitUserBalanceParams struct {
+	Amount decimal.Decimal
+	UserID uuid.UUID
+}
+
+func (q *Queries) DebitUserBalance(ctx context.Context, arg DebitUserBalanceParams) error {
+	_, err := q.db.Exec(ctx, debitUserBalance, arg.Amount, arg.UserID)
+	return err
+}
+
+const ensureUserBalance = `-- name: EnsureUserBalance :exec
+INSERT INTO user_balances (user_id)
+VALUES ($1)
+ON CONFLICT (user_id) DO NOTHING
+`
+
+func (q *Queries) EnsureUserBalance(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, ensureUserBalance, userID)
+	return err
+}
+
+const expireUserBalance = `-- name: ExpireUserBalance :exec
+UPDATE user_balances
+SET current    = current - $1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE user_id = $2
+`
+
+type ExpireUserBalanceParams struct {
+	Amount decimal.Decimal
+	UserID uuid.UUID
+}
+
+func (q *Queries) ExpireUserBalance(ctx context.Context, arg ExpireUserBalanceParams) error {
+	_, err := q.db.Exec(ctx, expireUserBalance, arg.Amount, arg.UserID)
+	return err
+}
+
+const getAccrualVolumeByUserID = `-- name: GetAccrualVolumeByUserID :one
+SELECT COALESCE(SUM(amount), 0)::NUMERIC AS volume
+FROM orders
+WHERE type = 'CREDIT'
+  AND status = 'PROCESSED'
+  AND user_id = $1
+  AND processed_at >= $2
+`
+
+type GetAccrualVolumeByUserIDParams struct {
+	UserID uuid.UUID
+	Since  pgtype.Timestamptz
+}
+
+func (q *Queries) GetAccrualVolumeByUserID(ctx context.Context, arg GetAccrualVolumeByUserIDParams) (decimal.Decimal, error) {
+	row := q.db.QueryRow(ctx, getAccrualVolumeByUserID, arg.UserID, arg.Since)
+	var volume decimal.Decimal
+	err := row.Scan(&volume)
+	return volume, err
+}
+
+const getAccrualVolumes = `-- name: GetAccrualVolumes :many
+SELECT orders.user_id,
+       COALESCE(SUM(orders.amount) FILTER (WHERE orders.status = 'PROCESSED' AND orders.processed_at >= $1),
+                0)::NUMERIC AS volume
+FROM orders
+WHERE orders.type = 'CREDIT'
+  AND orders.user_id > $2
+GROUP BY orders.user_id
+ORDER BY orders.user_id
+LIMIT $3
+`
+
+type GetAccrualVolumesParams struct {
+	Since       pgtype.Timestamptz
+	AfterUserID uuid.UUID
+	LimitCount  int32
+}
+
+type GetAccrualVolumesRow struct {
+	UserID uuid.UUID
+	Volume decimal.Decimal
+}
+
+func (q *Queries) GetAccrualVolumes(ctx context.Context, arg GetAccrualVolumesParams) ([]GetAccrualVolumesRow, error) {
+	rows, err := q.db.Query(ctx, getAccrualVolumes, arg.Since, arg.AfterUserID, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAccrualVolumesRow
+	for rows.Next() {
+		var i GetAccrualVolumesRow
+		if err := rows.Scan(&i.UserID, &i.Volume); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getActiveCampaignsByUserID = `-- name: GetActiveCampaignsByUserID :many
+SELECT campaigns.id, campaigns.name, campaigns.starts_at, campaigns.ends_at, campaigns.days_of_week, campaigns.first_order_only, campaigns.cohort, campaigns.multiplier, campaigns.bonus, campaigns.budget, campaigns.spent, campaigns.created_at,
+       (campaigns.cohort IS NOT NULL AND EXISTS (SELECT 1
+                                                 FROM user_cohorts
+                                                 WHERE user_cohorts.cohort = campaigns.cohort
+                                                   AND user_cohorts.user_id = $1))::BOOLEAN AS in_cohort
+FROM campaigns
+WHERE campaigns.starts_at <= $2
+  AND (campaigns.ends_at IS NULL OR campaigns.ends_at > $2)
+ORDER BY campaigns.created_at, campaigns.id
+`
+
+type GetActiveCampaignsByUserIDParams struct {
+	UserID uuid.UUID
+	At     pgtype.Timestamptz
+}
+
+type GetActiveCampaignsByUserIDRow struct {
+	Campaign Campaign
+	InCohort bool
+}
+
+func (q *Queries) GetActiveCampaignsByUserID(ctx context.Context, arg GetActiveCampaignsByUserIDParams) ([]GetActiveCampaignsByUserIDRow, error) {
+	rows, err := q.db.Query(ctx, getActiveCampaignsByUserID, arg.UserID, arg.At)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetActiveCampaignsByUserIDRow
+	for rows.Next() {
+		var i GetActiveCampaignsByUserIDRow
+		if err := rows.Scan(
+			&i.Campaign.ID,
+			&i.Campaign.Name,
+			&i.Campaign.StartsAt,
+			&i.Campaign.EndsAt,
+			&i.Campaign.DaysOfWeek,
+			&i.Campaign.FirstOrderOnly,
+			&i.Campaign.Cohort,
+			&i.Campaign.Multiplier,
+			&i.Campaign.Bonus,
+			&i.Campaign.Budget,
+			&i.Campaign.Spent,
+			&i.Campaign.CreatedAt,
+			&i.InCohort,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getGiftCodeBatch = `-- name: GetGiftCodeBatch :one
+SELECT id, name, amount, max_redemptions, expires_at, created_at
+FROM gift_code_batches
+WHERE id = $1
+`
+
+func (q *Queries) GetGiftCodeBatch(ctx context.Context, id uuid.UUID) (GiftCodeBatch, error) {
+	row := q.db.QueryRow(ctx, getGiftCodeBatch, id)
+	var i GiftCodeBatch
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Amount,
+		&i.MaxRedemptions,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getGiftCodesByBatchID = `-- name: GetGiftCodesByBatchID :many
+SELECT id, batch_id, code, redemptions, created_at
+FROM gift_codes
+WHERE batch_id = $1
+ORDER BY code
+`
+
+func (q *Queries) GetGiftCodesByBatchID(ctx context.Context, batchID uuid.UUID) ([]GiftCode, error) {
+	rows, err := q.db.Query(ctx, getGiftCodesByBatchID, batchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GiftCode
+	for rows.Next() {
+		var i GiftCode
+		if err := rows.Scan(
+			&i.ID,
+			&i.BatchID,
+			&i.Code,
+			&i.Redemptions,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOrderByNumber = `-- name: GetOrderByNumber :one
+SELECT id, user_id, amount, number, type, status, processed_at, created_at
+FROM orders
+WHERE number = $1
+LIMIT 1
+`
+
+func (q *Queries) GetOrderByNumber(ctx context.Context, number string) (Order, error) {
+	row := q.db.QueryRow(ctx, getOrderByNumber, number)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Amount,
+		&i.Number,
+		&i.Type,
+		&i.Status,
+		&i.ProcessedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPointsExpiryByUserID = `-- name: GetPointsExpiryByUserID :one
+SELECT COALESCE(SUM(remaining) FILTER (WHERE expires_at <= $1), 0)::NUMERIC AS expiring_soon,
+       MIN(expires_at)::TIMESTAMPTZ                                                    AS next_expiry
+FROM point_lots
+WHERE user_id = $2
+  AND remaining > 0
+  AND expires_at > CURRENT_TIMESTAMP
+`
+
+type GetPointsExpiryByUserIDParams struct {
+	Until  pgtype.Timestamptz
+	UserID uuid.UUID
+}
+
+type GetPointsExpiryByUserIDRow struct {
+	ExpiringSoon decimal.Decimal
+	NextExpiry   pgtype.Timestamptz
+}
+
+func (q *Queries) GetPointsExpiryByUserID(ctx context.Context, arg GetPointsExpiryByUserIDParams) (GetPointsExpiryByUserIDRow, error) {
+	row := q.db.QueryRow(ctx, getPointsExpiryByUserID, arg.Until, arg.UserID)
+	var i GetPointsExpiryByUserIDRow
+	err := row.Scan(&i.ExpiringSoon, &i.NextExpiry)
+	return i, err
+}
+
+const getReferralCodeByUserID = `-- name: GetReferralCodeByUserID :one
+SELECT referral_code
+FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetReferralCodeByUserID(ctx context.Context, id uuid.UUID) (string, error) {
+	row := q.db.QueryRow(ctx, getReferralCodeByUserID, id)
+	var referral_code string
+	err := row.Scan(&referral_code)
+	return referral_code, err
+}
+
+const getReferralsByReferrerID = `-- name: GetReferralsByReferrerID :many
+SELECT users.username AS referred,
+       referrals.status,
+       referrals.reason,
+       referrals.referrer_reward,
+       referrals.created_at,
+       referrals.settled_at
+FROM referrals
+         JOIN users ON users.id = referrals.referred_id
+WHERE referrals.referrer_id = $1
+ORDER BY referrals.created_at DESC
+`
+
+type GetReferralsByReferrerIDRow struct {
+	Referred       string
+	Status         Referralstatus
+	Reason         pgtype.Text
+	ReferrerReward decimal.Decimal
+	CreatedAt      pgtype.Timestamptz
+	SettledAt      pgtype.Timestamptz
+}
+
+func (q *Queries) GetReferralsByReferrerID(ctx context.Context, referrerID uuid.UUID) ([]GetReferralsByReferrerIDRow, error) {
+	rows, err := q.db.Query(ctx, getReferralsByReferrerID, referrerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetReferralsByReferrerIDRow
+	for rows.Next() {
+		var i GetReferralsByReferrerIDRow
+		if err := rows.Scan(
+			&i.Referred,
+			&i.Status,
+			&i.Reason,
+			&i.ReferrerReward,
+			&i.CreatedAt,
+			&i.SettledAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRefundedAmountByWithdrawalID = `-- name: GetRefundedAmountByWithdrawalID :one
+SELECT COALESCE(SUM(amount), 0)::NUMERIC AS refunded
+FROM withdrawal_refunds
+WHERE withdrawal_id = $1
+`
+
+func (q *Queries) GetRefundedAmountByWithdrawalID(ctx context.Context, withdrawalID uuid.UUID) (decimal.Decimal, error) {
+	row := q.db.QueryRow(ctx, getRefundedAmountByWithdrawalID, withdrawalID)
+	var refunded decimal.Decimal
+	err := row.Scan(&refunded)
+	return refunded, err
+}
+
+const getTopUpOrdersByUserID = `-- name: GetTopUpOrdersByUserID :many
+SELECT id, user_id, amount, number, type, status, processed_at, created_at
+FROM orders
+WHERE type = 'CREDIT'
+  AND user_id = $1
+`
+
+func (q *Queries) GetTopUpOrdersByUserID(ctx context.Context, userID uuid.UUID) ([]Order, error) {
+	rows, err := q.db.Query(ctx, getTopUpOrdersByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Amount,
+			&i.Number,
+			&i.Type,
+			&i.Status,
+			&i.ProcessedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTransferredSinceByUserID = `-- name: GetTransferredSinceByUserID :one
+SELECT COALESCE(SUM(amount), 0)::NUMERIC AS transferred
+FROM transfers
+WHERE sender_id = $1
+  AND created_at >= $2
+`
+
+type GetTransferredSinceByUserIDParams struct {
+	UserID uuid.UUID
+	Since  pgtype.Timestamptz
+}
+
+func (q *Queries) GetTransferredSinceByUserID(ctx context.Context, arg GetTransferredSinceByUserIDParams) (decimal.Decimal, error) {
+	row := q.db.QueryRow(ctx, getTransferredSinceByUserID, arg.UserID, arg.Since)
+	var transferred decimal.Decimal
+	err := row.Scan(&transferred)
+	return transferred, err
+}
+
+const getTransfersByUserID = `-- name: GetTransfersByUserID :many
+SELECT transfers.id,
+       transfers.amount,
+       transfers.created_at,
+       (transfers.sender_id = $1)::BOOLEAN AS outgoing,
+       users.username                                     AS counterparty
+FROM transfers
+         JOIN users ON users.id = CASE
+                                      WHEN transfers.sender_id = $1 THEN transfers.recipient_id
+                                      ELSE transfers.sender_id END
+WHERE transfers.sender_id = $1
+   OR transfers.recipient_id = $1
+ORDER BY transfers.created_at DESC
+`
+
+type GetTransfersByUserIDRow struct {
+	ID           uuid.UUID
+	Amount       decimal.Decimal
+	CreatedAt    pgtype.Timestamptz
+	Outgoing     bool
+	Counterparty string
+}
+
+func (q *Queries) GetTransfersByUserID(ctx context.Context, userID uuid.UUID) ([]GetTransfersByUserIDRow, error) {
+	rows, err := q.db.Query(ctx, getTransfersByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTransfersByUserIDRow
+	for rows.Next() {
+		var i GetTransfersByUserIDRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.Outgoing,
+			&i.Counterparty,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserBalanceByUserID = `-- name: GetUserBalanceByUserID :one
+SELECT user_id, current, withdrawn, updated_at, held
+FROM user_balances
+WHERE user_id = $1
+`
+
+func (q *Queries) GetUserBalanceByUserID(ctx context.Context, userID uuid.UUID) (UserBalance, error) {
+	row := q.db.QueryRow(ctx, getUserBalanceByUserID, userID)
+	var i UserBalance
+	err := row.Scan(
+		&i.UserID,
+		&i.Current,
+		&i.Withdrawn,
+		&i.UpdatedAt,
+		&i.Held,
+	)
+	return i, err
+}
+
+const getUserIDByUsername = `-- name: GetUserIDByUsername :one
+SELECT id
+FROM users
+WHERE username = $1
+`
+
+func (q *Queries) GetUserIDByUsername(ctx context.Context, username string) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, getUserIDByUsername, username)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getUserTierByUserID = `-- name: GetUserTierByUserID :one
+SELECT user_id, tier, volume, accrual_multiplier, withdrawal_limit, updated_at
+FROM user_tiers
+WHERE user_id = $1
+`
+
+func (q *Queries) GetUserTierByUserID(ctx context.Context, userID uuid.UUID) (UserTier, error) {
+	row := q.db.QueryRow(ctx, getUserTierByUserID, userID)
+	var i UserTier
+	err := row.Scan(
+		&i.UserID,
+		&i.Tier,
+		&i.Volume,
+		&i.AccrualMultiplier,
+		&i.WithdrawalLimit,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getUsersWithExpiredPointLots = `-- name: GetUsersWithExpiredPointLots :many
+SELECT DISTINCT user_id
+FROM point_lots
+WHERE remaining > 0
+  AND expires_at <= CURRENT_TIMESTAMP
+LIMIT $1
+`
+
+func (q *Queries) GetUsersWithExpiredPointLots(ctx context.Context, limit int32) ([]uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, getUsersWithExpiredPointLots, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var user_id uuid.UUID
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWithdrawalsByUserID = `-- name: GetWithdrawalsByUserID :many
+SELECT orders.id, orders.user_id, orders.amount, orders.number, orders.type, orders.status, orders.processed_at, orders.created_at, COALESCE(SUM(withdrawal_refunds.amount), 0)::NUMERIC AS refunded
+FROM orders
+         LEFT JOIN withdrawal_refunds ON withdrawal_refunds.withdrawal_id = orders.id
+WHERE orders.user_id = $1
+  AND orders.type = 'DEBIT'
+  AND orders.status = 'PROCESSED'
+GROUP BY orders.id
+ORDER BY orders.processed_at
+`
+
+type GetWithdrawalsByUserIDRow struct {
+	Order    Order
+	Refunded decimal.Decimal
+}
+
+func (q *Queries) GetWithdrawalsByUserID(ctx context.Context, userID uuid.UUID) ([]GetWithdrawalsByUserIDRow, error) {
+	rows, err := q.db.Query(ctx, getWithdrawalsByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetWithdrawalsByUserIDRow
+	for rows.Next() {
+		var i GetWithdrawalsByUserIDRow
+		if err := rows.Scan(
+			&i.Order.ID,
+			&i.Order.UserID,
+			&i.Order.Amount,
+			&i.Order.Number,
+			&i.Order.Type,
+			&i.Order.Status,
+			&i.Order.ProcessedAt,
+			&i.Order.CreatedAt,
+			&i.Refunded,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWithdrawnSinceByUserID = `-- name: GetWithdrawnSinceByUserID :one
+SELECT (COALESCE((SELECT SUM(orders.amount)
+                  FROM orders
+                  WHERE orders.user_id = $1
+                    AND orders.type = 'DEBIT'
+                    AND orders.created_at >= $2), 0) +
+        COALESCE((SELECT SUM(balance_holds.amount)
+                  FROM balance_holds
+                  WHERE balance_holds.user_id = $1
+                    AND balance_holds.status = 'ACTIVE'
+                    AND balance_holds.created_at >= $2), 0))::NUMERIC AS withdrawn
+`
+
+type GetWithdrawnSinceByUserIDParams struct {
+	UserID uuid.UUID
+	Since  pgtype.Timestamptz
+}
+
+func (q *Queries) GetWithdrawnSinceByUserID(ctx context.Context, arg GetWithdrawnSinceByUserIDParams) (decimal.Decimal, error) {
+	row := q.db.QueryRow(ctx, getWithdrawnSinceByUserID, arg.UserID, arg.Since)
+	var withdrawn decimal.Decimal
+	err := row.Scan(&withdrawn)
+	return withdrawn, err
+}
+
+const holdUserBalance = `-- name: HoldUserBalance :exec
+UPDATE user_balances
+SET current    = current - $1,
+    held       = held + $1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE user_id = $2
+`
+
+type HoldUserBalanceParams struct {
+	Amount decimal.Decimal
+	UserID uuid.UUID
+}
+
+func (q *Queries) HoldUserBalance(ctx context.Context, arg HoldUserBalanceParams) error {
+	_, err := q.db.Exec(ctx, holdUserBalance, arg.Amount, arg.UserID)
+	return err
+}
+
+const incrementGiftCodeRedemptions = `-- name: IncrementGiftCodeRedemptions :exec
+UPDATE gift_codes
+SET redemptions = redemptions + 1
+WHERE id = $1
+`
+
+func (q *Queries) IncrementGiftCodeRedemptions(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, incrementGiftCodeRedemptions, id)
+	return err
+}
+
+const lockBalanceHold = `-- name: LockBalanceHold :one
+SELECT id, user_id, order_number, amount, status, expires_at, created_at, updated_at
+FROM balance_holds
+WHERE id = $1
+  AND user_id = $2
+FOR UPDATE
+`
+
+type LockBalanceHoldParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) LockBalanceHold(ctx context.Context, arg LockBalanceHoldParams) (BalanceHold, error) {
+	row := q.db.QueryRow(ctx, lockBalanceHold, arg.ID, arg.UserID)
+	var i BalanceHold
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.OrderNumber,
+		&i.Amount,
+		&i.Status,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const lockCampaign = `-- name: LockCampaign :one
+SELECT id, name, starts_at, ends_at, days_of_week, first_order_only, cohort, multiplier, bonus, budget, spent, created_at
+FROM campaigns
+WHERE id = $1
+FOR UPDATE
 `
 
-func (q *Queries) GetNewTopUpOrder(ctx context.Context) (Order, error) {
-	row := q.db.QueryRow(ctx, getNewTopUpOrder)
-	var i Order
+func (q *Queries) LockCampaign(ctx context.Context, id uuid.UUID) (Campaign, error) {
+	row := q.db.QueryRow(ctx, lockCampaign, id)
+	var i Campaign
 	err := row.Scan(
 		&i.ID,
-		&i.UserID,
-		&i.Amount,
-		&i.Number,
-		&i.Type,
-		&i.Status,
-		&i.ProcessedAt,
+		&i.Name,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.DaysOfWeek,
+		&i.FirstOrderOnly,
+		&i.Cohort,
+		&i.Multiplier,
+		&i.Bonus,
+		&i.Budget,
+		&i.Spent,
 		&i.CreatedAt,
 	)
 	return i, err
 }
 
-const getOrderByNumber = `-- name: GetOrderByNumber :one
-SELECT id, user_id, amount, number, type, status, processed_at, created_at
-FROM orders
-WHERE number = $1
-LIMIT 1
+const lockExpiredBalanceHold = `-- name: LockExpiredBalanceHold :one
+SELECT id, user_id, order_number, amount, status, expires_at, created_at, updated_at
+FROM balance_holds
+WHERE status = 'ACTIVE'
+  AND expires_at <= CURRENT_TIMESTAMP
+ORDER BY expires_at
+LIMIT 1 FOR UPDATE SKIP LOCKED
 `
 
-func (q *Queries) GetOrderByNumber(ctx context.Context, number string) (Order, error) {
-	row := q.db.QueryRow(ctx, getOrderByNumber, number)
-	var i Order
+func (q *Queries) LockExpiredBalanceHold(ctx context.Context) (BalanceHold, error) {
+	row := q.db.QueryRow(ctx, lockExpiredBalanceHold)
+	var i BalanceHold
 	err := row.Scan(
 		&i.ID,
 		&i.UserID,
+		&i.OrderNumber,
 		&i.Amount,
-		&i.Number,
-		&i.Type,
 		&i.Status,
-		&i.ProcessedAt,
+		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const getTopUpOrdersByUserID = `-- name: GetTopUpOrdersByUserID :many
-SELECT id, user_id, amount, number, type, status, processed_at, created_at
-FROM orders
-WHERE type = 'CREDIT'
-  AND user_id = $1
+const lockExpiredPointLotsByUser = `-- name: LockExpiredPointLotsByUser :many
+SELECT id, user_id, reference, amount, remaining, expires_at, created_at
+FROM point_lots
+WHERE user_id = $1
+  AND remaining > 0
+  AND expires_at <= CURRENT_TIMESTAMP
+FOR UPDATE
 `
 
-func (q *Queries) GetTopUpOrdersByUserID(ctx context.Context, userID uuid.UUID) ([]Order, error) {
-	rows, err := q.db.Query(ctx, getTopUpOrdersByUserID, userID)
+func (q *Queries) LockExpiredPointLotsByUser(ctx context.Context, userID uuid.UUID) ([]PointLot, error) {
+	rows, err := q.db.Query(ctx, lockExpiredPointLotsByUser, userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Order
+	var items []PointLot
 	for rows.Next() {
-		var i Order
+		var i PointLot
 		if err := rows.Scan(
 			&i.ID,
 			&i.UserID,
+			&i.Reference,
 			&i.Amount,
-			&i.Number,
-			&i.Type,
-			&i.Status,
-			&i.ProcessedAt,
+			&i.Remaining,
+			&i.ExpiresAt,
 			&i.CreatedAt,
 		); err != nil {
 			return nil, err
@@ -124,67 +1222,135 @@ func (q *Queries) GetTopUpOrdersByUserID(ctx context.Context, userID uuid.UUID)
 	return items, nil
 }
 
-const getUserBalanceByUserID = `-- name: GetUserBalanceByUserID :one
-SELECT COALESCE(SUM(
-                        CASE type
-                            WHEN 'CREDIT' THEN amount
-                            WHEN 'DEBIT' THEN -amount
-                            ELSE 0
-                            END
-                ), 0::NUMERIC(10, 2))::NUMERIC(10, 2)
-FROM orders
-WHERE user_id = $1
-  AND status = 'PROCESSED'
+const lockGiftCodeByCode = `-- name: LockGiftCodeByCode :one
+SELECT gift_codes.id,
+       gift_codes.code,
+       gift_codes.redemptions,
+       gift_code_batches.amount,
+       gift_code_batches.max_redemptions,
+       gift_code_batches.expires_at
+FROM gift_codes
+         JOIN gift_code_batches ON gift_code_batches.id = gift_codes.batch_id
+WHERE gift_codes.code = $1
+FOR UPDATE OF gift_codes
 `
 
-func (q *Queries) GetUserBalanceByUserID(ctx context.Context, userID uuid.UUID) (decimal.Decimal, error) {
-	row := q.db.QueryRow(ctx, getUserBalanceByUserID, userID)
-	var column_1 decimal.Decimal
-	err := row.Scan(&column_1)
-	return column_1, err
+type LockGiftCodeByCodeRow struct {
+	ID             uuid.UUID
+	Code           string
+	Redemptions    int32
+	Amount         decimal.Decimal
+	MaxRedemptions int32
+	ExpiresAt      pgtype.Timestamptz
 }
 
-const getUserWithdrawByUserID = `-- name: GetUserWithdrawByUserID :one
-SELECT COALESCE(SUM(amount), 0)::NUMERIC(10, 2)
-FROM orders
-WHERE user_id = $1
-  AND type = 'DEBIT'
-  AND status = 'PROCESSED'
+func (q *Queries) LockGiftCodeByCode(ctx context.Context, code string) (LockGiftCodeByCodeRow, error) {
+	row := q.db.QueryRow(ctx, lockGiftCodeByCode, code)
+	var i LockGiftCodeByCodeRow
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Redemptions,
+		&i.Amount,
+		&i.MaxRedemptions,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const lockPendingReferralByReferredID = `-- name: LockPendingReferralByReferredID :one
+SELECT referred_id, referrer_id, status, reason, order_id, referrer_reward, referred_reward, created_at, settled_at
+FROM referrals
+WHERE referred_id = $1
+  AND status = 'PENDING'
+FOR UPDATE
+`
+
+func (q *Queries) LockPendingReferralByReferredID(ctx context.Context, referredID uuid.UUID) (Referral, error) {
+	row := q.db.QueryRow(ctx, lockPendingReferralByReferredID, referredID)
+	var i Referral
+	err := row.Scan(
+		&i.ReferredID,
+		&i.ReferrerID,
+		&i.Status,
+		&i.Reason,
+		&i.OrderID,
+		&i.ReferrerReward,
+		&i.ReferredReward,
+		&i.CreatedAt,
+		&i.SettledAt,
+	)
+	return i, err
+}
+
+const lockPointLotAllocationsByReference = `-- name: LockPointLotAllocationsByReference :many
+SELECT point_lot_allocations.id, point_lot_allocations.lot_id, point_lot_allocations.reference, point_lot_allocations.amount, point_lot_allocations.created_at
+FROM point_lot_allocations
+         JOIN point_lots ON point_lots.id = point_lot_allocations.lot_id
+WHERE point_lot_allocations.reference = $1
+  AND point_lots.user_id = $2
+  AND point_lot_allocations.amount > 0
+ORDER BY point_lots.expires_at DESC NULLS FIRST
+FOR UPDATE
 `
 
-func (q *Queries) GetUserWithdrawByUserID(ctx context.Context, userID uuid.UUID) (decimal.Decimal, error) {
-	row := q.db.QueryRow(ctx, getUserWithdrawByUserID, userID)
-	var column_1 decimal.Decimal
-	err := row.Scan(&column_1)
-	return column_1, err
+type LockPointLotAllocationsByReferenceParams struct {
+	Reference string
+	UserID    uuid.UUID
 }
 
-const getWithdrawalsByUserID = `-- name: GetWithdrawalsByUserID :many
-SELECT id, user_id, amount, number, type, status, processed_at, created_at
-FROM orders
+func (q *Queries) LockPointLotAllocationsByReference(ctx context.Context, arg LockPointLotAllocationsByReferenceParams) ([]PointLotAllocation, error) {
+	rows, err := q.db.Query(ctx, lockPointLotAllocationsByReference, arg.Reference, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PointLotAllocation
+	for rows.Next() {
+		var i PointLotAllocation
+		if err := rows.Scan(
+			&i.ID,
+			&i.LotID,
+			&i.Reference,
+			&i.Amount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockSpendablePointLots = `-- name: LockSpendablePointLots :many
+SELECT id, user_id, reference, amount, remaining, expires_at, created_at
+FROM point_lots
 WHERE user_id = $1
-  AND type = 'DEBIT'
-  AND status = 'PROCESSED'
-ORDER BY processed_at
+  AND remaining > 0
+  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+ORDER BY expires_at NULLS LAST, created_at
+FOR UPDATE
 `
 
-func (q *Queries) GetWithdrawalsByUserID(ctx context.Context, userID uuid.UUID) ([]Order, error) {
-	rows, err := q.db.Query(ctx, getWithdrawalsByUserID, userID)
+func (q *Queries) LockSpendablePointLots(ctx context.Context, userID uuid.UUID) ([]PointLot, error) {
+	rows, err := q.db.Query(ctx, lockSpendablePointLots, userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Order
+	var items []PointLot
 	for rows.Next() {
-		var i Order
+		var i PointLot
 		if err := rows.Scan(
 			&i.ID,
 			&i.UserID,
+			&i.Reference,
 			&i.Amount,
-			&i.Number,
-			&i.Type,
-			&i.Status,
-			&i.ProcessedAt,
+			&i.Remaining,
+			&i.ExpiresAt,
 			&i.CreatedAt,
 		); err != nil {
 			return nil, err
@@ -197,27 +1363,370 @@ func (q *Queries) GetWithdrawalsByUserID(ctx context.Context, userID uuid.UUID)
 	return items, nil
 }
 
-const updateOrderByNumber = `-- name: UpdateOrderByNumber :exec
-UPDATE orders
-SET status       = $2,
-    amount       = $3,
-    processed_at = $4
+const lockUserBalance = `-- name: LockUserBalance :one
+SELECT user_id, current, withdrawn, updated_at, held
+FROM user_balances
+WHERE user_id = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockUserBalance(ctx context.Context, userID uuid.UUID) (UserBalance, error) {
+	row := q.db.QueryRow(ctx, lockUserBalance, userID)
+	var i UserBalance
+	err := row.Scan(
+		&i.UserID,
+		&i.Current,
+		&i.Withdrawn,
+		&i.UpdatedAt,
+		&i.Held,
+	)
+	return i, err
+}
+
+const lockUserTier = `-- name: LockUserTier :one
+SELECT user_id, tier, volume, accrual_multiplier, withdrawal_limit, updated_at
+FROM user_tiers
+WHERE user_id = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockUserTier(ctx context.Context, userID uuid.UUID) (UserTier, error) {
+	row := q.db.QueryRow(ctx, lockUserTier, userID)
+	var i UserTier
+	err := row.Scan(
+		&i.UserID,
+		&i.Tier,
+		&i.Volume,
+		&i.AccrualMultiplier,
+		&i.WithdrawalLimit,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const lockWithdrawalByNumber = `-- name: LockWithdrawalByNumber :one
+SELECT id, user_id, amount, number, type, status, processed_at, created_at
+FROM orders
 WHERE number = $1
+  AND user_id = $2
+  AND type = 'DEBIT'
+  AND status = 'PROCESSED'
+FOR UPDATE
 `
 
-type UpdateOrderByNumberParams struct {
-	Number      string
-	Status      Orderstatus
+type LockWithdrawalByNumberParams struct {
+	Number string
+	UserID uuid.UUID
+}
+
+func (q *Queries) LockWithdrawalByNumber(ctx context.Context, arg LockWithdrawalByNumberParams) (Order, error) {
+	row := q.db.QueryRow(ctx, lockWithdrawalByNumber, arg.Number, arg.UserID)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Amount,
+		&i.Number,
+		&i.Type,
+		&i.Status,
+		&i.ProcessedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const refundUserBalance = `-- name: RefundUserBalance :exec
+UPDATE user_balances
+SET current    = current + $1,
+    withdrawn  = withdrawn - $1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE user_id = $2
+`
+
+type RefundUserBalanceParams struct {
+	Amount decimal.Decimal
+	UserID uuid.UUID
+}
+
+func (q *Queries) RefundUserBalance(ctx context.Context, arg RefundUserBalanceParams) error {
+	_, err := q.db.Exec(ctx, refundUserBalance, arg.Amount, arg.UserID)
+	return err
+}
+
+const releaseAccrualJob = `-- name: ReleaseAccrualJob :exec
+UPDATE accrual_jobs
+SET attempts     = GREATEST(attempts - 1, 0),
+    locked_by    = NULL,
+    locked_until = NULL,
+    updated_at   = CURRENT_TIMESTAMP
+WHERE id = $1
+  AND locked_by = $2
+`
+
+type ReleaseAccrualJobParams struct {
+	ID       uuid.UUID
+	LockedBy pgtype.Text
+}
+
+func (q *Queries) ReleaseAccrualJob(ctx context.Context, arg ReleaseAccrualJobParams) error {
+	_, err := q.db.Exec(ctx, releaseAccrualJob, arg.ID, arg.LockedBy)
+	return err
+}
+
+const releaseHeldBalance = `-- name: ReleaseHeldBalance :exec
+UPDATE user_balances
+SET held       = held - $1,
+    current    = current + $1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE user_id = $2
+`
+
+type ReleaseHeldBalanceParams struct {
+	Amount decimal.Decimal
+	UserID uuid.UUID
+}
+
+func (q *Queries) ReleaseHeldBalance(ctx context.Context, arg ReleaseHeldBalanceParams) error {
+	_, err := q.db.Exec(ctx, releaseHeldBalance, arg.Amount, arg.UserID)
+	return err
+}
+
+const rescheduleAccrualJob = `-- name: RescheduleAccrualJob :exec
+UPDATE accrual_jobs
+SET next_run_at  = CURRENT_TIMESTAMP + make_interval(secs => $1::FLOAT8),
+    last_error   = $2,
+    locked_by    = NULL,
+    locked_until = NULL,
+    updated_at   = CURRENT_TIMESTAMP
+WHERE id = $3
+  AND locked_by = $4
+`
+
+type RescheduleAccrualJobParams struct {
+	DelaySeconds float64
+	LastError    pgtype.Text
+	ID           uuid.UUID
+	LockedBy     pgtype.Text
+}
+
+func (q *Queries) RescheduleAccrualJob(ctx context.Context, arg RescheduleAccrualJobParams) error {
+	_, err := q.db.Exec(ctx, rescheduleAccrualJob,
+		arg.DelaySeconds,
+		arg.LastError,
+		arg.ID,
+		arg.LockedBy,
+	)
+	return err
+}
+
+const restorePointLot = `-- name: RestorePointLot :exec
+UPDATE point_lots
+SET remaining = remaining + $2
+WHERE id = $1
+`
+
+type RestorePointLotParams struct {
+	ID        uuid.UUID
+	Remaining decimal.Decimal
+}
+
+func (q *Queries) RestorePointLot(ctx context.Context, arg RestorePointLotParams) error {
+	_, err := q.db.Exec(ctx, restorePointLot, arg.ID, arg.Remaining)
+	return err
+}
+
+const settleReferral = `-- name: SettleReferral :exec
+UPDATE referrals
+SET status          = $1,
+    reason          = $2,
+    order_id        = $3,
+    referrer_reward = $4,
+    referred_reward = $5,
+    settled_at      = CURRENT_TIMESTAMP
+WHERE referred_id = $6
+`
+
+type SettleReferralParams struct {
+	Status         Referralstatus
+	Reason         pgtype.Text
+	OrderID        pgtype.UUID
+	ReferrerReward decimal.Decimal
+	ReferredReward decimal.Decimal
+	ReferredID     uuid.UUID
+}
+
+func (q *Queries) SettleReferral(ctx context.Context, arg SettleReferralParams) error {
+	_, err := q.db.Exec(ctx, settleReferral,
+		arg.Status,
+		arg.Reason,
+		arg.OrderID,
+		arg.ReferrerReward,
+		arg.ReferredReward,
+		arg.ReferredID,
+	)
+	return err
+}
+
+const spendCampaignBudget = `-- name: SpendCampaignBudget :exec
+UPDATE campaigns
+SET spent = spent + $2
+WHERE id = $1
+`
+
+type SpendCampaignBudgetParams struct {
+	ID    uuid.UUID
+	Spent decimal.Decimal
+}
+
+func (q *Queries) SpendCampaignBudget(ctx context.Context, arg SpendCampaignBudgetParams) error {
+	_, err := q.db.Exec(ctx, spendCampaignBudget, arg.ID, arg.Spent)
+	return err
+}
+
+const transferUserBalance = `-- name: TransferUserBalance :exec
+UPDATE user_balances
+SET current    = current - $1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE user_id = $2
+`
+
+type TransferUserBalanceParams struct {
+	Amount decimal.Decimal
+	UserID uuid.UUID
+}
+
+func (q *Queries) TransferUserBalance(ctx context.Context, arg TransferUserBalanceParams) error {
+	_, err := q.db.Exec(ctx, transferUserBalance, arg.Amount, arg.UserID)
+	return err
+}
+
+const updateBalanceHoldStatus = `-- name: UpdateBalanceHoldStatus :one
+UPDATE balance_holds
+SET status     = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, user_id, order_number, amount, status, expires_at, created_at, updated_at
+`
+
+type UpdateBalanceHoldStatusParams struct {
+	ID     uuid.UUID
+	Status Holdstatus
+}
+
+func (q *Queries) UpdateBalanceHoldStatus(ctx context.Context, arg UpdateBalanceHoldStatusParams) (BalanceHold, error) {
+	row := q.db.QueryRow(ctx, updateBalanceHoldStatus, arg.ID, arg.Status)
+	var i BalanceHold
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.OrderNumber,
+		&i.Amount,
+		&i.Status,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateOrderStatus = `-- name: UpdateOrderStatus :one
+UPDATE orders
+SET status       = $1,
+    amount       = $2,
+    processed_at = $3
+WHERE number = $4
+  AND status = $5
+RETURNING id, user_id, amount, number, type, status, processed_at, created_at
+`
+
+type UpdateOrderStatusParams struct {
+	ToStatus    Orderstatus
 	Amount      decimal.Decimal
 	ProcessedAt pgtype.Timestamptz
+	Number      string
+	FromStatus  Orderstatus
 }
 
-func (q *Queries) UpdateOrderByNumber(ctx context.Context, arg UpdateOrderByNumberParams) error {
-	_, err := q.db.Exec(ctx, updateOrderByNumber,
-		arg.Number,
-		arg.Status,
+func (q *Queries) UpdateOrderStatus(ctx context.Context, arg UpdateOrderStatusParams) (Order, error) {
+	row := q.db.QueryRow(ctx, updateOrderStatus,
+		arg.ToStatus,
 		arg.Amount,
 		arg.ProcessedAt,
+		arg.Number,
+		arg.FromStatus,
+	)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Amount,
+		&i.Number,
+		&i.Type,
+		&i.Status,
+		&i.ProcessedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const updatePointLotAllocationAmount = `-- name: UpdatePointLotAllocationAmount :exec
+UPDATE point_lot_allocations
+SET amount = $2
+WHERE id = $1
+`
+
+type UpdatePointLotAllocationAmountParams struct {
+	ID     uuid.UUID
+	Amount decimal.Decimal
+}
+
+func (q *Queries) UpdatePointLotAllocationAmount(ctx context.Context, arg UpdatePointLotAllocationAmountParams) error {
+	_, err := q.db.Exec(ctx, updatePointLotAllocationAmount, arg.ID, arg.Amount)
+	return err
+}
+
+const updatePointLotRemaining = `-- name: UpdatePointLotRemaining :exec
+UPDATE point_lots
+SET remaining = $2
+WHERE id = $1
+`
+
+type UpdatePointLotRemainingParams struct {
+	ID        uuid.UUID
+	Remaining decimal.Decimal
+}
+
+func (q *Queries) UpdatePointLotRemaining(ctx context.Context, arg UpdatePointLotRemainingParams) error {
+	_, err := q.db.Exec(ctx, updatePointLotRemaining, arg.ID, arg.Remaining)
+	return err
+}
+
+const upsertUserTier = `-- name: UpsertUserTier :exec
+INSERT INTO user_tiers (user_id, tier, volume, accrual_multiplier, withdrawal_limit)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id) DO UPDATE
+    SET tier               = EXCLUDED.tier,
+        volume             = EXCLUDED.volume,
+        accrual_multiplier = EXCLUDED.accrual_multiplier,
+        withdrawal_limit   = EXCLUDED.withdrawal_limit,
+        updated_at         = CURRENT_TIMESTAMP
+`
+
+type UpsertUserTierParams struct {
+	UserID            uuid.UUID
+	Tier              string
+	Volume            decimal.Decimal
+	AccrualMultiplier decimal.Decimal
+	WithdrawalLimit   decimal.Decimal
+}
+
+func (q *Queries) UpsertUserTier(ctx context.Context, arg UpsertUserTierParams) error {
+	_, err := q.db.Exec(ctx, upsertUserTier,
+		arg.UserID,
+		arg.Tier,
+		arg.Volume,
+		arg.AccrualMultiplier,
+		arg.WithdrawalLimit,
 	)
 	return err
 }
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// Reasons a referral is rejected with.
+const (
+	ReferralReasonBelowMinAccrual    = "BELOW_MIN_ACCRUAL"
+	ReferralReasonReferrerCapReached = "REFERRER_CAP_REACHED"
+)
+
+// ReferralRewards are the points paid when the first order of a referred user is processed.
+type ReferralRewards struct {
+	ReferrerBonus decimal.Decimal
+	ReferredBonus decimal.Decimal
+	// MaxPerReferrer caps the rewarded referrals of one referrer; zero is unlimited.
+	MaxPerReferrer int64
+	// MinAccrual is the accrual the first order needs to earn the rewards.
+	MinAccrual decimal.Decimal
+}
+
+// CreateReferral records that referredID registered with the referral code of referrerID. It runs
+// in tx, the transaction that creates the referred user.
+func (s *service) CreateReferral(ctx context.Context, tx pgx.Tx, referrerID, referredID uuid.UUID) error {
+	return s.queries.WithTx(tx).CreateReferral(ctx, CreateReferralParams{
+		ReferrerID: referrerID,
+		ReferredID: referredID,
+	})
+}
+
+// GetReferrals returns the user's referral code and the users who registered with it, newest first.
+func (s *service) GetReferrals(ctx context.Context, userID string) (string, []GetReferralsByReferrerIDRow, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	code, err := s.queries.GetReferralCodeByUserID(ctx, id)
+	if err != nil {
+		return "", nil, err
+	}
+
+	referrals, err := s.queries.GetReferralsByReferrerID(ctx, id)
+	if err != nil {
+		return "", nil, err
+	}
+	return code, referrals, nil
+}
+
+// lockPendingReferral locks and returns the referral of the user if it is still waiting for the
+// first processed order, or nil. The caller locks the balance rows of both users afterwards.
+func lockPendingReferral(ctx context.Context, q *Queries, userID uuid.UUID) (*Referral, error) {
+	referral, err := q.LockPendingReferralByReferredID(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &referral, nil
+}
+
+// settleReferral rewards both users of the referral or rejects it. The referrer's rewarded
+// referrals are counted under the referrer's balance lock, so the cap holds when several
+// referred users are processed at once. It returns the points paid to both users.
+func (s *service) settleReferral(ctx context.Context, q *Queries, referral Referral, order Order, accrual decimal.Decimal) (decimal.Decimal, error) {
+	log := logger.FromContext(ctx).With(
+		zap.String("referrerID", referral.ReferrerID.String()), zap.String("referredID", referral.ReferredID.String()))
+	rewards := s.cfg.ReferralRewards
+
+	reason := ""
+	if accrual.LessThan(rewards.MinAccrual) {
+		reason = ReferralReasonBelowMinAccrual
+	} else if rewards.MaxPerReferrer > 0 {
+		rewarded, err := q.CountRewardedReferralsByReferrerID(ctx, referral.ReferrerID)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		if rewarded >= rewards.MaxPerReferrer {
+			reason = ReferralReasonReferrerCapReached
+		}
+	}
+
+	params := SettleReferralParams{
+		Status:     ReferralstatusREJECTED,
+		Reason:     pgtype.Text{String: reason, Valid: reason != ""},
+		OrderID:    pgtype.UUID{Bytes: order.ID, Valid: true},
+		ReferredID: referral.ReferredID,
+	}
+	if reason != "" {
+		log.Info("orderrepository: referral rejected", zap.String("reason", reason))
+		return decimal.Zero, q.SettleReferral(ctx, params)
+	}
+
+	reference := "referral:" + referral.ReferredID.String()
+	err := s.creditReferralReward(ctx, q, referral.ReferrerID, reference, rewards.ReferrerBonus)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	err = s.creditReferralReward(ctx, q, referral.ReferredID, reference, rewards.ReferredBonus)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	params.Status = ReferralstatusREWARDED
+	params.ReferrerReward = rewards.ReferrerBonus
+	params.ReferredReward = rewards.ReferredBonus
+	log.Info("orderrepository: referral rewarded",
+		zap.String("referrerReward", rewards.ReferrerBonus.String()), zap.String("referredReward", rewards.ReferredBonus.String()))
+	err = q.SettleReferral(ctx, params)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return rewards.ReferrerBonus.Add(rewards.ReferredBonus), nil
+}
+
+func (s *service) creditReferralReward(ctx context.Context, q *Queries, userID uuid.UUID, reference string, amount decimal.Decimal) error {
+	if !amount.IsPositive() {
+		return nil
+	}
+
+	err := postLedgerTransaction(ctx, q, AccountReferrals, UserAccount(userID), amount, reference)
+	if err != nil {
+		return err
+	}
+
+	err = q.CreditUserBalance(ctx, CreditUserBalanceParams{
+		UserID: userID,
+		Amount: amount,
+	})
+	if err != nil {
+		return err
+	}
+	return s.createLot(ctx, q, userID, reference, amount)
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// RefundWithdrawal returns amount of a processed withdrawal to the user's balance.
+// The withdrawal row is locked, so concurrent refunds cannot exceed the withdrawn sum together.
+func (s *service) RefundWithdrawal(ctx context.Context, userID, orderNumber string, amount decimal.Decimal) (GetWithdrawalsByUserIDRow, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return GetWithdrawalsByUserIDRow{}, ErrWithdrawalNotFound
+	}
+
+	tx, err := s.pgpool.Begin(ctx)
+	if err != nil {
+		return GetWithdrawalsByUserIDRow{}, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	qtx := s.queries.WithTx(tx)
+	withdrawal, err := qtx.LockWithdrawalByNumber(ctx, LockWithdrawalByNumberParams{
+		Number: orderNumber,
+		UserID: id,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return GetWithdrawalsByUserIDRow{}, ErrWithdrawalNotFound
+	}
+	if err != nil {
+		return GetWithdrawalsByUserIDRow{}, err
+	}
+
+	refunded, err := qtx.GetRefundedAmountByWithdrawalID(ctx, withdrawal.ID)
+	if err != nil {
+		return GetWithdrawalsByUserIDRow{}, err
+	}
+
+	refunded = refunded.Add(amount)
+	if refunded.GreaterThan(withdrawal.Amount) {
+		logger.FromContext(ctx).Debug("orderrepository: refund exceeds withdrawal",
+			zap.String("orderNumber", orderNumber), zap.String("withdrawn", withdrawal.Amount.String()),
+			zap.String("refunded", refunded.String()))
+		return GetWithdrawalsByUserIDRow{}, ErrRefundExceedsWithdrawal
+	}
+
+	err = qtx.CreateWithdrawalRefund(ctx, CreateWithdrawalRefundParams{
+		WithdrawalID: withdrawal.ID,
+		Amount:       amount,
+	})
+	if err != nil {
+		return GetWithdrawalsByUserIDRow{}, err
+	}
+
+	err = postLedgerTransaction(ctx, qtx, AccountWithdrawals, UserAccount(id), amount, orderNumber)
+	if err != nil {
+		return GetWithdrawalsByUserIDRow{}, err
+	}
+
+	err = qtx.EnsureUserBalance(ctx, id)
+	if err != nil {
+		return GetWithdrawalsByUserIDRow{}, err
+	}
+
+	_, err = qtx.LockUserBalance(ctx, id)
+	if err != nil {
+		return GetWithdrawalsByUserIDRow{}, err
+	}
+
+	err = s.restoreLots(ctx, qtx, id, orderNumber, amount)
+	if err != nil {
+		return GetWithdrawalsByUserIDRow{}, err
+	}
+
+	err = qtx.RefundUserBalance(ctx, RefundUserBalanceParams{
+		Amount: amount,
+		UserID: id,
+	})
+	if err != nil {
+		return GetWithdrawalsByUserIDRow{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return GetWithdrawalsByUserIDRow{}, err
+	}
+
+	return GetWithdrawalsByUserIDRow{
+		Order:    withdrawal,
+		Refunded: refunded,
+	}, nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRefundWithdrawal needs a migrated database in TEST_DATABASE_URI.
+func TestRefundWithdrawal(t *testing.T) {
+	repo, pool := newTestRepository(t)
+	ctx := context.Background()
+
+	t.Run("refunds up to the withdrawn sum", func(t *testing.T) {
+		userID, _ := createTestUser(t, pool, 100)
+		number := newTestNumber()
+		_, err := repo.CreateWithdrawalOrder(ctx, userID.String(), number, decimal.NewFromInt(40))
+		require.NoError(t, err)
+		assertBalance(t, pool, userID, 60, 0, 40)
+
+		refund, err := repo.RefundWithdrawal(ctx, userID.String(), number, decimal.NewFromInt(30))
+		require.NoError(t, err)
+		assert.True(t, refund.Refunded.Equal(decimal.NewFromInt(30)), "refunded is %s", refund.Refunded)
+		assertBalance(t, pool, userID, 90, 0, 10)
+
+		_, err = repo.RefundWithdrawal(ctx, userID.String(), number, decimal.NewFromInt(11))
+		assert.ErrorIs(t, err, ErrRefundExceedsWithdrawal)
+		assertBalance(t, pool, userID, 90, 0, 10)
+
+		refund, err = repo.RefundWithdrawal(ctx, userID.String(), number, decimal.NewFromInt(10))
+		require.NoError(t, err)
+		assert.True(t, refund.Refunded.Equal(decimal.NewFromInt(40)), "refunded is %s", refund.Refunded)
+		assertBalance(t, pool, userID, 100, 0, 0)
+
+		_, err = repo.RefundWithdrawal(ctx, userID.String(), number, decimal.NewFromInt(1))
+		assert.ErrorIs(t, err, ErrRefundExceedsWithdrawal)
+	})
+
+	t.Run("concurrent refunds do not exceed the withdrawn sum", func(t *testing.T) {
+		const (
+			withdrawn = 40
+			refunds   = 20
+		)
+
+		userID, _ := createTestUser(t, pool, 100)
+		number := newTestNumber()
+		_, err := repo.CreateWithdrawalOrder(ctx, userID.String(), number, decimal.NewFromInt(withdrawn))
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		errs := make(chan error, refunds)
+		for i := 0; i < refunds; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				_, err := repo.RefundWithdrawal(ctx, userID.String(), number, decimal.NewFromInt(10))
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		succeeded := 0
+		for err := range errs {
+			if err == nil {
+				succeeded++
+				continue
+			}
+			assert.True(t, errors.Is(err, ErrRefundExceedsWithdrawal), "unexpected error: %v", err)
+		}
+		assert.Equal(t, withdrawn/10, succeeded)
+		assertBalance(t, pool, userID, 100, 0, 0)
+	})
+
+	t.Run("withdrawal of another user is not found", func(t *testing.T) {
+		userID, _ := createTestUser(t, pool, 100)
+		otherID, _ := createTestUser(t, pool, 0)
+		number := newTestNumber()
+		_, err := repo.CreateWithdrawalOrder(ctx, userID.String(), number, decimal.NewFromInt(40))
+		require.NoError(t, err)
+
+		_, err = repo.RefundWithdrawal(ctx, otherID.String(), number, decimal.NewFromInt(10))
+		assert.ErrorIs(t, err, ErrWithdrawalNotFound)
+		assertBalance(t, pool, userID, 60, 0, 40)
+	})
+}
@@ -3,75 +3,226 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
 	"github.com/google/uuid"
 	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
 )
 
 type Repository interface {
-	GetOrderByNumber(number string) (Order, error)
-	GetNewTopUpOrder() (Order, error)
-	UpdateOrderStatusByNumber(number string, status Orderstatus, amount *decimal.Decimal) error
-	GetOrdersByUserID(userID string) ([]Order, error)
-	CreateTopUpOrder(userID, orderNumber string) (Order, bool, error)
-	CreateWithdrawalOrder(userID, orderNumber string, amount decimal.Decimal) (Order, error)
-	GetWithdrawalsByUserID(userID string) ([]Order, error)
-	GetUserBalanceByUserID(userID string) (decimal.Decimal, error)
-	GetUserWithdrawByUserID(userID string) (decimal.Decimal, error)
+	GetOrderByNumber(ctx context.Context, number string) (Order, error)
+	UpdateOrderStatus(ctx context.Context, number string, from, to Orderstatus, amount *decimal.Decimal, bonuses OrderBonuses) (OrderCredits, error)
+	GetOrdersByUserID(ctx context.Context, userID string) ([]Order, error)
+	CreateTopUpOrder(ctx context.Context, userID, orderNumber string) (Order, bool, error)
+	CreateWithdrawalOrder(ctx context.Context, userID, orderNumber string, amount decimal.Decimal) (Order, error)
+	GetWithdrawalsByUserID(ctx context.Context, userID string) ([]GetWithdrawalsByUserIDRow, error)
+	RefundWithdrawal(ctx context.Context, userID, orderNumber string, amount decimal.Decimal) (GetWithdrawalsByUserIDRow, error)
+	GetUserBalanceByUserID(ctx context.Context, userID string) (UserBalance, error)
+	CreateTransfer(ctx context.Context, senderID, recipientLogin string, amount, dailyLimit decimal.Decimal) (GetTransfersByUserIDRow, error)
+	GetTransfers(ctx context.Context, userID string) ([]GetTransfersByUserIDRow, error)
+	CreateReferral(ctx context.Context, tx pgx.Tx, referrerID, referredID uuid.UUID) error
+	GetReferrals(ctx context.Context, userID string) (string, []GetReferralsByReferrerIDRow, error)
+	CreateGiftCodeBatch(ctx context.Context, batch CreateGiftCodeBatchParams, codes []string) (GiftCodeBatch, error)
+	GetGiftCodeBatch(ctx context.Context, batchID string) (GiftCodeBatch, []GiftCode, error)
+	RedeemGiftCode(ctx context.Context, userID, code string) (GiftCodeRedemption, error)
+	CreateHold(ctx context.Context, userID, orderNumber string, amount decimal.Decimal, expiresAt time.Time) (BalanceHold, error)
+	CaptureHold(ctx context.Context, userID, holdID string) (BalanceHold, Order, error)
+	VoidHold(ctx context.Context, userID, holdID string) (BalanceHold, error)
+	ExpireHolds(ctx context.Context, limit int32) (int, error)
+	ExpirePoints(ctx context.Context, limit int32) (int, decimal.Decimal, error)
+	GetPointsExpiry(ctx context.Context, userID string) (GetPointsExpiryByUserIDRow, error)
+	GetActiveCampaigns(ctx context.Context, userID string, at time.Time) ([]GetActiveCampaignsByUserIDRow, error)
+	CountProcessedOrders(ctx context.Context, userID string, excludeOrderID uuid.UUID) (int64, error)
+	GetUserTier(ctx context.Context, userID string) (UserTier, error)
+	SetUserTier(ctx context.Context, tier UserTier) (bool, error)
+	GetAccrualVolume(ctx context.Context, userID string, since time.Time) (decimal.Decimal, error)
+	GetAccrualVolumes(ctx context.Context, since time.Time, afterUserID uuid.UUID, limit int32) ([]GetAccrualVolumesRow, error)
+	ClaimAccrualJob(ctx context.Context, workerID string, lease time.Duration) (AccrualJob, error)
+	CompleteAccrualJob(ctx context.Context, jobID uuid.UUID, workerID string) error
+	RescheduleAccrualJob(ctx context.Context, jobID uuid.UUID, workerID string, delay time.Duration, lastError string) error
+	ReleaseAccrualJob(ctx context.Context, jobID uuid.UUID, workerID string) error
+}
+
+// OrderCredits are the points UpdateOrderStatus credited, by where they came from.
+type OrderCredits struct {
+	Accrual  decimal.Decimal
+	Campaign decimal.Decimal
+	Tier     decimal.Decimal
+	// Referral is the total paid to both users of a rewarded referral.
+	Referral decimal.Decimal
+}
+
+type Config struct {
+	// PointsTTL is how long accrued points can be spent. Zero means they never expire.
+	PointsTTL time.Duration
+	// ExpiringSoonWindow is how far ahead GetPointsExpiry looks for expiring points.
+	ExpiringSoonWindow time.Duration
+	ReferralRewards    ReferralRewards
 }
 
 type service struct {
-	ctx     context.Context
 	queries *Queries
 	pgpool  *pgxpool.Pool
+	cfg     Config
 }
 
-func NewRepository(ctx context.Context, pgpool *pgxpool.Pool) Repository {
+func NewRepository(pgpool *pgxpool.Pool, cfg Config) Repository {
 	return &service{
-		ctx:     ctx,
 		queries: New(pgpool),
 		pgpool:  pgpool,
+		cfg:     cfg,
 	}
 }
 
-func (s *service) GetOrderByNumber(number string) (Order, error) {
-	return s.queries.GetOrderByNumber(s.ctx, number)
+func (s *service) GetOrderByNumber(ctx context.Context, number string) (Order, error) {
+	return s.queries.GetOrderByNumber(ctx, number)
 }
 
-func (s *service) GetOrdersByUserID(userID string) ([]Order, error) {
+func (s *service) GetOrdersByUserID(ctx context.Context, userID string) ([]Order, error) {
 	id, err := uuid.Parse(userID)
 	if err != nil {
 		return nil, err
 	}
-	return s.queries.GetTopUpOrdersByUserID(s.ctx, id)
-}
-
-func (s *service) GetNewTopUpOrder() (Order, error) {
-	return s.queries.GetNewTopUpOrder(s.ctx)
+	return s.queries.GetTopUpOrdersByUserID(ctx, id)
 }
 
-func (s *service) UpdateOrderStatusByNumber(number string, status Orderstatus, amount *decimal.Decimal) error {
+// UpdateOrderStatus moves the order from one status to another and records the transition.
+// A processed order is credited with its accrual and the campaign and tier bonuses on top of it,
+// and the first processed order of a referred user settles the referral.
+// It returns ErrOrderStatusConflict if the order is no longer in the expected status.
+func (s *service) UpdateOrderStatus(ctx context.Context, number string, from, to Orderstatus, amount *decimal.Decimal, bonuses OrderBonuses) (OrderCredits, error) {
 	var amountValue decimal.Decimal
 	if amount != nil {
 		amountValue = *amount
 	}
-	return s.queries.UpdateOrderByNumber(s.ctx, UpdateOrderByNumberParams{
-		Number: number,
-		Status: status,
-		Amount: amountValue,
+
+	var processedAt pgtype.Timestamptz
+	if to == OrderstatusPROCESSED || to == OrderstatusINVALID {
+		processedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	}
+
+	tx, err := s.pgpool.Begin(ctx)
+	if err != nil {
+		return OrderCredits{}, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	qtx := s.queries.WithTx(tx)
+	order, err := qtx.UpdateOrderStatus(ctx, UpdateOrderStatusParams{
+		ToStatus:    to,
+		Amount:      amountValue,
+		ProcessedAt: processedAt,
+		Number:      number,
+		FromStatus:  from,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		logger.FromContext(ctx).Debug("orderrepository: order status changed concurrently",
+			zap.String("orderNumber", number), zap.String("from", string(from)), zap.String("to", string(to)))
+		return OrderCredits{}, ErrOrderStatusConflict
+	}
+	if err != nil {
+		return OrderCredits{}, err
+	}
+
+	err = qtx.CreateOrderStatusTransition(ctx, CreateOrderStatusTransitionParams{
+		OrderID:    order.ID,
+		FromStatus: NullOrderstatus{Orderstatus: from, Valid: true},
+		ToStatus:   to,
 	})
+	if err != nil {
+		return OrderCredits{}, err
+	}
+
+	var credits OrderCredits
+	var referral *Referral
+	if to == OrderstatusPROCESSED {
+		referral, err = lockPendingReferral(ctx, qtx, order.UserID)
+		if err != nil {
+			return OrderCredits{}, err
+		}
+
+		// Every balance the order credits is locked up front, before the campaign rows.
+		userIDs := []uuid.UUID{order.UserID}
+		if referral != nil {
+			userIDs = append(userIDs, referral.ReferrerID)
+		}
+		err = lockUserBalances(ctx, qtx, userIDs...)
+		if err != nil {
+			return OrderCredits{}, err
+		}
+	}
+
+	if to == OrderstatusPROCESSED && amountValue.IsPositive() {
+		err = postLedgerTransaction(ctx, qtx, AccountAccrual, UserAccount(order.UserID), amountValue, order.Number)
+		if err != nil {
+			return OrderCredits{}, err
+		}
+
+		campaignBonus, err := applyCampaignBonuses(ctx, qtx, order, bonuses.Campaigns)
+		if err != nil {
+			return OrderCredits{}, err
+		}
+		tierBonus, err := applyTierBonus(ctx, qtx, order, bonuses.Tier)
+		if err != nil {
+			return OrderCredits{}, err
+		}
+		credits.Accrual = amountValue
+		credits.Campaign = campaignBonus
+		credits.Tier = tierBonus
+		credited := amountValue.Add(campaignBonus).Add(tierBonus)
+
+		err = qtx.CreditUserBalance(ctx, CreditUserBalanceParams{
+			UserID: order.UserID,
+			Amount: credited,
+		})
+		if err != nil {
+			return OrderCredits{}, err
+		}
+
+		err = s.createLot(ctx, qtx, order.UserID, order.Number, credited)
+		if err != nil {
+			return OrderCredits{}, err
+		}
+	}
+
+	if referral != nil {
+		credits.Referral, err = s.settleReferral(ctx, qtx, *referral, order, amountValue)
+		if err != nil {
+			return OrderCredits{}, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return OrderCredits{}, err
+	}
+	return credits, nil
 }
 
-func (s *service) CreateTopUpOrder(userID, orderNumber string) (Order, bool, error) {
+func (s *service) CreateTopUpOrder(ctx context.Context, userID, orderNumber string) (Order, bool, error) {
 	id, err := uuid.Parse(userID)
 	if err != nil {
 		return Order{}, false, err
 	}
 
-	newOrder, err := s.queries.CreateTopUpOrder(s.ctx, CreateTopUpOrderParams{
+	tx, err := s.pgpool.Begin(ctx)
+	if err != nil {
+		return Order{}, false, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	qtx := s.queries.WithTx(tx)
+	newOrder, err := qtx.CreateTopUpOrder(ctx, CreateTopUpOrderParams{
 		UserID: id,
 		Number: orderNumber,
 		Amount: decimal.NewFromInt(0),
@@ -79,7 +230,8 @@ func (s *service) CreateTopUpOrder(userID, orderNumber string) (Order, bool, err
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
-			existingOrder, err := s.queries.GetOrderByNumber(s.ctx, orderNumber)
+			logger.FromContext(ctx).Debug("orderrepository: order already exists", zap.String("orderNumber", orderNumber))
+			existingOrder, err := s.queries.GetOrderByNumber(ctx, orderNumber)
 			if err != nil {
 				return Order{}, false, err
 			}
@@ -87,32 +239,65 @@ func (s *service) CreateTopUpOrder(userID, orderNumber string) (Order, bool, err
 		}
 		return Order{}, false, err
 	}
+
+	err = qtx.CreateOrderStatusTransition(ctx, CreateOrderStatusTransitionParams{
+		OrderID:  newOrder.ID,
+		ToStatus: newOrder.Status,
+	})
+	if err != nil {
+		return Order{}, false, err
+	}
+
+	err = qtx.CreateAccrualJob(ctx, CreateAccrualJobParams{
+		OrderID:     newOrder.ID,
+		OrderNumber: newOrder.Number,
+	})
+	if err != nil {
+		return Order{}, false, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return Order{}, false, err
+	}
 	return newOrder, true, nil
 }
 
-func (s *service) CreateWithdrawalOrder(userID, orderNumber string, amount decimal.Decimal) (Order, error) {
+// CreateWithdrawalOrder stores the withdrawal, posts it to the ledger and updates
+// the materialized balance in one transaction. The balance row is locked before
+// the funds check, so concurrent withdrawals of the same user are serialized.
+func (s *service) CreateWithdrawalOrder(ctx context.Context, userID, orderNumber string, amount decimal.Decimal) (Order, error) {
 	id, err := uuid.Parse(userID)
 	if err != nil {
 		return Order{}, err
 	}
 
-	tx, err := s.pgpool.Begin(s.ctx)
+	tx, err := s.pgpool.Begin(ctx)
 	if err != nil {
 		return Order{}, err
 	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
 
 	qtx := s.queries.WithTx(tx)
-	balance, err := qtx.GetUserBalanceByUserID(s.ctx, id)
+	balance, err := lockSpendableBalance(ctx, qtx, id)
 	if err != nil {
 		return Order{}, err
 	}
 
-	if balance.LessThan(amount) {
+	if balance.Current.LessThan(amount) {
+		logger.FromContext(ctx).Debug("orderrepository: balance is lower than withdrawal",
+			zap.String("balance", balance.Current.String()), zap.String("sum", amount.String()))
 		return Order{}, ErrWithdrawInsufficientFunds
 	}
 
-	newWithdraw, err := s.queries.Withdrawal(
-		s.ctx,
+	err = checkWithdrawalLimit(ctx, qtx, id, amount)
+	if err != nil {
+		return Order{}, err
+	}
+
+	newWithdraw, err := qtx.Withdrawal(
+		ctx,
 		WithdrawalParams{
 			id,
 			orderNumber,
@@ -127,33 +312,82 @@ func (s *service) CreateWithdrawalOrder(userID, orderNumber string, amount decim
 		return Order{}, err
 	}
 
-	if err = tx.Commit(s.ctx); err != nil {
+	err = postLedgerTransaction(ctx, qtx, UserAccount(id), AccountWithdrawals, amount, orderNumber)
+	if err != nil {
 		return Order{}, err
 	}
 
-	return newWithdraw, err
+	err = qtx.DebitUserBalance(ctx, DebitUserBalanceParams{
+		Amount: amount,
+		UserID: id,
+	})
+	if err != nil {
+		return Order{}, err
+	}
+
+	_, err = consumeLots(ctx, qtx, id, orderNumber, amount)
+	if err != nil {
+		return Order{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return Order{}, err
+	}
+
+	return newWithdraw, nil
 }
 
-func (s *service) GetWithdrawalsByUserID(userID string) ([]Order, error) {
+func (s *service) GetWithdrawalsByUserID(ctx context.Context, userID string) ([]GetWithdrawalsByUserIDRow, error) {
 	id, err := uuid.Parse(userID)
 	if err != nil {
 		return nil, err
 	}
-	return s.queries.GetWithdrawalsByUserID(s.ctx, id)
+	return s.queries.GetWithdrawalsByUserID(ctx, id)
 }
 
-func (s *service) GetUserBalanceByUserID(userID string) (decimal.Decimal, error) {
+func (s *service) GetUserBalanceByUserID(ctx context.Context, userID string) (UserBalance, error) {
 	id, err := uuid.Parse(userID)
 	if err != nil {
-		return decimal.Decimal{}, err
+		return UserBalance{}, err
 	}
-	return s.queries.GetUserBalanceByUserID(s.ctx, id)
+	return getUserBalance(ctx, s.queries, id)
 }
 
-func (s *service) GetUserWithdrawByUserID(userID string) (decimal.Decimal, error) {
-	id, err := uuid.Parse(userID)
-	if err != nil {
-		return decimal.Decimal{}, err
+// getUserBalance returns a zero balance for users that have not been credited yet.
+func getUserBalance(ctx context.Context, q *Queries, userID uuid.UUID) (UserBalance, error) {
+	balance, err := q.GetUserBalanceByUserID(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return UserBalance{UserID: userID}, nil
 	}
-	return s.queries.GetUserWithdrawByUserID(s.ctx, id)
+	return balance, err
+}
+
+func (s *service) ClaimAccrualJob(ctx context.Context, workerID string, lease time.Duration) (AccrualJob, error) {
+	return s.queries.ClaimAccrualJob(ctx, ClaimAccrualJobParams{
+		LockedBy:     pgtype.Text{String: workerID, Valid: true},
+		LeaseSeconds: lease.Seconds(),
+	})
+}
+
+func (s *service) CompleteAccrualJob(ctx context.Context, jobID uuid.UUID, workerID string) error {
+	return s.queries.CompleteAccrualJob(ctx, CompleteAccrualJobParams{
+		ID:       jobID,
+		LockedBy: pgtype.Text{String: workerID, Valid: true},
+	})
+}
+
+func (s *service) RescheduleAccrualJob(ctx context.Context, jobID uuid.UUID, workerID string, delay time.Duration, lastError string) error {
+	return s.queries.RescheduleAccrualJob(ctx, RescheduleAccrualJobParams{
+		DelaySeconds: delay.Seconds(),
+		LastError:    pgtype.Text{String: lastError, Valid: lastError != ""},
+		ID:           jobID,
+		LockedBy:     pgtype.Text{String: workerID, Valid: true},
+	})
+}
+
+func (s *service) ReleaseAccrualJob(ctx context.Context, jobID uuid.UUID, workerID string) error {
+	return s.queries.ReleaseAccrualJob(ctx, ReleaseAccrualJobParams{
+		ID:       jobID,
+		LockedBy: pgtype.Text{String: workerID, Valid: true},
+	})
 }
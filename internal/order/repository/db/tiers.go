@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// withdrawalLimitWindow is the period a tier withdrawal limit applies to.
+const withdrawalLimitWindow = 24 * time.Hour
+
+// TierBonus is the points a tier multiplier adds on top of an order's accrual.
+type TierBonus struct {
+	Tier   string
+	Amount decimal.Decimal
+}
+
+// GetUserTier returns the tier last assigned to the user.
+// Users the recalculation job has not seen yet get a tier with an empty name.
+func (s *service) GetUserTier(ctx context.Context, userID string) (UserTier, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return UserTier{}, err
+	}
+
+	tier, err := s.queries.GetUserTierByUserID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return UserTier{UserID: id}, nil
+	}
+	return tier, err
+}
+
+// SetUserTier stores the user's tier and its benefits. A change of the tier name is
+// recorded in the tier history, and only then true is returned.
+func (s *service) SetUserTier(ctx context.Context, tier UserTier) (bool, error) {
+	tx, err := s.pgpool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	qtx := s.queries.WithTx(tx)
+	var fromTier pgtype.Text
+	current, err := qtx.LockUserTier(ctx, tier.UserID)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+	case err != nil:
+		return false, err
+	default:
+		fromTier = pgtype.Text{String: current.Tier, Valid: true}
+	}
+
+	err = qtx.UpsertUserTier(ctx, UpsertUserTierParams{
+		UserID:            tier.UserID,
+		Tier:              tier.Tier,
+		Volume:            tier.Volume,
+		AccrualMultiplier: tier.AccrualMultiplier,
+		WithdrawalLimit:   tier.WithdrawalLimit,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	changed := !fromTier.Valid || fromTier.String != tier.Tier
+	if changed {
+		err = qtx.CreateUserTierHistory(ctx, CreateUserTierHistoryParams{
+			UserID:   tier.UserID,
+			FromTier: fromTier,
+			ToTier:   tier.Tier,
+			Volume:   tier.Volume,
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return false, err
+	}
+	return changed, nil
+}
+
+// GetAccrualVolume returns the points accrued to the user by orders processed since the given time.
+func (s *service) GetAccrualVolume(ctx context.Context, userID string, since time.Time) (decimal.Decimal, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return s.queries.GetAccrualVolumeByUserID(ctx, GetAccrualVolumeByUserIDParams{
+		UserID: id,
+		Since:  pgtype.Timestamptz{Time: since, Valid: true},
+	})
+}
+
+// GetAccrualVolumes returns the accrual volume since the given time of up to limit users
+// that have uploaded orders, ordered by user id and starting after afterUserID.
+func (s *service) GetAccrualVolumes(ctx context.Context, since time.Time, afterUserID uuid.UUID, limit int32) ([]GetAccrualVolumesRow, error) {
+	return s.queries.GetAccrualVolumes(ctx, GetAccrualVolumesParams{
+		Since:       pgtype.Timestamptz{Time: since, Valid: true},
+		AfterUserID: afterUserID,
+		LimitCount:  limit,
+	})
+}
+
+func applyTierBonus(ctx context.Context, q *Queries, order Order, bonus *TierBonus) (decimal.Decimal, error) {
+	if bonus == nil || !bonus.Amount.IsPositive() {
+		return decimal.Zero, nil
+	}
+
+	err := q.CreateOrderTierBonus(ctx, CreateOrderTierBonusParams{
+		OrderID: order.ID,
+		Tier:    bonus.Tier,
+		Amount:  bonus.Amount,
+	})
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	err = postLedgerTransaction(ctx, q, AccountTiers, UserAccount(order.UserID), bonus.Amount, order.Number)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return bonus.Amount, nil
+}
+
+// checkWithdrawalLimit returns ErrWithdrawLimitExceeded if withdrawing amount takes the user
+// over the limit of their tier. It must run after the user's balance row is locked.
+func checkWithdrawalLimit(ctx context.Context, q *Queries, userID uuid.UUID, amount decimal.Decimal) error {
+	tier, err := q.GetUserTierByUserID(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !tier.WithdrawalLimit.IsPositive() {
+		return nil
+	}
+
+	withdrawn, err := q.GetWithdrawnSinceByUserID(ctx, GetWithdrawnSinceByUserIDParams{
+		UserID: userID,
+		Since:  pgtype.Timestamptz{Time: time.Now().Add(-withdrawalLimitWindow), Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+
+	if withdrawn.Add(amount).GreaterThan(tier.WithdrawalLimit) {
+		logger.FromContext(ctx).Debug("orderrepository: withdrawal limit exceeded",
+			zap.String("tier", tier.Tier), zap.String("limit", tier.WithdrawalLimit.String()),
+			zap.String("withdrawn", withdrawn.String()), zap.String("sum", amount.String()))
+		return ErrWithdrawLimitExceeded
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// transferLimitWindow is the period the daily transfer limit applies to.
+const transferLimitWindow = 24 * time.Hour
+
+// CreateTransfer moves amount from the sender to the user with the recipient login in one
+// transaction and returns it as the sender sees it. Both balance rows are locked in user id
+// order, so transfers in opposite directions cannot deadlock. A dailyLimit of zero is unlimited.
+func (s *service) CreateTransfer(ctx context.Context, senderID, recipientLogin string, amount, dailyLimit decimal.Decimal) (GetTransfersByUserIDRow, error) {
+	sender, err := uuid.Parse(senderID)
+	if err != nil {
+		return GetTransfersByUserIDRow{}, err
+	}
+
+	tx, err := s.pgpool.Begin(ctx)
+	if err != nil {
+		return GetTransfersByUserIDRow{}, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	qtx := s.queries.WithTx(tx)
+	recipient, err := qtx.GetUserIDByUsername(ctx, recipientLogin)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return GetTransfersByUserIDRow{}, ErrRecipientNotFound
+	}
+	if err != nil {
+		return GetTransfersByUserIDRow{}, err
+	}
+	if recipient == sender {
+		return GetTransfersByUserIDRow{}, ErrTransferToSelf
+	}
+
+	err = lockUserBalances(ctx, qtx, sender, recipient)
+	if err != nil {
+		return GetTransfersByUserIDRow{}, err
+	}
+
+	balance, err := lockSpendableBalance(ctx, qtx, sender)
+	if err != nil {
+		return GetTransfersByUserIDRow{}, err
+	}
+	if balance.Current.LessThan(amount) {
+		logger.FromContext(ctx).Debug("orderrepository: balance is lower than transfer",
+			zap.String("balance", balance.Current.String()), zap.String("sum", amount.String()))
+		return GetTransfersByUserIDRow{}, ErrWithdrawInsufficientFunds
+	}
+
+	if dailyLimit.IsPositive() {
+		transferred, err := qtx.GetTransferredSinceByUserID(ctx, GetTransferredSinceByUserIDParams{
+			UserID: sender,
+			Since:  pgtype.Timestamptz{Time: time.Now().Add(-transferLimitWindow), Valid: true},
+		})
+		if err != nil {
+			return GetTransfersByUserIDRow{}, err
+		}
+		if transferred.Add(amount).GreaterThan(dailyLimit) {
+			logger.FromContext(ctx).Debug("orderrepository: transfer limit exceeded",
+				zap.String("limit", dailyLimit.String()), zap.String("transferred", transferred.String()), zap.String("sum", amount.String()))
+			return GetTransfersByUserIDRow{}, ErrTransferLimitExceeded
+		}
+	}
+
+	transfer, err := qtx.CreateTransfer(ctx, CreateTransferParams{
+		SenderID:    sender,
+		RecipientID: recipient,
+		Amount:      amount,
+	})
+	if err != nil {
+		return GetTransfersByUserIDRow{}, err
+	}
+
+	reference := "transfer:" + transfer.ID.String()
+	err = postLedgerTransaction(ctx, qtx, UserAccount(sender), UserAccount(recipient), amount, reference)
+	if err != nil {
+		return GetTransfersByUserIDRow{}, err
+	}
+
+	err = qtx.TransferUserBalance(ctx, TransferUserBalanceParams{
+		Amount: amount,
+		UserID: sender,
+	})
+	if err != nil {
+		return GetTransfersByUserIDRow{}, err
+	}
+
+	err = qtx.CreditUserBalance(ctx, CreditUserBalanceParams{
+		UserID: recipient,
+		Amount: amount,
+	})
+	if err != nil {
+		return GetTransfersByUserIDRow{}, err
+	}
+
+	err = s.moveLots(ctx, qtx, sender, recipient, reference, amount)
+	if err != nil {
+		return GetTransfersByUserIDRow{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return GetTransfersByUserIDRow{}, err
+	}
+
+	return GetTransfersByUserIDRow{
+		ID:           transfer.ID,
+		Amount:       transfer.Amount,
+		CreatedAt:    transfer.CreatedAt,
+		Outgoing:     true,
+		Counterparty: recipientLogin,
+	}, nil
+}
+
+// GetTransfers returns the transfers the user sent and received, newest first.
+func (s *service) GetTransfers(ctx context.Context, userID string) ([]GetTransfersByUserIDRow, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.queries.GetTransfersByUserID(ctx, id)
+}
+
+// moveLots gives the recipient new lots with the expiry of the sender's lots the points came
+// from, so passing points around does not extend their life.
+func (s *service) moveLots(ctx context.Context, q *Queries, sender, recipient uuid.UUID, reference string, amount decimal.Decimal) error {
+	takes, err := consumeLots(ctx, q, sender, reference, amount)
+	if err != nil {
+		return err
+	}
+
+	left := amount
+	for _, take := range takes {
+		err = createLotExpiringAt(ctx, q, recipient, reference, take.Amount, take.ExpiresAt)
+		if err != nil {
+			return err
+		}
+		left = left.Sub(take.Amount)
+	}
+
+	if left.IsPositive() {
+		return s.createLot(ctx, q, recipient, reference, left)
+	}
+	return nil
+}
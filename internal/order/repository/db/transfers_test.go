@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCreateTransfer needs a migrated database in TEST_DATABASE_URI.
+func TestCreateTransfer(t *testing.T) {
+	repo, pool := newTestRepository(t)
+	ctx := context.Background()
+	unlimited := decimal.Zero
+
+	t.Run("moves points with their lots", func(t *testing.T) {
+		senderID, _ := createTestUser(t, pool, 100)
+		recipientID, recipientLogin := createTestUser(t, pool, 0)
+
+		transfer, err := repo.CreateTransfer(ctx, senderID.String(), recipientLogin, decimal.NewFromInt(35), unlimited)
+		require.NoError(t, err)
+		assert.True(t, transfer.Outgoing)
+		assert.Equal(t, recipientLogin, transfer.Counterparty)
+		assertBalance(t, pool, senderID, 65, 0, 0)
+		assertBalance(t, pool, recipientID, 35, 0, 0)
+	})
+
+	t.Run("rejects transfers over the balance", func(t *testing.T) {
+		senderID, _ := createTestUser(t, pool, 100)
+		recipientID, recipientLogin := createTestUser(t, pool, 0)
+
+		_, err := repo.CreateTransfer(ctx, senderID.String(), recipientLogin, decimal.NewFromInt(101), unlimited)
+		assert.ErrorIs(t, err, ErrWithdrawInsufficientFunds)
+		assertBalance(t, pool, senderID, 100, 0, 0)
+		assertBalance(t, pool, recipientID, 0, 0, 0)
+	})
+
+	t.Run("enforces the daily limit", func(t *testing.T) {
+		senderID, _ := createTestUser(t, pool, 100)
+		recipientID, recipientLogin := createTestUser(t, pool, 0)
+		limit := decimal.NewFromInt(50)
+
+		_, err := repo.CreateTransfer(ctx, senderID.String(), recipientLogin, decimal.NewFromInt(30), limit)
+		require.NoError(t, err)
+		_, err = repo.CreateTransfer(ctx, senderID.String(), recipientLogin, decimal.NewFromInt(30), limit)
+		assert.ErrorIs(t, err, ErrTransferLimitExceeded)
+		_, err = repo.CreateTransfer(ctx, senderID.String(), recipientLogin, decimal.NewFromInt(20), limit)
+		require.NoError(t, err)
+
+		assertBalance(t, pool, senderID, 50, 0, 0)
+		assertBalance(t, pool, recipientID, 50, 0, 0)
+	})
+
+	t.Run("concurrent transfers stay within the daily limit", func(t *testing.T) {
+		const (
+			limit     = 50
+			transfers = 20
+		)
+
+		senderID, _ := createTestUser(t, pool, 100)
+		recipientID, recipientLogin := createTestUser(t, pool, 0)
+
+		var wg sync.WaitGroup
+		errs := make(chan error, transfers)
+		for i := 0; i < transfers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				_, err := repo.CreateTransfer(ctx, senderID.String(), recipientLogin, decimal.NewFromInt(10), decimal.NewFromInt(limit))
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		succeeded := 0
+		for err := range errs {
+			if err == nil {
+				succeeded++
+				continue
+			}
+			assert.True(t, errors.Is(err, ErrTransferLimitExceeded), "unexpected error: %v", err)
+		}
+		assert.Equal(t, limit/10, succeeded)
+		assertBalance(t, pool, senderID, 100-limit, 0, 0)
+		assertBalance(t, pool, recipientID, limit, 0, 0)
+	})
+
+	t.Run("rejects unknown recipient and self transfer", func(t *testing.T) {
+		senderID, senderLogin := createTestUser(t, pool, 100)
+
+		_, err := repo.CreateTransfer(ctx, senderID.String(), "unknown-"+senderLogin, decimal.NewFromInt(10), unlimited)
+		assert.ErrorIs(t, err, ErrRecipientNotFound)
+		_, err = repo.CreateTransfer(ctx, senderID.String(), senderLogin, decimal.NewFromInt(10), unlimited)
+		assert.ErrorIs(t, err, ErrTransferToSelf)
+		assertBalance(t, pool, senderID, 100, 0, 0)
+	})
+}
@@ -10,9 +10,13 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
+	time "time"
 
 	repository "github.com/aifedorov/gophermart/internal/order/repository/db"
+	uuid "github.com/google/uuid"
+	pgx "github.com/jackc/pgx/v5"
 	decimal "github.com/shopspring/decimal"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -41,10 +45,114 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// CaptureHold mocks base method.
+func (m *MockRepository) CaptureHold(ctx context.Context, userID, holdID string) (repository.BalanceHold, repository.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CaptureHold", ctx, userID, holdID)
+	ret0, _ := ret[0].(repository.BalanceHold)
+	ret1, _ := ret[1].(repository.Order)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CaptureHold indicates an expected call of CaptureHold.
+func (mr *MockRepositoryMockRecorder) CaptureHold(ctx, userID, holdID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CaptureHold", reflect.TypeOf((*MockRepository)(nil).CaptureHold), ctx, userID, holdID)
+}
+
+// ClaimAccrualJob mocks base method.
+func (m *MockRepository) ClaimAccrualJob(ctx context.Context, workerID string, lease time.Duration) (repository.AccrualJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimAccrualJob", ctx, workerID, lease)
+	ret0, _ := ret[0].(repository.AccrualJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimAccrualJob indicates an expected call of ClaimAccrualJob.
+func (mr *MockRepositoryMockRecorder) ClaimAccrualJob(ctx, workerID, lease any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimAccrualJob", reflect.TypeOf((*MockRepository)(nil).ClaimAccrualJob), ctx, workerID, lease)
+}
+
+// CompleteAccrualJob mocks base method.
+func (m *MockRepository) CompleteAccrualJob(ctx context.Context, jobID uuid.UUID, workerID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteAccrualJob", ctx, jobID, workerID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteAccrualJob indicates an expected call of CompleteAccrualJob.
+func (mr *MockRepositoryMockRecorder) CompleteAccrualJob(ctx, jobID, workerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteAccrualJob", reflect.TypeOf((*MockRepository)(nil).CompleteAccrualJob), ctx, jobID, workerID)
+}
+
+// CountProcessedOrders mocks base method.
+func (m *MockRepository) CountProcessedOrders(ctx context.Context, userID string, excludeOrderID uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountProcessedOrders", ctx, userID, excludeOrderID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountProcessedOrders indicates an expected call of CountProcessedOrders.
+func (mr *MockRepositoryMockRecorder) CountProcessedOrders(ctx, userID, excludeOrderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountProcessedOrders", reflect.TypeOf((*MockRepository)(nil).CountProcessedOrders), ctx, userID, excludeOrderID)
+}
+
+// CreateGiftCodeBatch mocks base method.
+func (m *MockRepository) CreateGiftCodeBatch(ctx context.Context, batch repository.CreateGiftCodeBatchParams, codes []string) (repository.GiftCodeBatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateGiftCodeBatch", ctx, batch, codes)
+	ret0, _ := ret[0].(repository.GiftCodeBatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateGiftCodeBatch indicates an expected call of CreateGiftCodeBatch.
+func (mr *MockRepositoryMockRecorder) CreateGiftCodeBatch(ctx, batch, codes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGiftCodeBatch", reflect.TypeOf((*MockRepository)(nil).CreateGiftCodeBatch), ctx, batch, codes)
+}
+
+// CreateHold mocks base method.
+func (m *MockRepository) CreateHold(ctx context.Context, userID, orderNumber string, amount decimal.Decimal, expiresAt time.Time) (repository.BalanceHold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateHold", ctx, userID, orderNumber, amount, expiresAt)
+	ret0, _ := ret[0].(repository.BalanceHold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateHold indicates an expected call of CreateHold.
+func (mr *MockRepositoryMockRecorder) CreateHold(ctx, userID, orderNumber, amount, expiresAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateHold", reflect.TypeOf((*MockRepository)(nil).CreateHold), ctx, userID, orderNumber, amount, expiresAt)
+}
+
+// CreateReferral mocks base method.
+func (m *MockRepository) CreateReferral(ctx context.Context, tx pgx.Tx, referrerID, referredID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReferral", ctx, tx, referrerID, referredID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateReferral indicates an expected call of CreateReferral.
+func (mr *MockRepositoryMockRecorder) CreateReferral(ctx, tx, referrerID, referredID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReferral", reflect.TypeOf((*MockRepository)(nil).CreateReferral), ctx, tx, referrerID, referredID)
+}
+
 // CreateTopUpOrder mocks base method.
-func (m *MockRepository) CreateTopUpOrder(userID, orderNumber string) (repository.Order, bool, error) {
+func (m *MockRepository) CreateTopUpOrder(ctx context.Context, userID, orderNumber string) (repository.Order, bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateTopUpOrder", userID, orderNumber)
+	ret := m.ctrl.Call(m, "CreateTopUpOrder", ctx, userID, orderNumber)
 	ret0, _ := ret[0].(repository.Order)
 	ret1, _ := ret[1].(bool)
 	ret2, _ := ret[2].(error)
@@ -52,126 +160,353 @@ func (m *MockRepository) CreateTopUpOrder(userID, orderNumber string) (repositor
 }
 
 // CreateTopUpOrder indicates an expected call of CreateTopUpOrder.
-func (mr *MockRepositoryMockRecorder) CreateTopUpOrder(userID, orderNumber any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) CreateTopUpOrder(ctx, userID, orderNumber any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTopUpOrder", reflect.TypeOf((*MockRepository)(nil).CreateTopUpOrder), userID, orderNumber)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTopUpOrder", reflect.TypeOf((*MockRepository)(nil).CreateTopUpOrder), ctx, userID, orderNumber)
+}
+
+// CreateTransfer mocks base method.
+func (m *MockRepository) CreateTransfer(ctx context.Context, senderID, recipientLogin string, amount, dailyLimit decimal.Decimal) (repository.GetTransfersByUserIDRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTransfer", ctx, senderID, recipientLogin, amount, dailyLimit)
+	ret0, _ := ret[0].(repository.GetTransfersByUserIDRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTransfer indicates an expected call of CreateTransfer.
+func (mr *MockRepositoryMockRecorder) CreateTransfer(ctx, senderID, recipientLogin, amount, dailyLimit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransfer", reflect.TypeOf((*MockRepository)(nil).CreateTransfer), ctx, senderID, recipientLogin, amount, dailyLimit)
 }
 
 // CreateWithdrawalOrder mocks base method.
-func (m *MockRepository) CreateWithdrawalOrder(userID, orderNumber string, amount decimal.Decimal) (repository.Order, error) {
+func (m *MockRepository) CreateWithdrawalOrder(ctx context.Context, userID, orderNumber string, amount decimal.Decimal) (repository.Order, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateWithdrawalOrder", userID, orderNumber, amount)
+	ret := m.ctrl.Call(m, "CreateWithdrawalOrder", ctx, userID, orderNumber, amount)
 	ret0, _ := ret[0].(repository.Order)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateWithdrawalOrder indicates an expected call of CreateWithdrawalOrder.
-func (mr *MockRepositoryMockRecorder) CreateWithdrawalOrder(userID, orderNumber, amount any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) CreateWithdrawalOrder(ctx, userID, orderNumber, amount any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWithdrawalOrder", reflect.TypeOf((*MockRepository)(nil).CreateWithdrawalOrder), userID, orderNumber, amount)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWithdrawalOrder", reflect.TypeOf((*MockRepository)(nil).CreateWithdrawalOrder), ctx, userID, orderNumber, amount)
 }
 
-// GetNewTopUpOrder mocks base method.
-func (m *MockRepository) GetNewTopUpOrder() (repository.Order, error) {
+// ExpireHolds mocks base method.
+func (m *MockRepository) ExpireHolds(ctx context.Context, limit int32) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetNewTopUpOrder")
-	ret0, _ := ret[0].(repository.Order)
+	ret := m.ctrl.Call(m, "ExpireHolds", ctx, limit)
+	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetNewTopUpOrder indicates an expected call of GetNewTopUpOrder.
-func (mr *MockRepositoryMockRecorder) GetNewTopUpOrder() *gomock.Call {
+// ExpireHolds indicates an expected call of ExpireHolds.
+func (mr *MockRepositoryMockRecorder) ExpireHolds(ctx, limit any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNewTopUpOrder", reflect.TypeOf((*MockRepository)(nil).GetNewTopUpOrder))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireHolds", reflect.TypeOf((*MockRepository)(nil).ExpireHolds), ctx, limit)
+}
+
+// ExpirePoints mocks base method.
+func (m *MockRepository) ExpirePoints(ctx context.Context, limit int32) (int, decimal.Decimal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpirePoints", ctx, limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(decimal.Decimal)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ExpirePoints indicates an expected call of ExpirePoints.
+func (mr *MockRepositoryMockRecorder) ExpirePoints(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpirePoints", reflect.TypeOf((*MockRepository)(nil).ExpirePoints), ctx, limit)
+}
+
+// GetAccrualVolume mocks base method.
+func (m *MockRepository) GetAccrualVolume(ctx context.Context, userID string, since time.Time) (decimal.Decimal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccrualVolume", ctx, userID, since)
+	ret0, _ := ret[0].(decimal.Decimal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccrualVolume indicates an expected call of GetAccrualVolume.
+func (mr *MockRepositoryMockRecorder) GetAccrualVolume(ctx, userID, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccrualVolume", reflect.TypeOf((*MockRepository)(nil).GetAccrualVolume), ctx, userID, since)
+}
+
+// GetAccrualVolumes mocks base method.
+func (m *MockRepository) GetAccrualVolumes(ctx context.Context, since time.Time, afterUserID uuid.UUID, limit int32) ([]repository.GetAccrualVolumesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccrualVolumes", ctx, since, afterUserID, limit)
+	ret0, _ := ret[0].([]repository.GetAccrualVolumesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccrualVolumes indicates an expected call of GetAccrualVolumes.
+func (mr *MockRepositoryMockRecorder) GetAccrualVolumes(ctx, since, afterUserID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccrualVolumes", reflect.TypeOf((*MockRepository)(nil).GetAccrualVolumes), ctx, since, afterUserID, limit)
+}
+
+// GetActiveCampaigns mocks base method.
+func (m *MockRepository) GetActiveCampaigns(ctx context.Context, userID string, at time.Time) ([]repository.GetActiveCampaignsByUserIDRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActiveCampaigns", ctx, userID, at)
+	ret0, _ := ret[0].([]repository.GetActiveCampaignsByUserIDRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveCampaigns indicates an expected call of GetActiveCampaigns.
+func (mr *MockRepositoryMockRecorder) GetActiveCampaigns(ctx, userID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveCampaigns", reflect.TypeOf((*MockRepository)(nil).GetActiveCampaigns), ctx, userID, at)
+}
+
+// GetGiftCodeBatch mocks base method.
+func (m *MockRepository) GetGiftCodeBatch(ctx context.Context, batchID string) (repository.GiftCodeBatch, []repository.GiftCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGiftCodeBatch", ctx, batchID)
+	ret0, _ := ret[0].(repository.GiftCodeBatch)
+	ret1, _ := ret[1].([]repository.GiftCode)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetGiftCodeBatch indicates an expected call of GetGiftCodeBatch.
+func (mr *MockRepositoryMockRecorder) GetGiftCodeBatch(ctx, batchID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGiftCodeBatch", reflect.TypeOf((*MockRepository)(nil).GetGiftCodeBatch), ctx, batchID)
 }
 
 // GetOrderByNumber mocks base method.
-func (m *MockRepository) GetOrderByNumber(number string) (repository.Order, error) {
+func (m *MockRepository) GetOrderByNumber(ctx context.Context, number string) (repository.Order, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOrderByNumber", number)
+	ret := m.ctrl.Call(m, "GetOrderByNumber", ctx, number)
 	ret0, _ := ret[0].(repository.Order)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetOrderByNumber indicates an expected call of GetOrderByNumber.
-func (mr *MockRepositoryMockRecorder) GetOrderByNumber(number any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetOrderByNumber(ctx, number any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderByNumber", reflect.TypeOf((*MockRepository)(nil).GetOrderByNumber), number)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderByNumber", reflect.TypeOf((*MockRepository)(nil).GetOrderByNumber), ctx, number)
 }
 
 // GetOrdersByUserID mocks base method.
-func (m *MockRepository) GetOrdersByUserID(userID string) ([]repository.Order, error) {
+func (m *MockRepository) GetOrdersByUserID(ctx context.Context, userID string) ([]repository.Order, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOrdersByUserID", userID)
+	ret := m.ctrl.Call(m, "GetOrdersByUserID", ctx, userID)
 	ret0, _ := ret[0].([]repository.Order)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetOrdersByUserID indicates an expected call of GetOrdersByUserID.
-func (mr *MockRepositoryMockRecorder) GetOrdersByUserID(userID any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetOrdersByUserID(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersByUserID", reflect.TypeOf((*MockRepository)(nil).GetOrdersByUserID), ctx, userID)
+}
+
+// GetPointsExpiry mocks base method.
+func (m *MockRepository) GetPointsExpiry(ctx context.Context, userID string) (repository.GetPointsExpiryByUserIDRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPointsExpiry", ctx, userID)
+	ret0, _ := ret[0].(repository.GetPointsExpiryByUserIDRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPointsExpiry indicates an expected call of GetPointsExpiry.
+func (mr *MockRepositoryMockRecorder) GetPointsExpiry(ctx, userID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersByUserID", reflect.TypeOf((*MockRepository)(nil).GetOrdersByUserID), userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPointsExpiry", reflect.TypeOf((*MockRepository)(nil).GetPointsExpiry), ctx, userID)
+}
+
+// GetReferrals mocks base method.
+func (m *MockRepository) GetReferrals(ctx context.Context, userID string) (string, []repository.GetReferralsByReferrerIDRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReferrals", ctx, userID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].([]repository.GetReferralsByReferrerIDRow)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetReferrals indicates an expected call of GetReferrals.
+func (mr *MockRepositoryMockRecorder) GetReferrals(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReferrals", reflect.TypeOf((*MockRepository)(nil).GetReferrals), ctx, userID)
+}
+
+// GetTransfers mocks base method.
+func (m *MockRepository) GetTransfers(ctx context.Context, userID string) ([]repository.GetTransfersByUserIDRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransfers", ctx, userID)
+	ret0, _ := ret[0].([]repository.GetTransfersByUserIDRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransfers indicates an expected call of GetTransfers.
+func (mr *MockRepositoryMockRecorder) GetTransfers(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfers", reflect.TypeOf((*MockRepository)(nil).GetTransfers), ctx, userID)
 }
 
 // GetUserBalanceByUserID mocks base method.
-func (m *MockRepository) GetUserBalanceByUserID(userID string) (decimal.Decimal, error) {
+func (m *MockRepository) GetUserBalanceByUserID(ctx context.Context, userID string) (repository.UserBalance, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserBalanceByUserID", userID)
-	ret0, _ := ret[0].(decimal.Decimal)
+	ret := m.ctrl.Call(m, "GetUserBalanceByUserID", ctx, userID)
+	ret0, _ := ret[0].(repository.UserBalance)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUserBalanceByUserID indicates an expected call of GetUserBalanceByUserID.
-func (mr *MockRepositoryMockRecorder) GetUserBalanceByUserID(userID any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetUserBalanceByUserID(ctx, userID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserBalanceByUserID", reflect.TypeOf((*MockRepository)(nil).GetUserBalanceByUserID), userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserBalanceByUserID", reflect.TypeOf((*MockRepository)(nil).GetUserBalanceByUserID), ctx, userID)
 }
 
-// GetUserWithdrawByUserID mocks base method.
-func (m *MockRepository) GetUserWithdrawByUserID(userID string) (decimal.Decimal, error) {
+// GetUserTier mocks base method.
+func (m *MockRepository) GetUserTier(ctx context.Context, userID string) (repository.UserTier, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserWithdrawByUserID", userID)
-	ret0, _ := ret[0].(decimal.Decimal)
+	ret := m.ctrl.Call(m, "GetUserTier", ctx, userID)
+	ret0, _ := ret[0].(repository.UserTier)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserWithdrawByUserID indicates an expected call of GetUserWithdrawByUserID.
-func (mr *MockRepositoryMockRecorder) GetUserWithdrawByUserID(userID any) *gomock.Call {
+// GetUserTier indicates an expected call of GetUserTier.
+func (mr *MockRepositoryMockRecorder) GetUserTier(ctx, userID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserWithdrawByUserID", reflect.TypeOf((*MockRepository)(nil).GetUserWithdrawByUserID), userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserTier", reflect.TypeOf((*MockRepository)(nil).GetUserTier), ctx, userID)
 }
 
 // GetWithdrawalsByUserID mocks base method.
-func (m *MockRepository) GetWithdrawalsByUserID(userID string) ([]repository.Order, error) {
+func (m *MockRepository) GetWithdrawalsByUserID(ctx context.Context, userID string) ([]repository.GetWithdrawalsByUserIDRow, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetWithdrawalsByUserID", userID)
-	ret0, _ := ret[0].([]repository.Order)
+	ret := m.ctrl.Call(m, "GetWithdrawalsByUserID", ctx, userID)
+	ret0, _ := ret[0].([]repository.GetWithdrawalsByUserIDRow)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetWithdrawalsByUserID indicates an expected call of GetWithdrawalsByUserID.
-func (mr *MockRepositoryMockRecorder) GetWithdrawalsByUserID(userID any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetWithdrawalsByUserID(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithdrawalsByUserID", reflect.TypeOf((*MockRepository)(nil).GetWithdrawalsByUserID), ctx, userID)
+}
+
+// RedeemGiftCode mocks base method.
+func (m *MockRepository) RedeemGiftCode(ctx context.Context, userID, code string) (repository.GiftCodeRedemption, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedeemGiftCode", ctx, userID, code)
+	ret0, _ := ret[0].(repository.GiftCodeRedemption)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RedeemGiftCode indicates an expected call of RedeemGiftCode.
+func (mr *MockRepositoryMockRecorder) RedeemGiftCode(ctx, userID, code any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithdrawalsByUserID", reflect.TypeOf((*MockRepository)(nil).GetWithdrawalsByUserID), userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedeemGiftCode", reflect.TypeOf((*MockRepository)(nil).RedeemGiftCode), ctx, userID, code)
 }
 
-// UpdateOrderStatusByNumber mocks base method.
-func (m *MockRepository) UpdateOrderStatusByNumber(number string, status repository.Orderstatus, amount *decimal.Decimal) error {
+// RefundWithdrawal mocks base method.
+func (m *MockRepository) RefundWithdrawal(ctx context.Context, userID, orderNumber string, amount decimal.Decimal) (repository.GetWithdrawalsByUserIDRow, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateOrderStatusByNumber", number, status, amount)
+	ret := m.ctrl.Call(m, "RefundWithdrawal", ctx, userID, orderNumber, amount)
+	ret0, _ := ret[0].(repository.GetWithdrawalsByUserIDRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefundWithdrawal indicates an expected call of RefundWithdrawal.
+func (mr *MockRepositoryMockRecorder) RefundWithdrawal(ctx, userID, orderNumber, amount any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefundWithdrawal", reflect.TypeOf((*MockRepository)(nil).RefundWithdrawal), ctx, userID, orderNumber, amount)
+}
+
+// ReleaseAccrualJob mocks base method.
+func (m *MockRepository) ReleaseAccrualJob(ctx context.Context, jobID uuid.UUID, workerID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseAccrualJob", ctx, jobID, workerID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpdateOrderStatusByNumber indicates an expected call of UpdateOrderStatusByNumber.
-func (mr *MockRepositoryMockRecorder) UpdateOrderStatusByNumber(number, status, amount any) *gomock.Call {
+// ReleaseAccrualJob indicates an expected call of ReleaseAccrualJob.
+func (mr *MockRepositoryMockRecorder) ReleaseAccrualJob(ctx, jobID, workerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseAccrualJob", reflect.TypeOf((*MockRepository)(nil).ReleaseAccrualJob), ctx, jobID, workerID)
+}
+
+// RescheduleAccrualJob mocks base method.
+func (m *MockRepository) RescheduleAccrualJob(ctx context.Context, jobID uuid.UUID, workerID string, delay time.Duration, lastError string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RescheduleAccrualJob", ctx, jobID, workerID, delay, lastError)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RescheduleAccrualJob indicates an expected call of RescheduleAccrualJob.
+func (mr *MockRepositoryMockRecorder) RescheduleAccrualJob(ctx, jobID, workerID, delay, lastError any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RescheduleAccrualJob", reflect.TypeOf((*MockRepository)(nil).RescheduleAccrualJob), ctx, jobID, workerID, delay, lastError)
+}
+
+// SetUserTier mocks base method.
+func (m *MockRepository) SetUserTier(ctx context.Context, tier repository.UserTier) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserTier", ctx, tier)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserTier indicates an expected call of SetUserTier.
+func (mr *MockRepositoryMockRecorder) SetUserTier(ctx, tier any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserTier", reflect.TypeOf((*MockRepository)(nil).SetUserTier), ctx, tier)
+}
+
+// UpdateOrderStatus mocks base method.
+func (m *MockRepository) UpdateOrderStatus(ctx context.Context, number string, from, to repository.Orderstatus, amount *decimal.Decimal, bonuses repository.OrderBonuses) (repository.OrderCredits, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOrderStatus", ctx, number, from, to, amount, bonuses)
+	ret0, _ := ret[0].(repository.OrderCredits)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateOrderStatus indicates an expected call of UpdateOrderStatus.
+func (mr *MockRepositoryMockRecorder) UpdateOrderStatus(ctx, number, from, to, amount, bonuses any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrderStatus", reflect.TypeOf((*MockRepository)(nil).UpdateOrderStatus), ctx, number, from, to, amount, bonuses)
+}
+
+// VoidHold mocks base method.
+func (m *MockRepository) VoidHold(ctx context.Context, userID, holdID string) (repository.BalanceHold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VoidHold", ctx, userID, holdID)
+	ret0, _ := ret[0].(repository.BalanceHold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VoidHold indicates an expected call of VoidHold.
+func (mr *MockRepositoryMockRecorder) VoidHold(ctx, userID, holdID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrderStatusByNumber", reflect.TypeOf((*MockRepository)(nil).UpdateOrderStatusByNumber), number, status, amount)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VoidHold", reflect.TypeOf((*MockRepository)(nil).VoidHold), ctx, userID, holdID)
 }
@@ -15,19 +15,18 @@ FROM orders
 WHERE number = $1
 LIMIT 1;
 
--- name: UpdateOrderByNumber :exec
+-- name: UpdateOrderStatus :one
 UPDATE orders
-SET status       = $2,
-    amount       = $3,
-    processed_at = $4
-WHERE number = $1;
+SET status       = sqlc.arg(to_status),
+    amount       = sqlc.arg(amount),
+    processed_at = sqlc.arg(processed_at)
+WHERE number = sqlc.arg(number)
+  AND status = sqlc.arg(from_status)
+RETURNING *;
 
--- name: GetNewTopUpOrder :one
-SELECT *
-FROM orders
-WHERE type = 'CREDIT'
-  AND status = 'NEW'
-LIMIT 1;
+-- name: CreateOrderStatusTransition :exec
+INSERT INTO order_status_transitions (order_id, from_status, to_status)
+VALUES ($1, $2, $3);
 
 -- name: Withdrawal :one
 INSERT INTO orders (user_id, number, amount, type, status)
@@ -35,28 +34,450 @@ VALUES ($1, $2, $3, 'DEBIT', 'PROCESSED')
 RETURNING *;
 
 -- name: GetWithdrawalsByUserID :many
-SELECT *
+SELECT sqlc.embed(orders), COALESCE(SUM(withdrawal_refunds.amount), 0)::NUMERIC AS refunded
 FROM orders
+         LEFT JOIN withdrawal_refunds ON withdrawal_refunds.withdrawal_id = orders.id
+WHERE orders.user_id = $1
+  AND orders.type = 'DEBIT'
+  AND orders.status = 'PROCESSED'
+GROUP BY orders.id
+ORDER BY orders.processed_at;
+
+-- name: GetUserBalanceByUserID :one
+SELECT *
+FROM user_balances
+WHERE user_id = $1;
+
+-- name: EnsureUserBalance :exec
+INSERT INTO user_balances (user_id)
+VALUES ($1)
+ON CONFLICT (user_id) DO NOTHING;
+
+-- name: LockUserBalance :one
+SELECT *
+FROM user_balances
 WHERE user_id = $1
+FOR UPDATE;
+
+-- name: CreateLedgerEntry :exec
+INSERT INTO ledger_entries (transaction_id, account, direction, amount, reference)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: CreditUserBalance :exec
+INSERT INTO user_balances (user_id, current)
+VALUES (sqlc.arg(user_id), sqlc.arg(amount))
+ON CONFLICT (user_id) DO UPDATE
+    SET current    = user_balances.current + EXCLUDED.current,
+        updated_at = CURRENT_TIMESTAMP;
+
+-- name: DebitUserBalance :exec
+UPDATE user_balances
+SET current    = current - sqlc.arg(amount),
+    withdrawn  = withdrawn + sqlc.arg(amount),
+    updated_at = CURRENT_TIMESTAMP
+WHERE user_id = sqlc.arg(user_id);
+
+-- name: CreateAccrualJob :exec
+INSERT INTO accrual_jobs (order_id, order_number)
+VALUES ($1, $2)
+ON CONFLICT (order_id) DO NOTHING;
+
+-- name: ClaimAccrualJob :one
+UPDATE accrual_jobs
+SET locked_by    = sqlc.arg(locked_by),
+    locked_until = CURRENT_TIMESTAMP + make_interval(secs => sqlc.arg(lease_seconds)::FLOAT8),
+    attempts     = attempts + 1,
+    updated_at   = CURRENT_TIMESTAMP
+WHERE id = (SELECT id
+            FROM accrual_jobs
+            WHERE next_run_at <= CURRENT_TIMESTAMP
+              AND (locked_until IS NULL OR locked_until < CURRENT_TIMESTAMP)
+            ORDER BY next_run_at
+            LIMIT 1 FOR UPDATE SKIP LOCKED)
+RETURNING *;
+
+-- name: CompleteAccrualJob :exec
+DELETE
+FROM accrual_jobs
+WHERE id = $1
+  AND locked_by = $2;
+
+-- name: RescheduleAccrualJob :exec
+UPDATE accrual_jobs
+SET next_run_at  = CURRENT_TIMESTAMP + make_interval(secs => sqlc.arg(delay_seconds)::FLOAT8),
+    last_error   = sqlc.arg(last_error),
+    locked_by    = NULL,
+    locked_until = NULL,
+    updated_at   = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id)
+  AND locked_by = sqlc.arg(locked_by);
+
+-- name: ReleaseAccrualJob :exec
+UPDATE accrual_jobs
+SET attempts     = GREATEST(attempts - 1, 0),
+    locked_by    = NULL,
+    locked_until = NULL,
+    updated_at   = CURRENT_TIMESTAMP
+WHERE id = $1
+  AND locked_by = $2;
+
+-- name: CreateBalanceHold :one
+INSERT INTO balance_holds (user_id, order_number, amount, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: LockBalanceHold :one
+SELECT *
+FROM balance_holds
+WHERE id = $1
+  AND user_id = $2
+FOR UPDATE;
+
+-- name: LockExpiredBalanceHold :one
+SELECT *
+FROM balance_holds
+WHERE status = 'ACTIVE'
+  AND expires_at <= CURRENT_TIMESTAMP
+ORDER BY expires_at
+LIMIT 1 FOR UPDATE SKIP LOCKED;
+
+-- name: UpdateBalanceHoldStatus :one
+UPDATE balance_holds
+SET status     = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: HoldUserBalance :exec
+UPDATE user_balances
+SET current    = current - sqlc.arg(amount),
+    held       = held + sqlc.arg(amount),
+    updated_at = CURRENT_TIMESTAMP
+WHERE user_id = sqlc.arg(user_id);
+
+-- name: CaptureHeldBalance :exec
+UPDATE user_balances
+SET held       = held - sqlc.arg(amount),
+    withdrawn  = withdrawn + sqlc.arg(amount),
+    updated_at = CURRENT_TIMESTAMP
+WHERE user_id = sqlc.arg(user_id);
+
+-- name: ReleaseHeldBalance :exec
+UPDATE user_balances
+SET held       = held - sqlc.arg(amount),
+    current    = current + sqlc.arg(amount),
+    updated_at = CURRENT_TIMESTAMP
+WHERE user_id = sqlc.arg(user_id);
+
+-- name: LockWithdrawalByNumber :one
+SELECT *
+FROM orders
+WHERE number = $1
+  AND user_id = $2
   AND type = 'DEBIT'
   AND status = 'PROCESSED'
-ORDER BY processed_at;
+FOR UPDATE;
 
--- name: GetUserBalanceByUserID :one
-SELECT COALESCE(SUM(
-                        CASE type
-                            WHEN 'CREDIT' THEN amount
-                            WHEN 'DEBIT' THEN -amount
-                            ELSE 0
-                            END
-                ), 0::NUMERIC(10, 2))::NUMERIC(10, 2)
-FROM orders
+-- name: GetRefundedAmountByWithdrawalID :one
+SELECT COALESCE(SUM(amount), 0)::NUMERIC AS refunded
+FROM withdrawal_refunds
+WHERE withdrawal_id = $1;
+
+-- name: CreateWithdrawalRefund :exec
+INSERT INTO withdrawal_refunds (withdrawal_id, amount)
+VALUES ($1, $2);
+
+-- name: RefundUserBalance :exec
+UPDATE user_balances
+SET current    = current + sqlc.arg(amount),
+    withdrawn  = withdrawn - sqlc.arg(amount),
+    updated_at = CURRENT_TIMESTAMP
+WHERE user_id = sqlc.arg(user_id);
+
+-- name: CreatePointLot :exec
+INSERT INTO point_lots (user_id, reference, amount, remaining, expires_at)
+VALUES (sqlc.arg(user_id), sqlc.arg(reference), sqlc.arg(amount), sqlc.arg(amount), sqlc.arg(expires_at));
+
+-- name: LockSpendablePointLots :many
+SELECT *
+FROM point_lots
+WHERE user_id = $1
+  AND remaining > 0
+  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+ORDER BY expires_at NULLS LAST, created_at
+FOR UPDATE;
+
+-- name: UpdatePointLotRemaining :exec
+UPDATE point_lots
+SET remaining = $2
+WHERE id = $1;
+
+-- name: CreatePointLotAllocation :exec
+INSERT INTO point_lot_allocations (lot_id, reference, amount)
+VALUES ($1, $2, $3);
+
+-- name: LockPointLotAllocationsByReference :many
+SELECT point_lot_allocations.*
+FROM point_lot_allocations
+         JOIN point_lots ON point_lots.id = point_lot_allocations.lot_id
+WHERE point_lot_allocations.reference = $1
+  AND point_lots.user_id = $2
+  AND point_lot_allocations.amount > 0
+ORDER BY point_lots.expires_at DESC NULLS FIRST
+FOR UPDATE;
+
+-- name: UpdatePointLotAllocationAmount :exec
+UPDATE point_lot_allocations
+SET amount = $2
+WHERE id = $1;
+
+-- name: RestorePointLot :exec
+UPDATE point_lots
+SET remaining = remaining + $2
+WHERE id = $1;
+
+-- name: GetUsersWithExpiredPointLots :many
+SELECT DISTINCT user_id
+FROM point_lots
+WHERE remaining > 0
+  AND expires_at <= CURRENT_TIMESTAMP
+LIMIT $1;
+
+-- name: LockExpiredPointLotsByUser :many
+SELECT *
+FROM point_lots
 WHERE user_id = $1
-  AND status = 'PROCESSED';
+  AND remaining > 0
+  AND expires_at <= CURRENT_TIMESTAMP
+FOR UPDATE;
+
+-- name: ExpireUserBalance :exec
+UPDATE user_balances
+SET current    = current - sqlc.arg(amount),
+    updated_at = CURRENT_TIMESTAMP
+WHERE user_id = sqlc.arg(user_id);
+
+-- name: GetPointsExpiryByUserID :one
+SELECT COALESCE(SUM(remaining) FILTER (WHERE expires_at <= sqlc.arg(until)), 0)::NUMERIC AS expiring_soon,
+       MIN(expires_at)::TIMESTAMPTZ                                                    AS next_expiry
+FROM point_lots
+WHERE user_id = sqlc.arg(user_id)
+  AND remaining > 0
+  AND expires_at > CURRENT_TIMESTAMP;
+
+-- name: GetActiveCampaignsByUserID :many
+SELECT sqlc.embed(campaigns),
+       (campaigns.cohort IS NOT NULL AND EXISTS (SELECT 1
+                                                 FROM user_cohorts
+                                                 WHERE user_cohorts.cohort = campaigns.cohort
+                                                   AND user_cohorts.user_id = sqlc.arg(user_id)))::BOOLEAN AS in_cohort
+FROM campaigns
+WHERE campaigns.starts_at <= sqlc.arg(at)
+  AND (campaigns.ends_at IS NULL OR campaigns.ends_at > sqlc.arg(at))
+ORDER BY campaigns.created_at, campaigns.id;
+
+-- name: CountProcessedTopUpOrdersByUserID :one
+SELECT COUNT(*)
+FROM orders
+WHERE type = 'CREDIT'
+  AND status = 'PROCESSED'
+  AND user_id = sqlc.arg(user_id)
+  AND id <> sqlc.arg(exclude_order_id);
+
+-- name: LockCampaign :one
+SELECT *
+FROM campaigns
+WHERE id = $1
+FOR UPDATE;
+
+-- name: SpendCampaignBudget :exec
+UPDATE campaigns
+SET spent = spent + $2
+WHERE id = $1;
+
+-- name: CreateOrderCampaignBonus :exec
+INSERT INTO order_campaign_bonuses (order_id, campaign_id, amount)
+VALUES ($1, $2, $3);
 
--- name: GetUserWithdrawByUserID :one
-SELECT COALESCE(SUM(amount), 0)::NUMERIC(10, 2)
+-- name: GetAccrualVolumes :many
+SELECT orders.user_id,
+       COALESCE(SUM(orders.amount) FILTER (WHERE orders.status = 'PROCESSED' AND orders.processed_at >= sqlc.arg(since)),
+                0)::NUMERIC AS volume
 FROM orders
+WHERE orders.type = 'CREDIT'
+  AND orders.user_id > sqlc.arg(after_user_id)
+GROUP BY orders.user_id
+ORDER BY orders.user_id
+LIMIT sqlc.arg(limit_count);
+
+-- name: GetAccrualVolumeByUserID :one
+SELECT COALESCE(SUM(amount), 0)::NUMERIC AS volume
+FROM orders
+WHERE type = 'CREDIT'
+  AND status = 'PROCESSED'
+  AND user_id = sqlc.arg(user_id)
+  AND processed_at >= sqlc.arg(since);
+
+-- name: GetUserTierByUserID :one
+SELECT *
+FROM user_tiers
+WHERE user_id = $1;
+
+-- name: LockUserTier :one
+SELECT *
+FROM user_tiers
 WHERE user_id = $1
-  AND type = 'DEBIT'
-  AND status = 'PROCESSED';
\ No newline at end of file
+FOR UPDATE;
+
+-- name: UpsertUserTier :exec
+INSERT INTO user_tiers (user_id, tier, volume, accrual_multiplier, withdrawal_limit)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id) DO UPDATE
+    SET tier               = EXCLUDED.tier,
+        volume             = EXCLUDED.volume,
+        accrual_multiplier = EXCLUDED.accrual_multiplier,
+        withdrawal_limit   = EXCLUDED.withdrawal_limit,
+        updated_at         = CURRENT_TIMESTAMP;
+
+-- name: CreateUserTierHistory :exec
+INSERT INTO user_tier_history (user_id, from_tier, to_tier, volume)
+VALUES ($1, $2, $3, $4);
+
+-- name: CreateOrderTierBonus :exec
+INSERT INTO order_tier_bonuses (order_id, tier, amount)
+VALUES ($1, $2, $3);
+
+-- name: GetWithdrawnSinceByUserID :one
+SELECT (COALESCE((SELECT SUM(orders.amount)
+                  FROM orders
+                  WHERE orders.user_id = sqlc.arg(user_id)
+                    AND orders.type = 'DEBIT'
+                    AND orders.created_at >= sqlc.arg(since)), 0) +
+        COALESCE((SELECT SUM(balance_holds.amount)
+                  FROM balance_holds
+                  WHERE balance_holds.user_id = sqlc.arg(user_id)
+                    AND balance_holds.status = 'ACTIVE'
+                    AND balance_holds.created_at >= sqlc.arg(since)), 0))::NUMERIC AS withdrawn;
+
+-- name: GetUserIDByUsername :one
+SELECT id
+FROM users
+WHERE username = $1;
+
+-- name: CreateTransfer :one
+INSERT INTO transfers (sender_id, recipient_id, amount)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetTransferredSinceByUserID :one
+SELECT COALESCE(SUM(amount), 0)::NUMERIC AS transferred
+FROM transfers
+WHERE sender_id = sqlc.arg(user_id)
+  AND created_at >= sqlc.arg(since);
+
+-- name: TransferUserBalance :exec
+UPDATE user_balances
+SET current    = current - sqlc.arg(amount),
+    updated_at = CURRENT_TIMESTAMP
+WHERE user_id = sqlc.arg(user_id);
+
+-- name: GetTransfersByUserID :many
+SELECT transfers.id,
+       transfers.amount,
+       transfers.created_at,
+       (transfers.sender_id = sqlc.arg(user_id))::BOOLEAN AS outgoing,
+       users.username                                     AS counterparty
+FROM transfers
+         JOIN users ON users.id = CASE
+                                      WHEN transfers.sender_id = sqlc.arg(user_id) THEN transfers.recipient_id
+                                      ELSE transfers.sender_id END
+WHERE transfers.sender_id = sqlc.arg(user_id)
+   OR transfers.recipient_id = sqlc.arg(user_id)
+ORDER BY transfers.created_at DESC;
+
+-- name: CreateGiftCodeBatch :one
+INSERT INTO gift_code_batches (name, amount, max_redemptions, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: CreateGiftCode :exec
+INSERT INTO gift_codes (batch_id, code)
+VALUES ($1, $2);
+
+-- name: GetGiftCodeBatch :one
+SELECT *
+FROM gift_code_batches
+WHERE id = $1;
+
+-- name: GetGiftCodesByBatchID :many
+SELECT *
+FROM gift_codes
+WHERE batch_id = $1
+ORDER BY code;
+
+-- name: LockGiftCodeByCode :one
+SELECT gift_codes.id,
+       gift_codes.code,
+       gift_codes.redemptions,
+       gift_code_batches.amount,
+       gift_code_batches.max_redemptions,
+       gift_code_batches.expires_at
+FROM gift_codes
+         JOIN gift_code_batches ON gift_code_batches.id = gift_codes.batch_id
+WHERE gift_codes.code = $1
+FOR UPDATE OF gift_codes;
+
+-- name: CreateGiftCodeRedemption :one
+INSERT INTO gift_code_redemptions (gift_code_id, user_id, amount)
+VALUES ($1, $2, $3)
+ON CONFLICT (gift_code_id, user_id) DO NOTHING
+RETURNING *;
+
+-- name: IncrementGiftCodeRedemptions :exec
+UPDATE gift_codes
+SET redemptions = redemptions + 1
+WHERE id = $1;
+
+-- name: CreateReferral :exec
+INSERT INTO referrals (referrer_id, referred_id)
+VALUES ($1, $2);
+
+-- name: LockPendingReferralByReferredID :one
+SELECT *
+FROM referrals
+WHERE referred_id = $1
+  AND status = 'PENDING'
+FOR UPDATE;
+
+-- name: CountRewardedReferralsByReferrerID :one
+SELECT COUNT(*)
+FROM referrals
+WHERE referrer_id = $1
+  AND status = 'REWARDED';
+
+-- name: SettleReferral :exec
+UPDATE referrals
+SET status          = $1,
+    reason          = $2,
+    order_id        = $3,
+    referrer_reward = $4,
+    referred_reward = $5,
+    settled_at      = CURRENT_TIMESTAMP
+WHERE referred_id = $6;
+
+-- name: GetReferralCodeByUserID :one
+SELECT referral_code
+FROM users
+WHERE id = $1;
+
+-- name: GetReferralsByReferrerID :many
+SELECT users.username AS referred,
+       referrals.status,
+       referrals.reason,
+       referrals.referrer_reward,
+       referrals.created_at,
+       referrals.settled_at
+FROM referrals
+         JOIN users ON users.id = referrals.referred_id
+WHERE referrals.referrer_id = $1
+ORDER BY referrals.created_at DESC;
@@ -1,3 +1,13 @@
+-- users belongs to the user repository. It is repeated here so that queries can join it.
+CREATE TABLE users
+(
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    username      VARCHAR(255) UNIQUE NOT NULL,
+    password_hash VARCHAR(255)        NOT NULL,
+    created_at    TIMESTAMP        DEFAULT CURRENT_TIMESTAMP,
+    referral_code TEXT                NOT NULL
+);
+
 CREATE TYPE OrderStatus AS ENUM ('NEW', 'PROCESSING', 'INVALID', 'PROCESSED');
 CREATE TYPE OrderType AS ENUM ('DEBIT', 'CREDIT');
 
@@ -13,4 +23,244 @@ CREATE TABLE IF NOT EXISTS orders
     created_at   TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
-CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders (user_id);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders (user_id);
+
+CREATE TABLE IF NOT EXISTS accrual_jobs
+(
+    id           UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    order_id     UUID                     NOT NULL UNIQUE REFERENCES orders (id) ON DELETE CASCADE,
+    order_number TEXT                     NOT NULL,
+    attempts     INTEGER                  NOT NULL DEFAULT 0,
+    next_run_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error   TEXT,
+    locked_by    TEXT,
+    locked_until TIMESTAMP WITH TIME ZONE,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_accrual_jobs_next_run_at ON accrual_jobs (next_run_at);
+
+CREATE TABLE IF NOT EXISTS order_status_transitions
+(
+    id          UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    order_id    UUID                     NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    from_status OrderStatus,
+    to_status   OrderStatus              NOT NULL,
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_status_transitions_order_id ON order_status_transitions (order_id);
+
+CREATE TYPE LedgerDirection AS ENUM ('DEBIT', 'CREDIT');
+
+CREATE TABLE IF NOT EXISTS ledger_entries
+(
+    id             UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    transaction_id UUID                     NOT NULL,
+    account        TEXT                     NOT NULL,
+    direction      LedgerDirection          NOT NULL,
+    amount         NUMERIC(10, 2)           NOT NULL CHECK (amount > 0),
+    reference      TEXT                     NOT NULL,
+    created_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_account ON ledger_entries (account, created_at);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_transaction_id ON ledger_entries (transaction_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_reference ON ledger_entries (reference);
+
+CREATE TABLE IF NOT EXISTS user_balances
+(
+    user_id    UUID PRIMARY KEY         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    current    NUMERIC(12, 2)           NOT NULL DEFAULT 0 CHECK (current >= 0),
+    withdrawn  NUMERIC(12, 2)           NOT NULL DEFAULT 0 CHECK (withdrawn >= 0),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    held       NUMERIC(12, 2)           NOT NULL DEFAULT 0 CHECK (held >= 0)
+);
+
+CREATE TYPE HoldStatus AS ENUM ('ACTIVE', 'CAPTURED', 'VOIDED', 'EXPIRED');
+
+CREATE TABLE IF NOT EXISTS balance_holds
+(
+    id           UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    user_id      UUID                     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    order_number VARCHAR(255) UNIQUE      NOT NULL,
+    amount       NUMERIC(10, 2)           NOT NULL CHECK (amount > 0),
+    status       HoldStatus               NOT NULL DEFAULT 'ACTIVE',
+    expires_at   TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_balance_holds_user_id ON balance_holds (user_id);
+CREATE INDEX IF NOT EXISTS idx_balance_holds_active_expires_at ON balance_holds (expires_at) WHERE status = 'ACTIVE';
+
+CREATE TABLE IF NOT EXISTS withdrawal_refunds
+(
+    id            UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    withdrawal_id UUID                     NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    amount        NUMERIC(10, 2)           NOT NULL CHECK (amount > 0),
+    created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_withdrawal_refunds_withdrawal_id ON withdrawal_refunds (withdrawal_id);
+
+CREATE TABLE IF NOT EXISTS point_lots
+(
+    id         UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    user_id    UUID                     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    reference  TEXT                     NOT NULL,
+    amount     NUMERIC(12, 2)           NOT NULL CHECK (amount > 0),
+    remaining  NUMERIC(12, 2)           NOT NULL CHECK (remaining >= 0 AND remaining <= amount),
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_point_lots_user_id_expires_at ON point_lots (user_id, expires_at) WHERE remaining > 0;
+CREATE INDEX IF NOT EXISTS idx_point_lots_expires_at ON point_lots (expires_at) WHERE remaining > 0;
+
+CREATE TABLE IF NOT EXISTS point_lot_allocations
+(
+    id         UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    lot_id     UUID                     NOT NULL REFERENCES point_lots (id) ON DELETE CASCADE,
+    reference  TEXT                     NOT NULL,
+    amount     NUMERIC(12, 2)           NOT NULL CHECK (amount >= 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_point_lot_allocations_reference ON point_lot_allocations (reference);
+
+CREATE TABLE IF NOT EXISTS campaigns
+(
+    id               UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    name             TEXT                     NOT NULL,
+    starts_at        TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at          TIMESTAMP WITH TIME ZONE,
+    days_of_week     SMALLINT[],
+    first_order_only BOOLEAN                  NOT NULL DEFAULT FALSE,
+    cohort           TEXT,
+    multiplier       NUMERIC(6, 2)            NOT NULL DEFAULT 1 CHECK (multiplier >= 1),
+    bonus            NUMERIC(10, 2)           NOT NULL DEFAULT 0 CHECK (bonus >= 0),
+    budget           NUMERIC(12, 2) CHECK (budget >= 0),
+    spent            NUMERIC(12, 2)           NOT NULL DEFAULT 0 CHECK (spent >= 0 AND spent <= COALESCE(budget, spent)),
+    created_at       TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_at IS NULL OR ends_at > starts_at),
+    CHECK (multiplier > 1 OR bonus > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_campaigns_window ON campaigns (starts_at, ends_at);
+
+CREATE TABLE IF NOT EXISTS user_cohorts
+(
+    cohort  TEXT NOT NULL,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    PRIMARY KEY (cohort, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS order_campaign_bonuses
+(
+    id          UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    order_id    UUID                     NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    campaign_id UUID                     NOT NULL REFERENCES campaigns (id),
+    amount      NUMERIC(10, 2)           NOT NULL CHECK (amount > 0),
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (order_id, campaign_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_campaign_bonuses_campaign_id ON order_campaign_bonuses (campaign_id);
+
+CREATE TABLE IF NOT EXISTS user_tiers
+(
+    user_id            UUID PRIMARY KEY         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    tier               TEXT                     NOT NULL,
+    volume             NUMERIC(12, 2)           NOT NULL DEFAULT 0,
+    accrual_multiplier NUMERIC(6, 2)            NOT NULL DEFAULT 1 CHECK (accrual_multiplier >= 1),
+    withdrawal_limit   NUMERIC(12, 2)           NOT NULL DEFAULT 0 CHECK (withdrawal_limit >= 0),
+    updated_at         TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS user_tier_history
+(
+    id         UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    user_id    UUID                     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    from_tier  TEXT,
+    to_tier    TEXT                     NOT NULL,
+    volume     NUMERIC(12, 2)           NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_tier_history_user_id ON user_tier_history (user_id, created_at);
+
+CREATE TABLE IF NOT EXISTS order_tier_bonuses
+(
+    order_id   UUID PRIMARY KEY         NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    tier       TEXT                     NOT NULL,
+    amount     NUMERIC(10, 2)           NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_user_id_processed_at ON orders (user_id, processed_at) WHERE status = 'PROCESSED';
+
+CREATE TABLE IF NOT EXISTS transfers
+(
+    id           UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    sender_id    UUID                     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    recipient_id UUID                     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    amount       NUMERIC(10, 2)           NOT NULL CHECK (amount > 0),
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (sender_id <> recipient_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transfers_sender_id ON transfers (sender_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_transfers_recipient_id ON transfers (recipient_id, created_at);
+
+CREATE TABLE IF NOT EXISTS gift_code_batches
+(
+    id              UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    name            TEXT                     NOT NULL,
+    amount          NUMERIC(10, 2)           NOT NULL CHECK (amount > 0),
+    max_redemptions INTEGER                  NOT NULL CHECK (max_redemptions > 0),
+    expires_at      TIMESTAMP WITH TIME ZONE,
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS gift_codes
+(
+    id          UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    batch_id    UUID                     NOT NULL REFERENCES gift_code_batches (id) ON DELETE CASCADE,
+    code        TEXT                     NOT NULL UNIQUE,
+    redemptions INTEGER                  NOT NULL DEFAULT 0 CHECK (redemptions >= 0),
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_gift_codes_batch_id ON gift_codes (batch_id);
+
+CREATE TABLE IF NOT EXISTS gift_code_redemptions
+(
+    id           UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    gift_code_id UUID                     NOT NULL REFERENCES gift_codes (id) ON DELETE CASCADE,
+    user_id      UUID                     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    amount       NUMERIC(10, 2)           NOT NULL CHECK (amount > 0),
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (gift_code_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_gift_code_redemptions_user_id ON gift_code_redemptions (user_id);
+
+CREATE TYPE ReferralStatus AS ENUM ('PENDING', 'REWARDED', 'REJECTED');
+
+CREATE TABLE IF NOT EXISTS referrals
+(
+    referred_id     UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    referrer_id     UUID                     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status          ReferralStatus           NOT NULL DEFAULT 'PENDING',
+    reason          TEXT,
+    order_id        UUID REFERENCES orders (id) ON DELETE SET NULL,
+    referrer_reward NUMERIC(10, 2)           NOT NULL DEFAULT 0 CHECK (referrer_reward >= 0),
+    referred_reward NUMERIC(10, 2)           NOT NULL DEFAULT 0 CHECK (referred_reward >= 0),
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    settled_at      TIMESTAMP WITH TIME ZONE,
+    CHECK (referrer_id <> referred_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_referrals_referrer_id ON referrals (referrer_id, created_at);
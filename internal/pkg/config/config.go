@@ -2,9 +2,11 @@ package config
 
 import (
 	"flag"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
 )
 
 const dotEnvFile = ".env"
@@ -15,6 +17,62 @@ type Config struct {
 	AccrualSystemAddress string `env:"ACCRUAL_SYSTEM_ADDRESS" envDefault:":8081"`
 	LogLevel             string `env:"LOG_LEVEL" envDefault:"info"`
 	SecretKey            string `env:"SECRET_KEY,required,notEmpty"`
+
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"10s"`
+	IdempotencyTTL  time.Duration `env:"IDEMPOTENCY_TTL" envDefault:"24h"`
+	// IdempotencyLease is how long a request holds its key before a retry may take it over.
+	IdempotencyLease time.Duration `env:"IDEMPOTENCY_LEASE" envDefault:"1m"`
+
+	HoldTTL            time.Duration `env:"HOLD_TTL" envDefault:"15m"`
+	HoldExpiryInterval time.Duration `env:"HOLD_EXPIRY_INTERVAL" envDefault:"30s"`
+
+	// PointsTTL of zero keeps accrued points forever, which is the default.
+	PointsTTL                time.Duration `env:"POINTS_TTL" envDefault:"0"`
+	PointsExpiringSoonWindow time.Duration `env:"POINTS_EXPIRING_SOON_WINDOW" envDefault:"720h"`
+	PointsExpiryInterval     time.Duration `env:"POINTS_EXPIRY_INTERVAL" envDefault:"1h"`
+
+	// LoyaltyTiers are NAME:THRESHOLD:ACCRUAL_MULTIPLIER:WITHDRAWAL_LIMIT, lowest first.
+	// A withdrawal limit of 0 is unlimited. The default single tier neither multiplies
+	// accruals nor limits withdrawals, e.g. BRONZE:0:1:0,SILVER:1000:1.1:0 adds a level.
+	LoyaltyTiers       []string      `env:"LOYALTY_TIERS" envDefault:"STANDARD:0:1:0"`
+	TierWindow         time.Duration `env:"TIER_WINDOW" envDefault:"2160h"`
+	TierRecalcInterval time.Duration `env:"TIER_RECALC_INTERVAL" envDefault:"1h"`
+
+	// TransferDailyLimit caps the points a user sends to others per 24 hours; zero, the default, is unlimited.
+	TransferDailyLimit decimal.Decimal `env:"TRANSFER_DAILY_LIMIT" envDefault:"0"`
+
+	// Referral bonuses are paid once the referred user's first order is processed with at least
+	// ReferralMinAccrual. ReferralMaxRewards of zero is unlimited. No bonuses are paid by default.
+	ReferrerBonus      decimal.Decimal `env:"REFERRER_BONUS" envDefault:"0"`
+	ReferredBonus      decimal.Decimal `env:"REFERRED_BONUS" envDefault:"0"`
+	ReferralMaxRewards int64           `env:"REFERRAL_MAX_REWARDS" envDefault:"0"`
+	ReferralMinAccrual decimal.Decimal `env:"REFERRAL_MIN_ACCRUAL" envDefault:"0"`
+
+	// AdminAPIKey guards the admin API; when it is empty the admin API is disabled.
+	AdminAPIKey string `env:"ADMIN_API_KEY"`
+
+	LogRedactHeaders []string `env:"LOG_REDACT_HEADERS" envDefault:"Authorization,Proxy-Authorization,X-Api-Key"`
+	LogRedactCookies []string `env:"LOG_REDACT_COOKIES" envDefault:"JWT"`
+	LogRedactFields  []string `env:"LOG_REDACT_FIELDS" envDefault:"password,token,secret"`
+	LogBodyMaxBytes  int      `env:"LOG_BODY_MAX_BYTES" envDefault:"2048"`
+
+	TracingExporter string `env:"TRACING_EXPORTER" envDefault:"none"`
+	TracingFile     string `env:"TRACING_FILE" envDefault:"traces.jsonl"`
+
+	AccrualWorkers      int           `env:"ACCRUAL_WORKERS" envDefault:"4"`
+	AccrualQueueSize    int           `env:"ACCRUAL_QUEUE_SIZE" envDefault:"64"`
+	AccrualOrderTimeout time.Duration `env:"ACCRUAL_ORDER_TIMEOUT" envDefault:"1m"`
+	AccrualJobLease     time.Duration `env:"ACCRUAL_JOB_LEASE" envDefault:"30m"`
+
+	AccrualConnectTimeout          time.Duration `env:"ACCRUAL_CONNECT_TIMEOUT" envDefault:"2s"`
+	AccrualReadTimeout             time.Duration `env:"ACCRUAL_READ_TIMEOUT" envDefault:"5s"`
+	AccrualBreakerFailures         int           `env:"ACCRUAL_BREAKER_FAILURES" envDefault:"5"`
+	AccrualBreakerOpenTimeout      time.Duration `env:"ACCRUAL_BREAKER_OPEN_TIMEOUT" envDefault:"30s"`
+	AccrualBreakerHalfOpenRequests int           `env:"ACCRUAL_BREAKER_HALF_OPEN_REQUESTS" envDefault:"1"`
+
+	AccrualRetryBaseDelay time.Duration `env:"ACCRUAL_RETRY_BASE_DELAY" envDefault:"1s"`
+	AccrualRetryMaxDelay  time.Duration `env:"ACCRUAL_RETRY_MAX_DELAY" envDefault:"10m"`
+	AccrualOrderMaxAge    time.Duration `env:"ACCRUAL_ORDER_MAX_AGE" envDefault:"0"`
 }
 
 func LoadConfig() (Config, error) {
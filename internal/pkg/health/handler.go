@@ -0,0 +1,76 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const checkTimeout = 2 * time.Second
+
+type Response struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// NewLivenessHandler reports that the process is running. It does not touch dependencies,
+// so a slow database never gets the instance restarted.
+func NewLivenessHandler() http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		writeResponse(rw, http.StatusOK, Response{Status: StatusUp})
+	}
+}
+
+// NewReadinessHandler runs all checks in parallel and answers 503 when any of them is down.
+func NewReadinessHandler(checks ...Check) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithTimeout(req.Context(), checkTimeout)
+		defer cancel()
+
+		results := make([]Result, len(checks))
+		var wg sync.WaitGroup
+		for i, check := range checks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i] = check.Check(ctx)
+			}()
+		}
+		wg.Wait()
+
+		response := Response{
+			Status: StatusUp,
+			Checks: make(map[string]Result, len(checks)),
+		}
+		for i, check := range checks {
+			result := results[i]
+			response.Checks[check.Name] = result
+			if result.Status == StatusDown {
+				response.Status = StatusDown
+			} else if result.Status == StatusDegraded && response.Status == StatusUp {
+				response.Status = StatusDegraded
+			}
+		}
+
+		statusCode := http.StatusOK
+		if response.Status == StatusDown {
+			logger.Log.Warn("health: instance is not ready", zap.Any("checks", response.Checks))
+			statusCode = http.StatusServiceUnavailable
+		}
+		writeResponse(rw, statusCode, response)
+	}
+}
+
+func writeResponse(rw http.ResponseWriter, statusCode int, response Response) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.WriteHeader(statusCode)
+	if err := json.NewEncoder(rw).Encode(response); err != nil {
+		logger.Log.Error("health: failed to encode response", zap.Error(err))
+	}
+}
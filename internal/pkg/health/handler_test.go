@@ -0,0 +1,156 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aifedorov/gophermart/internal/client/accrual"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubPinger struct {
+	err error
+}
+
+func (p stubPinger) Ping(ctx context.Context) error {
+	return p.err
+}
+
+type stubMigrationSource struct {
+	version uint
+	dirty   bool
+	err     error
+}
+
+func (s stubMigrationSource) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	return s.version, s.dirty, s.err
+}
+
+type stubBreaker struct {
+	state accrual.BreakerState
+}
+
+func (b stubBreaker) BreakerState() accrual.BreakerState {
+	return b.state
+}
+
+func TestLivenessHandler(t *testing.T) {
+	t.Parallel()
+
+	rw := httptest.NewRecorder()
+	NewLivenessHandler()(rw, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.JSONEq(t, `{"status":"up"}`, rw.Body.String())
+}
+
+func TestReadinessHandler(t *testing.T) {
+	t.Parallel()
+
+	type want struct {
+		code     int
+		status   Status
+		postgres Status
+		schema   Status
+		accrual  Status
+	}
+	tests := []struct {
+		name       string
+		pinger     stubPinger
+		migrations stubMigrationSource
+		breaker    stubBreaker
+		want       want
+	}{
+		{
+			name:       "all dependencies are up",
+			migrations: stubMigrationSource{version: 3},
+			breaker:    stubBreaker{state: accrual.BreakerClosed},
+			want: want{
+				code:     http.StatusOK,
+				status:   StatusUp,
+				postgres: StatusUp,
+				schema:   StatusUp,
+				accrual:  StatusUp,
+			},
+		},
+		{
+			name:       "open circuit degrades but stays ready",
+			migrations: stubMigrationSource{version: 3},
+			breaker:    stubBreaker{state: accrual.BreakerOpen},
+			want: want{
+				code:     http.StatusOK,
+				status:   StatusDegraded,
+				postgres: StatusUp,
+				schema:   StatusUp,
+				accrual:  StatusDegraded,
+			},
+		},
+		{
+			name:       "database is unreachable",
+			pinger:     stubPinger{err: errors.New("connection refused")},
+			migrations: stubMigrationSource{err: errors.New("connection refused")},
+			breaker:    stubBreaker{state: accrual.BreakerClosed},
+			want: want{
+				code:     http.StatusServiceUnavailable,
+				status:   StatusDown,
+				postgres: StatusDown,
+				schema:   StatusDown,
+				accrual:  StatusUp,
+			},
+		},
+		{
+			name:       "schema is behind",
+			migrations: stubMigrationSource{version: 2},
+			breaker:    stubBreaker{state: accrual.BreakerClosed},
+			want: want{
+				code:     http.StatusServiceUnavailable,
+				status:   StatusDown,
+				postgres: StatusUp,
+				schema:   StatusDown,
+				accrual:  StatusUp,
+			},
+		},
+		{
+			name:       "dirty migration",
+			migrations: stubMigrationSource{version: 3, dirty: true},
+			breaker:    stubBreaker{state: accrual.BreakerClosed},
+			want: want{
+				code:     http.StatusServiceUnavailable,
+				status:   StatusDown,
+				postgres: StatusUp,
+				schema:   StatusDown,
+				accrual:  StatusUp,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := NewReadinessHandler(
+				NewPostgresCheck(tt.pinger),
+				NewMigrationCheck(tt.migrations, 3),
+				NewAccrualCheck(tt.breaker),
+			)
+			rw := httptest.NewRecorder()
+			handler(rw, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			assert.Equal(t, tt.want.code, rw.Code)
+			assert.Equal(t, "application/json", rw.Header().Get("Content-Type"))
+
+			var res Response
+			require.NoError(t, json.NewDecoder(rw.Body).Decode(&res))
+			assert.Equal(t, tt.want.status, res.Status)
+			assert.Equal(t, tt.want.postgres, res.Checks["postgres"].Status)
+			assert.Equal(t, tt.want.schema, res.Checks["migrations"].Status)
+			assert.Equal(t, tt.want.accrual, res.Checks["accrual"].Status)
+		})
+	}
+}
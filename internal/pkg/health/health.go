@@ -0,0 +1,90 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aifedorov/gophermart/internal/client/accrual"
+)
+
+type Status string
+
+const (
+	StatusUp       Status = "up"
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+type Result struct {
+	Status Status `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Check reports the state of a single dependency.
+// Only StatusDown makes the instance not ready.
+type Check struct {
+	Name  string
+	Check func(ctx context.Context) Result
+}
+
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type MigrationSource interface {
+	MigrationVersion(ctx context.Context) (version uint, dirty bool, err error)
+}
+
+type BreakerStater interface {
+	BreakerState() accrual.BreakerState
+}
+
+func NewPostgresCheck(db Pinger) Check {
+	return Check{
+		Name: "postgres",
+		Check: func(ctx context.Context) Result {
+			if err := db.Ping(ctx); err != nil {
+				return Result{Status: StatusDown, Error: err.Error()}
+			}
+			return Result{Status: StatusUp}
+		},
+	}
+}
+
+// NewMigrationCheck compares the applied schema version with the one the binary was built for.
+func NewMigrationCheck(source MigrationSource, expected uint) Check {
+	return Check{
+		Name: "migrations",
+		Check: func(ctx context.Context) Result {
+			version, dirty, err := source.MigrationVersion(ctx)
+			if err != nil {
+				return Result{Status: StatusDown, Error: err.Error()}
+			}
+
+			detail := fmt.Sprintf("version %d, expected %d", version, expected)
+			if dirty {
+				return Result{Status: StatusDown, Error: "migration is dirty", Detail: detail}
+			}
+			if version < expected {
+				return Result{Status: StatusDown, Error: "schema is behind the binary", Detail: detail}
+			}
+			return Result{Status: StatusUp, Detail: detail}
+		},
+	}
+}
+
+// NewAccrualCheck reports the accrual circuit state. An open circuit only degrades
+// the instance: user requests are still served and accrual jobs wait in the queue.
+func NewAccrualCheck(client BreakerStater) Check {
+	return Check{
+		Name: "accrual",
+		Check: func(ctx context.Context) Result {
+			state := client.BreakerState()
+			if state == accrual.BreakerClosed {
+				return Result{Status: StatusUp, Detail: "circuit " + state.String()}
+			}
+			return Result{Status: StatusDegraded, Detail: "circuit " + state.String()}
+		},
+	}
+}
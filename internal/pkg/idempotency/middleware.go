@@ -0,0 +1,194 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/aifedorov/gophermart/internal/pkg/middleware"
+	"go.uber.org/zap"
+)
+
+const (
+	KeyHeader      = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+)
+
+const (
+	maxKeyLength   = 255
+	maxRequestBody = 1 << 20
+)
+
+type Middleware struct {
+	store Store
+	ttl   time.Duration
+	// lease is how long a request holds its key. A retry after the lease takes over a key
+	// left behind by a request that crashed or timed out, so it must outlast any request.
+	lease time.Duration
+}
+
+func NewMiddleware(store Store, ttl, lease time.Duration) *Middleware {
+	return &Middleware{
+		store: store,
+		ttl:   ttl,
+		lease: lease,
+	}
+}
+
+// ScopeFunc returns the user whose keys a request uses. Requests it fails for are not made idempotent.
+type ScopeFunc func(r *http.Request) (string, error)
+
+// Handle replays the stored response for a repeated Idempotency-Key. Requests without the
+// header, or without an authenticated user, are passed through untouched.
+// Must be mounted after CheckJWT, because keys are scoped per user.
+func (m *Middleware) Handle(next http.Handler) http.Handler {
+	return m.HandleFor(middleware.GetUserID)(next)
+}
+
+// HandleFor works like Handle, but scopes keys to the user that scope returns. It serves routes
+// where the user does not come from the caller's token, such as the admin API.
+func (m *Middleware) HandleFor(scope ScopeFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return m.handle(scope, next)
+	}
+}
+
+func (m *Middleware) handle(scope ScopeFunc, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(KeyHeader)
+		userID, err := scope(r)
+		if key == "" || err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		log := logger.FromContext(r.Context()).With(zap.String("idempotencyKey", key))
+		if len(key) > maxKeyLength {
+			log.Info("idempotency: key is too long")
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		// One byte over the limit tells a body that is too large from one that fits exactly.
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBody+1))
+		if err != nil {
+			log.Info("idempotency: failed to read request body", zap.Error(err))
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		if len(body) > maxRequestBody {
+			log.Info("idempotency: request body is too large")
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		requestHash := hashRequest(r, body)
+		record, reserved, err := m.store.Reserve(r.Context(), userID, key, requestHash, m.ttl, m.lease)
+		if errors.Is(err, ErrUnknownUser) {
+			// Keys cannot be stored for a user that does not exist; the handler reports it.
+			log.Info("idempotency: unknown user")
+			next.ServeHTTP(w, r)
+			return
+		}
+		if err != nil {
+			log.Error("idempotency: failed to reserve key", zap.Error(err))
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		if !reserved {
+			switch {
+			case record.RequestHash != requestHash:
+				log.Info("idempotency: key reused with a different request")
+				http.Error(w, http.StatusText(http.StatusUnprocessableEntity), http.StatusUnprocessableEntity)
+			case !record.Completed():
+				log.Info("idempotency: request with the same key is still in progress")
+				http.Error(w, http.StatusText(http.StatusConflict), http.StatusConflict)
+			default:
+				log.Debug("idempotency: replaying stored response", zap.Int("status", record.StatusCode))
+				replay(w, record)
+			}
+			return
+		}
+
+		rec := &recordingResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		// The response is already sent, so bookkeeping must not depend on the client staying connected.
+		ctx := context.WithoutCancel(r.Context())
+		if rec.status() >= http.StatusInternalServerError {
+			// Server errors are not final, the client should be able to retry with the same key.
+			err = m.store.Release(ctx, userID, key, record.Token)
+			if err != nil {
+				log.Error("idempotency: failed to release key", zap.Error(err))
+			}
+			return
+		}
+
+		err = m.store.Complete(ctx, userID, key, Record{
+			Token:       record.Token,
+			RequestHash: requestHash,
+			StatusCode:  rec.status(),
+			ContentType: rec.Header().Get("Content-Type"),
+			Body:        rec.body.Bytes(),
+		})
+		if err != nil {
+			log.Error("idempotency: failed to store response", zap.Error(err))
+		}
+	})
+}
+
+func replay(w http.ResponseWriter, record Record) {
+	if record.ContentType != "" {
+		w.Header().Set("Content-Type", record.ContentType)
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(record.StatusCode)
+	_, _ = w.Write(record.Body)
+}
+
+// hashRequest binds a key to the exact operation, so the same key cannot be reused for another route or payload.
+func hashRequest(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.Path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (w *recordingResponseWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *recordingResponseWriter) Write(b []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingResponseWriter) status() int {
+	if w.statusCode == 0 {
+		return http.StatusOK
+	}
+	return w.statusCode
+}
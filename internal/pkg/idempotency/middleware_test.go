@@ -0,0 +1,423 @@
+package idempotency
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aifedorov/gophermart/internal/pkg/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore struct {
+	mu      sync.Mutex
+	records map[string]memoryRecord
+	tokens  int
+}
+
+type memoryRecord struct {
+	Record
+	lockedUntil time.Time
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{records: make(map[string]memoryRecord)}
+}
+
+func (s *memoryStore) Reserve(_ context.Context, userID, key, requestHash string, _, lease time.Duration) (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[userID+"/"+key]
+	if ok && (record.Completed() || time.Now().Before(record.lockedUntil)) {
+		return record.Record, false, nil
+	}
+	s.tokens++
+	reserved := Record{Token: strconv.Itoa(s.tokens), RequestHash: requestHash}
+	s.records[userID+"/"+key] = memoryRecord{
+		Record:      reserved,
+		lockedUntil: time.Now().Add(lease),
+	}
+	return reserved, true, nil
+}
+
+func (s *memoryStore) Complete(_ context.Context, userID, key string, record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.records[userID+"/"+key]
+	if !ok || stored.Token != record.Token || stored.Completed() {
+		return nil
+	}
+	s.records[userID+"/"+key] = memoryRecord{Record: record}
+	return nil
+}
+
+func (s *memoryStore) Release(_ context.Context, userID, key, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.records[userID+"/"+key]
+	if !ok || stored.Token != token || stored.Completed() {
+		return nil
+	}
+	delete(s.records, userID+"/"+key)
+	return nil
+}
+
+type response struct {
+	statusCode int
+	body       string
+	replayed   bool
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	type request struct {
+		userID string
+		key    string
+		body   string
+	}
+
+	tests := []struct {
+		name          string
+		handlerStatus int
+		requests      []request
+		want          []response
+		wantCalls     int
+	}{
+		{
+			name:          "replays stored response on retry",
+			handlerStatus: http.StatusOK,
+			requests: []request{
+				{userID: "u1", key: "k1", body: `{"sum":10}`},
+				{userID: "u1", key: "k1", body: `{"sum":10}`},
+			},
+			want: []response{
+				{statusCode: http.StatusOK, body: "call 1"},
+				{statusCode: http.StatusOK, body: "call 1", replayed: true},
+			},
+			wantCalls: 1,
+		},
+		{
+			name:          "replays client errors",
+			handlerStatus: http.StatusPaymentRequired,
+			requests: []request{
+				{userID: "u1", key: "k1", body: `{"sum":10}`},
+				{userID: "u1", key: "k1", body: `{"sum":10}`},
+			},
+			want: []response{
+				{statusCode: http.StatusPaymentRequired, body: "call 1"},
+				{statusCode: http.StatusPaymentRequired, body: "call 1", replayed: true},
+			},
+			wantCalls: 1,
+		},
+		{
+			name:          "rejects key reused with different body",
+			handlerStatus: http.StatusOK,
+			requests: []request{
+				{userID: "u1", key: "k1", body: `{"sum":10}`},
+				{userID: "u1", key: "k1", body: `{"sum":20}`},
+			},
+			want: []response{
+				{statusCode: http.StatusOK, body: "call 1"},
+				{statusCode: http.StatusUnprocessableEntity, body: "Unprocessable Entity\n"},
+			},
+			wantCalls: 1,
+		},
+		{
+			name:          "scopes keys per user",
+			handlerStatus: http.StatusOK,
+			requests: []request{
+				{userID: "u1", key: "k1", body: `{"sum":10}`},
+				{userID: "u2", key: "k1", body: `{"sum":10}`},
+			},
+			want: []response{
+				{statusCode: http.StatusOK, body: "call 1"},
+				{statusCode: http.StatusOK, body: "call 2"},
+			},
+			wantCalls: 2,
+		},
+		{
+			name:          "does not store server errors",
+			handlerStatus: http.StatusInternalServerError,
+			requests: []request{
+				{userID: "u1", key: "k1", body: `{"sum":10}`},
+				{userID: "u1", key: "k1", body: `{"sum":10}`},
+			},
+			want: []response{
+				{statusCode: http.StatusInternalServerError, body: "call 1"},
+				{statusCode: http.StatusInternalServerError, body: "call 2"},
+			},
+			wantCalls: 2,
+		},
+		{
+			name:          "passes through requests without key",
+			handlerStatus: http.StatusOK,
+			requests: []request{
+				{userID: "u1", body: `{"sum":10}`},
+				{userID: "u1", body: `{"sum":10}`},
+			},
+			want: []response{
+				{statusCode: http.StatusOK, body: "call 1"},
+				{statusCode: http.StatusOK, body: "call 2"},
+			},
+			wantCalls: 2,
+		},
+		{
+			name:          "passes through unauthenticated requests",
+			handlerStatus: http.StatusOK,
+			requests: []request{
+				{key: "k1", body: `{"sum":10}`},
+				{key: "k1", body: `{"sum":10}`},
+			},
+			want: []response{
+				{statusCode: http.StatusOK, body: "call 1"},
+				{statusCode: http.StatusOK, body: "call 2"},
+			},
+			wantCalls: 2,
+		},
+		{
+			name:          "rejects too long key",
+			handlerStatus: http.StatusOK,
+			requests: []request{
+				{userID: "u1", key: strings.Repeat("k", maxKeyLength+1), body: `{"sum":10}`},
+			},
+			want: []response{
+				{statusCode: http.StatusBadRequest, body: "Bad Request\n"},
+			},
+		},
+		{
+			name:          "rejects too large body",
+			handlerStatus: http.StatusOK,
+			requests: []request{
+				{userID: "u1", key: "k1", body: strings.Repeat("1", maxRequestBody+1)},
+			},
+			want: []response{
+				{statusCode: http.StatusRequestEntityTooLarge, body: "Request Entity Too Large\n"},
+			},
+		},
+		{
+			name:          "accepts body at the limit",
+			handlerStatus: http.StatusOK,
+			requests: []request{
+				{userID: "u1", key: "k1", body: strings.Repeat("1", maxRequestBody)},
+			},
+			want: []response{
+				{statusCode: http.StatusOK, body: "call 1"},
+			},
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			calls := 0
+			handler := func(rw http.ResponseWriter, req *http.Request) {
+				calls++
+				body, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				assert.NotEmpty(t, body)
+
+				rw.WriteHeader(tt.handlerStatus)
+				_, _ = io.WriteString(rw, "call "+string(rune('0'+calls)))
+			}
+			m := NewMiddleware(newMemoryStore(), time.Hour, time.Minute).Handle(http.HandlerFunc(handler))
+
+			for i, r := range tt.requests {
+				req := httptest.NewRequest(http.MethodPost, "/api/user/balance/withdraw", strings.NewReader(r.body))
+				if r.key != "" {
+					req.Header.Set(KeyHeader, r.key)
+				}
+				if r.userID != "" {
+					req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, r.userID))
+				}
+				rw := httptest.NewRecorder()
+
+				m.ServeHTTP(rw, req)
+
+				got := response{
+					statusCode: rw.Code,
+					body:       rw.Body.String(),
+					replayed:   rw.Header().Get(ReplayedHeader) == "true",
+				}
+				assert.Equal(t, tt.want[i], got, "request %d", i)
+			}
+			assert.Equal(t, tt.wantCalls, calls)
+		})
+	}
+}
+
+func TestMiddlewareInProgress(t *testing.T) {
+	t.Parallel()
+
+	store := newMemoryStore()
+	_, _, err := store.Reserve(context.Background(), "u1", "k1", hashRequest(
+		httptest.NewRequest(http.MethodPost, "/api/user/orders", nil), []byte("12345678903"),
+	), time.Hour, time.Minute)
+	require.NoError(t, err)
+
+	m := NewMiddleware(store, time.Hour, time.Minute).Handle(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		t.Fatal("handler must not be called while the first request is running")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/user/orders", strings.NewReader("12345678903"))
+	req.Header.Set(KeyHeader, "k1")
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "u1"))
+	rw := httptest.NewRecorder()
+
+	m.ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusConflict, rw.Code)
+}
+
+func TestMiddlewareTakesOverStaleReservation(t *testing.T) {
+	t.Parallel()
+
+	store := newMemoryStore()
+	// A zero lease stands for a request that crashed before it completed or released the key.
+	_, _, err := store.Reserve(context.Background(), "u1", "k1", hashRequest(
+		httptest.NewRequest(http.MethodPost, "/api/user/orders", nil), []byte("12345678903"),
+	), time.Hour, 0)
+	require.NoError(t, err)
+
+	calls := 0
+	m := NewMiddleware(store, time.Hour, time.Minute).Handle(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		calls++
+		rw.WriteHeader(http.StatusAccepted)
+	}))
+
+	for i, want := range []response{
+		{statusCode: http.StatusAccepted},
+		{statusCode: http.StatusAccepted, replayed: true},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/user/orders", strings.NewReader("12345678903"))
+		req.Header.Set(KeyHeader, "k1")
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "u1"))
+		rw := httptest.NewRecorder()
+
+		m.ServeHTTP(rw, req)
+
+		got := response{
+			statusCode: rw.Code,
+			body:       rw.Body.String(),
+			replayed:   rw.Header().Get(ReplayedHeader) == "true",
+		}
+		assert.Equal(t, want, got, "request %d", i)
+	}
+	assert.Equal(t, 1, calls)
+}
+
+func TestMiddlewareStaleRequestCannotTouchTakenOverKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		staleStatus int
+	}{
+		{
+			name:        "stale response is not stored",
+			staleStatus: http.StatusOK,
+		},
+		{
+			name:        "stale server error does not release the key",
+			staleStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			started := make(chan struct{})
+			unblock := make(chan struct{})
+			calls := 0
+			// A zero lease lets the retry take over the key while the first request is still running.
+			m := NewMiddleware(newMemoryStore(), time.Hour, 0).Handle(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				calls++
+				if calls == 1 {
+					close(started)
+					<-unblock
+					rw.WriteHeader(tt.staleStatus)
+					_, _ = io.WriteString(rw, "stale")
+					return
+				}
+				rw.WriteHeader(http.StatusOK)
+				_, _ = io.WriteString(rw, "retry")
+			}))
+
+			send := func() response {
+				req := httptest.NewRequest(http.MethodPost, "/api/user/balance/withdraw", strings.NewReader(`{"sum":10}`))
+				req.Header.Set(KeyHeader, "k1")
+				req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "u1"))
+				rw := httptest.NewRecorder()
+				m.ServeHTTP(rw, req)
+				return response{
+					statusCode: rw.Code,
+					body:       rw.Body.String(),
+					replayed:   rw.Header().Get(ReplayedHeader) == "true",
+				}
+			}
+
+			done := make(chan response)
+			go func() {
+				done <- send()
+			}()
+			<-started
+
+			assert.Equal(t, response{statusCode: http.StatusOK, body: "retry"}, send())
+			close(unblock)
+			assert.Equal(t, response{statusCode: tt.staleStatus, body: "stale"}, <-done)
+
+			assert.Equal(t, response{statusCode: http.StatusOK, body: "retry", replayed: true}, send())
+			assert.Equal(t, 2, calls)
+		})
+	}
+}
+
+func TestMiddlewareHandleFor(t *testing.T) {
+	t.Parallel()
+
+	// Admin requests carry no user token, the scope comes from the request itself.
+	scope := func(r *http.Request) (string, error) {
+		return r.Header.Get("X-User"), nil
+	}
+
+	calls := 0
+	m := NewMiddleware(newMemoryStore(), time.Hour, time.Minute).HandleFor(scope)(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		calls++
+		rw.WriteHeader(http.StatusOK)
+	}))
+
+	for i, want := range []response{
+		{statusCode: http.StatusOK},
+		{statusCode: http.StatusOK, replayed: true},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/users/u1/withdrawals/2377225624/refund", strings.NewReader(`{"sum":10}`))
+		req.Header.Set(KeyHeader, "k1")
+		req.Header.Set("X-User", "u1")
+		rw := httptest.NewRecorder()
+
+		m.ServeHTTP(rw, req)
+
+		got := response{
+			statusCode: rw.Code,
+			body:       rw.Body.String(),
+			replayed:   rw.Header().Get(ReplayedHeader) == "true",
+		}
+		assert.Equal(t, want, got, "request %d", i)
+	}
+	assert.Equal(t, 1, calls)
+}
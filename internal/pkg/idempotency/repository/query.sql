@@ -0,0 +1,46 @@
+-- name: ReserveIdempotencyKey :one
+-- An expired key, or a key whose request did not finish within its lease, is taken over in place,
+-- so no cleanup job is needed for correctness. Each reservation gets a new token.
+INSERT INTO idempotency_keys (user_id, key, request_hash, expires_at, locked_until, token)
+VALUES (sqlc.arg(user_id), sqlc.arg(key), sqlc.arg(request_hash),
+        CURRENT_TIMESTAMP + make_interval(secs => sqlc.arg(ttl_seconds)::FLOAT8),
+        CURRENT_TIMESTAMP + make_interval(secs => sqlc.arg(lease_seconds)::FLOAT8),
+        gen_random_uuid())
+ON CONFLICT (user_id, key) DO UPDATE
+    SET request_hash  = EXCLUDED.request_hash,
+        status_code   = NULL,
+        content_type  = NULL,
+        response_body = NULL,
+        created_at    = CURRENT_TIMESTAMP,
+        expires_at    = EXCLUDED.expires_at,
+        locked_until  = EXCLUDED.locked_until,
+        token         = EXCLUDED.token
+WHERE idempotency_keys.expires_at <= CURRENT_TIMESTAMP
+   OR (idempotency_keys.status_code IS NULL AND idempotency_keys.locked_until <= CURRENT_TIMESTAMP)
+RETURNING token;
+
+-- name: GetIdempotencyKey :one
+SELECT request_hash, status_code, content_type, response_body
+FROM idempotency_keys
+WHERE user_id = $1
+  AND key = $2;
+
+-- name: CompleteIdempotencyKey :exec
+-- Only the reservation that holds the key may complete it.
+UPDATE idempotency_keys
+SET status_code   = sqlc.arg(status_code),
+    content_type  = sqlc.arg(content_type),
+    response_body = sqlc.arg(response_body)
+WHERE user_id = sqlc.arg(user_id)
+  AND key = sqlc.arg(key)
+  AND token = sqlc.arg(token)
+  AND status_code IS NULL;
+
+-- name: ReleaseIdempotencyKey :exec
+-- Only the reservation that holds the key may release it, and only while it is pending.
+DELETE
+FROM idempotency_keys
+WHERE user_id = $1
+  AND key = $2
+  AND token = $3
+  AND status_code IS NULL;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys
+(
+    user_id       UUID                     NOT NULL,
+    key           VARCHAR(255)             NOT NULL,
+    request_hash  VARCHAR(64)              NOT NULL,
+    status_code   INTEGER,
+    content_type  VARCHAR(255),
+    response_body BYTEA,
+    created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at    TIMESTAMP WITH TIME ZONE NOT NULL,
+    locked_until  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    token         UUID                     NOT NULL DEFAULT gen_random_uuid(),
+    PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
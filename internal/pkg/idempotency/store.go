@@ -0,0 +1,131 @@
+// Package idempotency lets clients retry mutating requests safely: the first response
+// for an Idempotency-Key is stored and replayed for every retry with the same body.
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	repository "github.com/aifedorov/gophermart/internal/pkg/idempotency/repository/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrUnknownUser is returned by Reserve when the user the key is scoped to does not exist.
+var ErrUnknownUser = errors.New("idempotency: unknown user")
+
+// Record is a stored key. A record without a status code belongs to a request that is still running.
+type Record struct {
+	// Token identifies the reservation that holds the key. Only that reservation can complete
+	// or release it, so a request that lost the key to a retry after its lease cannot touch it.
+	Token       string
+	RequestHash string
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+func (r Record) Completed() bool {
+	return r.StatusCode != 0
+}
+
+type Store interface {
+	// Reserve claims the key for a new request, which holds it for the lease. When the key is
+	// already taken, has not expired and is completed or still within its lease, it returns the
+	// existing record and false.
+	Reserve(ctx context.Context, userID, key, requestHash string, ttl, lease time.Duration) (Record, bool, error)
+	// Complete stores the response that retries will get, if record.Token still holds the key.
+	Complete(ctx context.Context, userID, key string, record Record) error
+	// Release forgets the key, so the request can be retried from scratch. It does nothing
+	// unless token still holds the key and the key is not completed.
+	Release(ctx context.Context, userID, key, token string) error
+}
+
+type postgresStore struct {
+	queries *repository.Queries
+}
+
+func NewPostgresStore(pgpool *pgxpool.Pool) Store {
+	return &postgresStore{queries: repository.New(pgpool)}
+}
+
+func (s *postgresStore) Reserve(ctx context.Context, userID, key, requestHash string, ttl, lease time.Duration) (Record, bool, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return Record{}, false, err
+	}
+
+	token, err := s.queries.ReserveIdempotencyKey(ctx, repository.ReserveIdempotencyKeyParams{
+		UserID:       id,
+		Key:          key,
+		RequestHash:  requestHash,
+		TtlSeconds:   ttl.Seconds(),
+		LeaseSeconds: lease.Seconds(),
+	})
+	if err == nil {
+		return Record{Token: token.String(), RequestHash: requestHash}, true, nil
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+		return Record{}, false, ErrUnknownUser
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return Record{}, false, err
+	}
+
+	dbKey, err := s.queries.GetIdempotencyKey(ctx, repository.GetIdempotencyKeyParams{
+		UserID: id,
+		Key:    key,
+	})
+	if err != nil {
+		return Record{}, false, err
+	}
+	return Record{
+		RequestHash: dbKey.RequestHash,
+		StatusCode:  int(dbKey.StatusCode.Int32),
+		ContentType: dbKey.ContentType.String,
+		Body:        dbKey.ResponseBody,
+	}, false, nil
+}
+
+func (s *postgresStore) Complete(ctx context.Context, userID, key string, record Record) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return err
+	}
+	token, err := uuid.Parse(record.Token)
+	if err != nil {
+		return err
+	}
+
+	return s.queries.CompleteIdempotencyKey(ctx, repository.CompleteIdempotencyKeyParams{
+		StatusCode:   pgtype.Int4{Int32: int32(record.StatusCode), Valid: true},
+		ContentType:  pgtype.Text{String: record.ContentType, Valid: true},
+		ResponseBody: record.Body,
+		UserID:       id,
+		Key:          key,
+		Token:        token,
+	})
+}
+
+func (s *postgresStore) Release(ctx context.Context, userID, key, token string) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return err
+	}
+	tokenID, err := uuid.Parse(token)
+	if err != nil {
+		return err
+	}
+
+	return s.queries.ReleaseIdempotencyKey(ctx, repository.ReleaseIdempotencyKeyParams{
+		UserID: id,
+		Key:    key,
+		Token:  tokenID,
+	})
+}
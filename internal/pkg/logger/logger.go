@@ -1,18 +1,24 @@
 package logger
 
 import (
+	"context"
+
 	"go.uber.org/zap"
 )
 
 var Log = zap.NewNop()
 
+type contextKey struct{}
+
+// Initialize builds a JSON logger with the given level. Secrets must be redacted by
+// the caller, see middleware.Redactor.
 func Initialize(level string) error {
 	lvl, err := zap.ParseAtomicLevel(level)
 	if err != nil {
 		return err
 	}
 
-	cfg := zap.NewDevelopmentConfig()
+	cfg := zap.NewProductionConfig()
 	cfg.Level = lvl
 
 	zl, err := cfg.Build()
@@ -23,3 +29,16 @@ func Initialize(level string) error {
 
 	return nil
 }
+
+// WithContext stores a request-scoped logger in the context.
+func WithContext(ctx context.Context, l *zap.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the request-scoped logger, or the global one outside of a request.
+func FromContext(ctx context.Context) *zap.Logger {
+	if l, ok := ctx.Value(contextKey{}).(*zap.Logger); ok {
+		return l
+	}
+	return Log
+}
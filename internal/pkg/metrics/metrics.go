@@ -0,0 +1,114 @@
+// Package metrics holds the Prometheus collectors exposed on /metrics.
+// Labels must stay low-cardinality: never put user IDs or order numbers into them.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "gophermart"
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by method, chi route pattern and response code.",
+	}, []string{"method", "route", "code"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method and chi route pattern.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	AccrualRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "accrual",
+		Name:      "requests_total",
+		Help:      "Calls to the accrual system by response code and outcome.",
+	}, []string{"code", "outcome"})
+
+	AccrualBreakerState = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "accrual",
+		Name:      "circuit_state",
+		Help:      "Accrual circuit breaker state: 0 closed, 1 half-open, 2 open.",
+	})
+
+	AccrualBreakerStateChanges = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "accrual",
+		Name:      "breaker_state_changes_total",
+		Help:      "Accrual circuit breaker state changes.",
+	}, []string{"from", "to"})
+
+	OrderStatusTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "orders",
+		Name:      "status_transitions_total",
+		Help:      "Order status transitions applied by the accrual pipeline.",
+	}, []string{"from", "to"})
+
+	PointsCredited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "points",
+		Name:      "credited_total",
+		Help:      "Loyalty points credited to users for processed orders, by source: accrual, campaign, tier or referral.",
+	}, []string{"source"})
+
+	PointsWithdrawn = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "points",
+		Name:      "withdrawn_total",
+		Help:      "Loyalty points withdrawn by users.",
+	})
+
+	PointsRefunded = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "points",
+		Name:      "refunded_total",
+		Help:      "Loyalty points returned to users by withdrawal refunds.",
+	})
+
+	PointsExpired = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "points",
+		Name:      "expired_total",
+		Help:      "Loyalty points that expired unspent.",
+	})
+
+	PointsTransferred = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "points",
+		Name:      "transferred_total",
+		Help:      "Loyalty points moved between users by transfers.",
+	})
+
+	PointsGifted = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "points",
+		Name:      "gifted_total",
+		Help:      "Loyalty points credited by gift code redemptions.",
+	})
+
+	TierChanges = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "tiers",
+		Name:      "changes_total",
+		Help:      "Users moved to another loyalty tier by the recalculation job, by new tier.",
+	}, []string{"tier"})
+)
+
+// RegisterGaugeFunc exposes a value that is cheap to read on every scrape, such as queue depth.
+func RegisterGaugeFunc(subsystem, name, help string, value func() float64) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      name,
+		Help:      help,
+	}, value)
+}
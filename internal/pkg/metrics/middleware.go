@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// unmatchedRoute keeps requests to unknown paths from creating a series per path.
+const unmatchedRoute = "unmatched"
+
+// Middleware records request count and latency per chi route pattern.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		start := time.Now()
+		next.ServeHTTP(ww, r)
+		duration := time.Since(start)
+
+		route := unmatchedRoute
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		HTTPRequests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+		HTTPRequestDuration.WithLabelValues(r.Method, route).Observe(duration.Seconds())
+	})
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(Middleware)
+	router.Get("/api/user/orders/{number}", func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusAccepted)
+	})
+
+	tests := []struct {
+		name  string
+		path  string
+		route string
+		code  string
+	}{
+		{
+			name:  "labels request with route pattern",
+			path:  "/api/user/orders/2377225624",
+			route: "/api/user/orders/{number}",
+			code:  "202",
+		},
+		{
+			name:  "groups unknown paths",
+			path:  "/unknown/12345",
+			route: unmatchedRoute,
+			code:  "404",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := HTTPRequests.WithLabelValues(http.MethodGet, tt.route, tt.code)
+			before := testutil.ToFloat64(counter)
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, before+1, testutil.ToFloat64(counter))
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type pgxPoolCollector struct {
+	pool *pgxpool.Pool
+
+	acquiredConns     *prometheus.Desc
+	idleConns         *prometheus.Desc
+	totalConns        *prometheus.Desc
+	maxConns          *prometheus.Desc
+	acquireCount      *prometheus.Desc
+	acquireDuration   *prometheus.Desc
+	emptyAcquireCount *prometheus.Desc
+	canceledAcquires  *prometheus.Desc
+}
+
+// RegisterPgxPool exposes the pgx pool statistics, read on every scrape.
+func RegisterPgxPool(pool *pgxpool.Pool) {
+	prometheus.MustRegister(newPgxPoolCollector(pool))
+}
+
+func newPgxPoolCollector(pool *pgxpool.Pool) *pgxPoolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+	return &pgxPoolCollector{
+		pool:              pool,
+		acquiredConns:     desc("acquired_connections", "Connections currently in use."),
+		idleConns:         desc("idle_connections", "Idle connections in the pool."),
+		totalConns:        desc("total_connections", "Connections currently open."),
+		maxConns:          desc("max_connections", "Maximum size of the pool."),
+		acquireCount:      desc("acquires_total", "Successful connection acquires."),
+		acquireDuration:   desc("acquire_duration_seconds_total", "Total time spent waiting for a connection."),
+		emptyAcquireCount: desc("empty_acquires_total", "Acquires that had to wait because the pool was empty."),
+		canceledAcquires:  desc("canceled_acquires_total", "Acquires cancelled by their context."),
+	}
+}
+
+func (c *pgxPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.totalConns
+	ch <- c.maxConns
+	ch <- c.acquireCount
+	ch <- c.acquireDuration
+	ch <- c.emptyAcquireCount
+	ch <- c.canceledAcquires
+}
+
+func (c *pgxPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquireCount, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquires, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+}
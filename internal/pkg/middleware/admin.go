@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+)
+
+// AdminKeyHeader carries the API key of admin requests.
+const AdminKeyHeader = "X-Api-Key"
+
+type AdminMiddleware struct {
+	apiKey string
+}
+
+// NewAdminMiddleware creates a middleware that guards the admin API with apiKey.
+// An empty apiKey disables the admin API.
+func NewAdminMiddleware(apiKey string) *AdminMiddleware {
+	return &AdminMiddleware{
+		apiKey: apiKey,
+	}
+}
+
+func (m *AdminMiddleware) RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
+		if m.apiKey == "" {
+			log.Info("admin: admin api is disabled")
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+
+		key := r.Header.Get(AdminKeyHeader)
+		if subtle.ConstantTimeCompare([]byte(key), []byte(m.apiKey)) != 1 {
+			log.Info("admin: invalid api key")
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
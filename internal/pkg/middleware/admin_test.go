@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		apiKey     string
+		header     string
+		expectCode int
+	}{
+		{
+			name:       "valid key",
+			apiKey:     "admin-key",
+			header:     "admin-key",
+			expectCode: http.StatusOK,
+		},
+		{
+			name:       "wrong key",
+			apiKey:     "admin-key",
+			header:     "admin-kez",
+			expectCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "no key",
+			apiKey:     "admin-key",
+			expectCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "admin api disabled",
+			apiKey:     "",
+			header:     "",
+			expectCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			handlerCalled := false
+			testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handlerCalled = true
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/test", nil)
+			if tt.header != "" {
+				req.Header.Set(AdminKeyHeader, tt.header)
+			}
+			res := httptest.NewRecorder()
+
+			NewAdminMiddleware(tt.apiKey).RequireAdmin(testHandler).ServeHTTP(res, req)
+
+			assert.Equal(t, tt.expectCode, res.Code)
+			assert.Equal(t, tt.expectCode == http.StatusOK, handlerCalled)
+		})
+	}
+}
@@ -41,21 +41,23 @@ func NewJWTMiddleware(secretKey string) *JWTMiddleware {
 
 func (m *JWTMiddleware) CheckJWT(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
 		cookie, err := r.Cookie(CookieName)
 		if errors.Is(err, http.ErrNoCookie) {
-			logger.Log.Info("auth: no auth cookies", zap.Error(err))
+			log.Info("auth: no auth cookies", zap.Error(err))
 			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
 			return
 		}
 
 		userID, err := parseUserID(cookie.Value, m.secretKey)
 		if err != nil {
-			logger.Log.Info("auth: failed to get cookie", zap.Error(err))
+			log.Info("auth: failed to get cookie", zap.Error(err))
 			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
 			return
 		}
 
 		ctx := context.WithValue(r.Context(), UserIDKey, userID)
+		ctx = logger.WithContext(ctx, log.With(zap.String("userID", userID)))
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -64,7 +66,7 @@ func (m *JWTMiddleware) RequireAuth(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		_, err := GetUserID(r)
 		if err != nil {
-			logger.Log.Info("user not authenticated", zap.Error(err))
+			logger.FromContext(r.Context()).Info("user not authenticated", zap.Error(err))
 			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
 			return
 		}
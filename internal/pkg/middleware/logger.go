@@ -1,30 +1,80 @@
 package middleware
 
 import (
-	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"context"
+	"io"
 	"net/http"
 	"time"
 
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+
 	"go.uber.org/zap"
 )
 
+type LoggingConfig struct {
+	Redaction RedactionConfig
+	// BodyMaxBytes caps captured request and response bodies. Bodies that do not fit
+	// are not logged at all, because a truncated JSON document cannot be redacted.
+	BodyMaxBytes int
+}
+
+type bodyLogKey struct{}
+
+// bodyLog is shared between LogRequest and LogBodies through the request context,
+// so a route can opt in after the outer middleware has already wrapped the writer.
+type bodyLog struct {
+	enabled  bool
+	request  cappedBuffer
+	response cappedBuffer
+}
+
+type cappedBuffer struct {
+	limit     int
+	data      []byte
+	size      int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) {
+	b.size += len(p)
+	if b.truncated {
+		return
+	}
+	if len(b.data)+len(p) > b.limit {
+		b.truncated = true
+		b.data = nil
+		return
+	}
+	b.data = append(b.data, p...)
+}
+
 type (
 	responseData struct {
 		status int
 		size   int
-		body   []byte
+		body   *bodyLog
 	}
 
 	loggingResponseWriter struct {
 		http.ResponseWriter
 		responseData *responseData
 	}
+
+	loggingRequestBody struct {
+		io.ReadCloser
+		body *bodyLog
+	}
 )
 
 func (r *loggingResponseWriter) Write(b []byte) (int, error) {
+	if r.responseData.status == 0 {
+		r.responseData.status = http.StatusOK
+	}
 	size, err := r.ResponseWriter.Write(b)
 	r.responseData.size += size
-	r.responseData.body = append(r.responseData.body, b...)
+	if r.responseData.body.enabled {
+		r.responseData.body.response.Write(b[:size])
+	}
 	return size, err
 }
 
@@ -33,38 +83,79 @@ func (r *loggingResponseWriter) WriteHeader(statusCode int) {
 	r.responseData.status = statusCode
 }
 
-func RequestLogger(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		next.ServeHTTP(w, r)
-		duration := time.Since(start)
+func (r *loggingRequestBody) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.body.request.Write(p[:n])
+	return n, err
+}
 
-		logger.Log.Info("HTTP request ==>",
-			zap.String("method", r.Method),
-			zap.String("URL", r.URL.String()),
-			zap.Any("headers", r.Header),
-			zap.Duration("duration", duration),
-		)
-	})
+type LoggerMiddleware struct {
+	redactor     *Redactor
+	bodyMaxBytes int
 }
 
-func ResponseLogger(next http.Handler) http.Handler {
+func NewLoggerMiddleware(cfg LoggingConfig) *LoggerMiddleware {
+	return &LoggerMiddleware{
+		redactor:     NewRedactor(cfg.Redaction),
+		bodyMaxBytes: cfg.BodyMaxBytes,
+	}
+}
+
+// LogRequest logs every request with redacted headers. Bodies are logged only
+// for routes wrapped with LogBodies.
+func (m *LoggerMiddleware) LogRequest(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		rd := &responseData{}
+		body := &bodyLog{
+			request:  cappedBuffer{limit: m.bodyMaxBytes},
+			response: cappedBuffer{limit: m.bodyMaxBytes},
+		}
+		rd := &responseData{body: body}
 		lw := loggingResponseWriter{
 			ResponseWriter: w,
 			responseData:   rd,
 		}
 
 		start := time.Now()
-		next.ServeHTTP(&lw, r)
+		next.ServeHTTP(&lw, r.WithContext(context.WithValue(r.Context(), bodyLogKey{}, body)))
 		duration := time.Since(start)
 
-		logger.Log.Info("HTTP response <==",
+		fields := []zap.Field{
+			zap.String("method", r.Method),
+			zap.String("URL", r.URL.String()),
+			zap.Any("headers", m.redactor.Headers(r.Header)),
 			zap.Int("status", rd.status),
-			zap.Any("headers", r.Header),
-			zap.ByteString("body", rd.body),
+			zap.Int("size", rd.size),
+			zap.Any("responseHeaders", m.redactor.Headers(w.Header())),
 			zap.Duration("duration", duration),
-		)
+		}
+		if body.enabled {
+			fields = append(fields,
+				m.bodyField("requestBody", &body.request),
+				m.bodyField("responseBody", &body.response),
+			)
+		}
+		logger.FromContext(r.Context()).Info("HTTP request", fields...)
 	})
 }
+
+// LogBodies opts a route in to request and response body logging.
+// It must be mounted inside LogRequest.
+func (m *LoggerMiddleware) LogBodies(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := r.Context().Value(bodyLogKey{}).(*bodyLog)
+		if ok && m.bodyMaxBytes > 0 {
+			body.enabled = true
+			if r.Body != nil {
+				r.Body = &loggingRequestBody{ReadCloser: r.Body, body: body}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (m *LoggerMiddleware) bodyField(key string, buf *cappedBuffer) zap.Field {
+	if buf.truncated {
+		return zap.String(key, "[TRUNCATED]")
+	}
+	return zap.ByteString(key, m.redactor.Body(buf.data))
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLoggerMiddleware(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	original := logger.Log
+	logger.Log = zap.New(core)
+	t.Cleanup(func() {
+		logger.Log = original
+	})
+
+	m := NewLoggerMiddleware(LoggingConfig{Redaction: testRedaction, BodyMaxBytes: 64})
+	echo := func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		rw.Header().Set("Content-Type", "application/json")
+		_, _ = rw.Write(body)
+	}
+
+	router := chi.NewRouter()
+	router.Use(m.LogRequest)
+	router.Post("/quiet", echo)
+	router.With(m.LogBodies).Post("/loud", echo)
+
+	tests := []struct {
+		name         string
+		path         string
+		body         string
+		wantBody     bool
+		wantRequest  string
+		wantResponse string
+	}{
+		{
+			name: "bodies are not logged by default",
+			path: "/quiet",
+			body: `{"password":"secret"}`,
+		},
+		{
+			name:         "opted in route logs redacted bodies",
+			path:         "/loud",
+			body:         `{"password":"secret"}`,
+			wantBody:     true,
+			wantRequest:  `{"password":"[REDACTED]"}`,
+			wantResponse: `{"password":"[REDACTED]"}`,
+		},
+		{
+			name:         "oversized bodies are dropped",
+			path:         "/loud",
+			body:         `{"password":"` + strings.Repeat("s", 64) + `"}`,
+			wantBody:     true,
+			wantRequest:  "[TRUNCATED]",
+			wantResponse: "[TRUNCATED]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.TakeAll()
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Cookie", "JWT=secret")
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			entries := logs.TakeAll()
+			require.Len(t, entries, 1)
+			fields := entries[0].ContextMap()
+
+			assert.Equal(t, int64(http.StatusOK), fields["status"])
+			headers := fields["headers"].(http.Header)
+			assert.Equal(t, "JWT="+redacted, headers.Get("Cookie"))
+
+			if !tt.wantBody {
+				assert.NotContains(t, fields, "requestBody")
+				assert.NotContains(t, fields, "responseBody")
+				return
+			}
+			assert.Equal(t, tt.wantRequest, fields["requestBody"])
+			assert.Equal(t, tt.wantResponse, fields["responseBody"])
+		})
+	}
+}
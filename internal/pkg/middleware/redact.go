@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+type RedactionConfig struct {
+	// Headers are replaced entirely, matched case-insensitively.
+	Headers []string
+	// Cookies are redacted by name inside Cookie and Set-Cookie headers.
+	Cookies []string
+	// Fields are JSON object keys redacted at any depth, matched case-insensitively.
+	Fields []string
+}
+
+// Redactor removes secrets from headers and bodies before they are logged.
+type Redactor struct {
+	headers map[string]struct{}
+	cookies map[string]struct{}
+	fields  map[string]struct{}
+}
+
+func NewRedactor(cfg RedactionConfig) *Redactor {
+	return &Redactor{
+		headers: toLowerSet(cfg.Headers),
+		cookies: toLowerSet(cfg.Cookies),
+		fields:  toLowerSet(cfg.Fields),
+	}
+}
+
+// Headers returns a redacted copy of h.
+func (r *Redactor) Headers(h http.Header) http.Header {
+	result := make(http.Header, len(h))
+	for name, values := range h {
+		key := http.CanonicalHeaderKey(name)
+		switch {
+		case r.isRedactedHeader(key):
+			result[key] = []string{redacted}
+		case key == "Cookie":
+			result[key] = r.redactCookieHeader(values, ";")
+		case key == "Set-Cookie":
+			result[key] = r.redactSetCookieHeader(values)
+		default:
+			result[key] = values
+		}
+	}
+	return result
+}
+
+// Body redacts JSON bodies. Anything that is not valid JSON is returned unchanged,
+// so text bodies must not be opted in to logging on routes that may carry secrets.
+func (r *Redactor) Body(body []byte) []byte {
+	if len(r.fields) == 0 || !json.Valid(body) {
+		return body
+	}
+
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return body
+	}
+
+	result, err := json.Marshal(r.redactValue(value))
+	if err != nil {
+		return []byte(redacted)
+	}
+	return result
+}
+
+func (r *Redactor) redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if _, ok := r.fields[strings.ToLower(key)]; ok {
+				v[key] = redacted
+				continue
+			}
+			v[key] = r.redactValue(field)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = r.redactValue(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func (r *Redactor) isRedactedHeader(key string) bool {
+	_, ok := r.headers[strings.ToLower(key)]
+	return ok
+}
+
+func (r *Redactor) redactCookieHeader(values []string, separator string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		pairs := strings.Split(value, separator)
+		for i, pair := range pairs {
+			pairs[i] = r.redactCookiePair(pair)
+		}
+		result = append(result, strings.Join(pairs, separator))
+	}
+	return result
+}
+
+func (r *Redactor) redactSetCookieHeader(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		// Only the first pair is the cookie itself, the rest are attributes.
+		cookie, attributes, found := strings.Cut(value, ";")
+		cookie = r.redactCookiePair(cookie)
+		if found {
+			cookie += ";" + attributes
+		}
+		result = append(result, cookie)
+	}
+	return result
+}
+
+func (r *Redactor) redactCookiePair(pair string) string {
+	name, _, found := strings.Cut(pair, "=")
+	if !found {
+		return pair
+	}
+	if _, ok := r.cookies[strings.ToLower(strings.TrimSpace(name))]; !ok {
+		return pair
+	}
+	return name + "=" + redacted
+}
+
+func toLowerSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			set[strings.ToLower(value)] = struct{}{}
+		}
+	}
+	return set
+}
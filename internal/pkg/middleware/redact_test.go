@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testRedaction = RedactionConfig{
+	Headers: []string{"Authorization"},
+	Cookies: []string{CookieName},
+	Fields:  []string{"password", "token"},
+}
+
+func TestRedactorHeaders(t *testing.T) {
+	t.Parallel()
+
+	redactor := NewRedactor(testRedaction)
+	headers := http.Header{
+		"Authorization": {"Bearer secret"},
+		"Cookie":        {"JWT=secret; theme=dark"},
+		"Set-Cookie":    {"JWT=secret; Path=/; HttpOnly"},
+		"Content-Type":  {"application/json"},
+	}
+
+	got := redactor.Headers(headers)
+
+	assert.Equal(t, []string{redacted}, got["Authorization"])
+	assert.Equal(t, []string{"JWT=" + redacted + "; theme=dark"}, got["Cookie"])
+	assert.Equal(t, []string{"JWT=" + redacted + "; Path=/; HttpOnly"}, got["Set-Cookie"])
+	assert.Equal(t, []string{"application/json"}, got["Content-Type"])
+	assert.Equal(t, "Bearer secret", headers.Get("Authorization"), "original headers must not change")
+}
+
+func TestRedactorBody(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "top level field",
+			body: `{"login":"user","password":"secret"}`,
+			want: `{"login":"user","password":"[REDACTED]"}`,
+		},
+		{
+			name: "nested fields are matched case-insensitively",
+			body: `{"items":[{"Token":"secret","sum":751.5}]}`,
+			want: `{"items":[{"Token":"[REDACTED]","sum":751.5}]}`,
+		},
+		{
+			name: "plain text is kept",
+			body: `2377225624`,
+			want: `2377225624`,
+		},
+		{
+			name: "invalid json is kept",
+			body: `{"password":`,
+			want: `{"password":`,
+		},
+	}
+
+	redactor := NewRedactor(testRedaction)
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, string(redactor.Body([]byte(tt.body))))
+		})
+	}
+}
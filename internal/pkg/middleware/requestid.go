@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+const RequestIDKey ContextKey = "request_id"
+
+const maxRequestIDLength = 128
+
+// RequestID accepts the caller's X-Request-ID or generates a new one, echoes it in the
+// response and attaches it to the context logger, so every log line of the request carries it.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !isValidRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		ctx := context.WithValue(r.Context(), RequestIDKey, requestID)
+		ctx = logger.WithContext(ctx, logger.FromContext(ctx).With(zap.String("requestID", requestID)))
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("request.id", requestID))
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func GetRequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(RequestIDKey).(string)
+	return requestID
+}
+
+// isValidRequestID keeps client-supplied IDs short and printable, so they cannot
+// inject fake fields or line breaks into the logs.
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range requestID {
+		isAlnum := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+		if !isAlnum && c != '-' && c != '_' && c != '.' && c != ':' {
+			return false
+		}
+	}
+	return true
+}
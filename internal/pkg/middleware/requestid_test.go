@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRequestID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		requestID string
+		wantSame  bool
+	}{
+		{
+			name:      "accepts caller request id",
+			requestID: "checkout-42.retry_1",
+			wantSame:  true,
+		},
+		{
+			name: "generates missing request id",
+		},
+		{
+			name:      "replaces request id with unsafe characters",
+			requestID: "id\nlevel=error",
+		},
+		{
+			name:      "replaces too long request id",
+			requestID: strings.Repeat("a", maxRequestIDLength+1),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			core, logs := observer.New(zap.InfoLevel)
+			var ctxRequestID string
+			handler := func(rw http.ResponseWriter, req *http.Request) {
+				ctxRequestID = GetRequestID(req.Context())
+				logger.FromContext(req.Context()).Info("handled")
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.requestID != "" {
+				req.Header.Set(RequestIDHeader, tt.requestID)
+			}
+			req = req.WithContext(logger.WithContext(req.Context(), zap.New(core)))
+			rw := httptest.NewRecorder()
+			RequestID(http.HandlerFunc(handler)).ServeHTTP(rw, req)
+
+			responseID := rw.Header().Get(RequestIDHeader)
+			assert.Equal(t, ctxRequestID, responseID)
+			if tt.wantSame {
+				assert.Equal(t, tt.requestID, responseID)
+			} else {
+				_, err := uuid.Parse(responseID)
+				assert.NoError(t, err)
+			}
+
+			entries := logs.All()
+			require.Len(t, entries, 1)
+			assert.Equal(t, responseID, entries[0].ContextMap()["requestID"])
+		})
+	}
+}
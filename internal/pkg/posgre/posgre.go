@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/aifedorov/gophermart/internal/pkg/tracing"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -26,7 +27,13 @@ func (p *PostgreRepository) Open() error {
 	ctx, cancel := context.WithTimeout(p.ctx, defaultDBTimeout)
 	defer cancel()
 
-	dbpool, err := pgxpool.New(context.Background(), p.dsn)
+	poolConfig, err := pgxpool.ParseConfig(p.dsn)
+	if err != nil {
+		return err
+	}
+	poolConfig.ConnConfig.Tracer = tracing.QueryTracer{}
+
+	dbpool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
 		return err
 	}
@@ -44,6 +51,23 @@ func (p *PostgreRepository) Close() {
 	p.dbPool.Close()
 }
 
+func (p *PostgreRepository) Ping(ctx context.Context) error {
+	return p.dbPool.Ping(ctx)
+}
+
+// MigrationVersion reads the schema version recorded by golang-migrate.
+func (p *PostgreRepository) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	var (
+		version int64
+		dirty   bool
+	)
+	err := p.dbPool.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err != nil {
+		return 0, false, err
+	}
+	return uint(version), dirty, nil
+}
+
 func (p *PostgreRepository) DBPool() *pgxpool.Pool {
 	return p.dbPool
 }
@@ -0,0 +1,19 @@
+package randcode
+
+import "crypto/rand"
+
+// Alphabet leaves out 0, O, 1 and I, which are easy to mix up when typing a code.
+// It has 32 characters, so a random byte maps onto it without bias.
+const Alphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// Generate returns a random code of the given length made of Alphabet characters.
+func Generate(length int) (string, error) {
+	buf := make([]byte, length)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = Alphabet[int(b)%len(Alphabet)]
+	}
+	return string(buf), nil
+}
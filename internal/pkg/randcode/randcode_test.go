@@ -0,0 +1,22 @@
+package randcode
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	for range 100 {
+		code, err := Generate(12)
+		require.NoError(t, err)
+		assert.Len(t, code, 12)
+		for _, char := range code {
+			assert.True(t, strings.ContainsRune(Alphabet, char), "unexpected character %q in %s", char, code)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// QueryTracer wraps every pgx query in a client span. sqlc queries are named
+// after their "-- name:" annotation, arguments are never recorded.
+type QueryTracer struct{}
+
+var _ pgx.QueryTracer = QueryTracer{}
+
+func (QueryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	name := queryName(data.SQL)
+	ctx, _ = otel.Tracer(instrumentationName).Start(ctx, "db "+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(name),
+			semconv.DBQueryText(data.SQL),
+		),
+	)
+	return ctx
+}
+
+func (QueryTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	defer span.End()
+
+	if data.Err != nil && !errors.Is(data.Err, pgx.ErrNoRows) {
+		RecordError(span, data.Err)
+	}
+}
+
+// queryName extracts the sqlc query name from "-- name: GetOrderByNumber :one",
+// falling back to the first SQL keyword for hand-written statements.
+func queryName(sql string) string {
+	sql = strings.TrimSpace(sql)
+	if rest, ok := strings.CutPrefix(sql, "-- name: "); ok {
+		if name, _, found := strings.Cut(rest, " "); found {
+			return name
+		}
+	}
+	keyword, _, _ := strings.Cut(sql, " ")
+	return strings.ToUpper(keyword)
+}
@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+	})
+
+	router := chi.NewRouter()
+	router.Use(Middleware)
+	router.Post("/api/user/orders", func(rw http.ResponseWriter, req *http.Request) {
+		_, span := Start(req.Context(), "orderservice.CreateOrder", OrderNumber("2377225624"))
+		span.End()
+		rw.WriteHeader(http.StatusAccepted)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/user/orders", nil))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	child, server := spans[0], spans[1]
+	assert.Equal(t, "POST /api/user/orders", server.Name())
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind())
+	assert.Equal(t, server.SpanContext().SpanID(), child.Parent().SpanID())
+	assert.Contains(t, child.Attributes(), OrderNumber("2377225624"))
+}
+
+func TestQueryName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{
+			name: "sqlc query",
+			sql:  "-- name: GetOrderByNumber :one\nSELECT id FROM orders WHERE number = $1",
+			want: "GetOrderByNumber",
+		},
+		{
+			name: "plain statement",
+			sql:  "begin",
+			want: "BEGIN",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, queryName(tt.sql))
+		})
+	}
+}
@@ -1,64 +1,129 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
 	orderDomain "github.com/aifedorov/gophermart/internal/order/domain"
 	orderHandler "github.com/aifedorov/gophermart/internal/order/handler"
 	"github.com/aifedorov/gophermart/internal/pkg/config"
+	"github.com/aifedorov/gophermart/internal/pkg/health"
+	"github.com/aifedorov/gophermart/internal/pkg/idempotency"
 	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/aifedorov/gophermart/internal/pkg/metrics"
 	"github.com/aifedorov/gophermart/internal/pkg/middleware"
+	"github.com/aifedorov/gophermart/internal/pkg/tracing"
 	userDomain "github.com/aifedorov/gophermart/internal/user/domain"
 	userHandler "github.com/aifedorov/gophermart/internal/user/handler"
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
 )
 
 type Server struct {
 	router       *chi.Mux
+	httpServer   *http.Server
 	config       config.Config
 	userService  userDomain.Service
 	orderService orderDomain.Service
+	healthChecks []health.Check
+	idempotency  idempotency.Store
 }
 
 func NewServer(
 	cfg config.Config,
 	userService userDomain.Service,
 	orderService orderDomain.Service,
+	healthChecks []health.Check,
+	idempotencyStore idempotency.Store,
 ) *Server {
+	router := chi.NewRouter()
 	return &Server{
-		router:       chi.NewRouter(),
+		router: router,
+		httpServer: &http.Server{
+			Addr:    cfg.ListenAddress,
+			Handler: router,
+		},
 		config:       cfg,
 		userService:  userService,
 		orderService: orderService,
+		healthChecks: healthChecks,
+		idempotency:  idempotencyStore,
 	}
 }
 
+// Run serves requests until Shutdown is called.
 func (s *Server) Run() error {
 	s.mountHandlers()
 
 	logger.Log.Info("server: running on", zap.String("address", s.config.ListenAddress))
-	return http.ListenAndServe(s.config.ListenAddress, s.router)
+	err := s.httpServer.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests until ctx expires.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
 }
 
 func (s *Server) mountHandlers() {
+	// Probes and metrics stay outside the API group so they do not flood the request log.
+	s.router.Get("/healthz", health.NewLivenessHandler())
+	s.router.Get("/readyz", health.NewReadinessHandler(s.healthChecks...))
+	s.router.Handle("/metrics", promhttp.Handler())
+
+	s.router.Group(s.mountAPIHandlers)
+}
 
+func (s *Server) mountAPIHandlers(r chi.Router) {
 	jwtMiddleware := middleware.NewJWTMiddleware(s.config.SecretKey)
+	adminMiddleware := middleware.NewAdminMiddleware(s.config.AdminAPIKey)
+	loggerMiddleware := middleware.NewLoggerMiddleware(middleware.LoggingConfig{
+		Redaction: middleware.RedactionConfig{
+			Headers: append([]string{middleware.AdminKeyHeader}, s.config.LogRedactHeaders...),
+			Cookies: append([]string{middleware.CookieName}, s.config.LogRedactCookies...),
+			Fields:  s.config.LogRedactFields,
+		},
+		BodyMaxBytes: s.config.LogBodyMaxBytes,
+	})
+	idempotencyMiddleware := idempotency.NewMiddleware(s.idempotency, s.config.IdempotencyTTL, s.config.IdempotencyLease)
 
-	s.router.Use(chimiddleware.Compress(6, "application/json", "text/plain", "text/html"))
-	s.router.Use(middleware.RequestLogger)
-	s.router.Use(middleware.ResponseLogger)
+	r.Use(tracing.Middleware)
+	r.Use(middleware.RequestID)
+	r.Use(metrics.Middleware)
+	r.Use(chimiddleware.Compress(6, "application/json", "text/plain", "text/html"))
+	r.Use(loggerMiddleware.LogRequest)
 
-	s.router.Post("/api/user/register", userHandler.NewUserRegisterHandler(s.config, s.userService))
-	s.router.Post("/api/user/login", userHandler.NewLoginHandler(s.config, s.userService))
+	r.Post("/api/user/register", userHandler.NewUserRegisterHandler(s.config, s.userService))
+	r.Post("/api/user/login", userHandler.NewLoginHandler(s.config, s.userService))
 
-	s.router.Group(func(r chi.Router) {
+	r.Group(func(r chi.Router) {
 		r.Use(jwtMiddleware.CheckJWT)
-		r.Post("/api/user/orders", jwtMiddleware.RequireAuth(orderHandler.NewCreateOrdersHandler(s.orderService)))
+		r.With(loggerMiddleware.LogBodies, idempotencyMiddleware.Handle).Post("/api/user/orders", jwtMiddleware.RequireAuth(orderHandler.NewCreateOrdersHandler(s.orderService)))
 		r.Get("/api/user/orders", jwtMiddleware.RequireAuth(orderHandler.NewGetOrdersHandler(s.orderService)))
 		r.Get("/api/user/balance", jwtMiddleware.RequireAuth(orderHandler.NewBalanceHandler(s.orderService)))
-		r.Post("/api/user/balance/withdraw", jwtMiddleware.RequireAuth(orderHandler.NewWithdrawHandler(s.orderService)))
+		r.Get("/api/user/tier", jwtMiddleware.RequireAuth(orderHandler.NewTierHandler(s.orderService)))
+		r.With(loggerMiddleware.LogBodies, idempotencyMiddleware.Handle).Post("/api/user/balance/withdraw", jwtMiddleware.RequireAuth(orderHandler.NewWithdrawHandler(s.orderService)))
 		r.Get("/api/user/withdrawals", jwtMiddleware.RequireAuth(orderHandler.NewWithdrawalsHandler(s.orderService)))
+		r.With(loggerMiddleware.LogBodies, idempotencyMiddleware.Handle).Post("/api/user/balance/holds", jwtMiddleware.RequireAuth(orderHandler.NewCreateHoldHandler(s.orderService, s.config.HoldTTL)))
+		r.Post("/api/user/balance/holds/{"+orderHandler.HoldIDParam+"}/capture", jwtMiddleware.RequireAuth(orderHandler.NewCaptureHoldHandler(s.orderService)))
+		r.Post("/api/user/balance/holds/{"+orderHandler.HoldIDParam+"}/void", jwtMiddleware.RequireAuth(orderHandler.NewVoidHoldHandler(s.orderService)))
+		r.With(loggerMiddleware.LogBodies, idempotencyMiddleware.Handle).Post("/api/user/transfers", jwtMiddleware.RequireAuth(orderHandler.NewTransferHandler(s.orderService)))
+		r.Get("/api/user/transfers", jwtMiddleware.RequireAuth(orderHandler.NewTransfersHandler(s.orderService)))
+		r.Get("/api/user/referrals", jwtMiddleware.RequireAuth(orderHandler.NewReferralsHandler(s.orderService)))
+		// Gift code bodies are not logged, the codes are as good as points.
+		r.With(idempotencyMiddleware.Handle).Post("/api/user/gift-codes/redeem", jwtMiddleware.RequireAuth(orderHandler.NewRedeemGiftCodeHandler(s.orderService)))
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(adminMiddleware.RequireAdmin)
+		r.Post("/api/admin/gift-codes", orderHandler.NewCreateGiftCodesHandler(s.orderService))
+		r.Get("/api/admin/gift-codes/{"+orderHandler.GiftCodeBatchIDParam+"}/export", orderHandler.NewExportGiftCodesHandler(s.orderService))
+		r.With(loggerMiddleware.LogBodies, idempotencyMiddleware.HandleFor(orderHandler.RefundUserID)).Post("/api/admin/users/{"+orderHandler.UserIDParam+"}/withdrawals/{"+orderHandler.OrderNumberParam+"}/refund", orderHandler.NewRefundHandler(s.orderService))
 	})
 }
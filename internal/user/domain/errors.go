@@ -3,8 +3,9 @@ package domain
 import "errors"
 
 var (
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrUserAlreadyExists  = errors.New("user already exists")
-	ErrEmptyCredentials   = errors.New("empty login or password")
-	ErrNotFound           = errors.New("user not found")
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrUserAlreadyExists   = errors.New("user already exists")
+	ErrEmptyCredentials    = errors.New("empty login or password")
+	ErrNotFound            = errors.New("user not found")
+	ErrInvalidReferralCode = errors.New("unknown referral code")
 )
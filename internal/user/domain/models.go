@@ -1,14 +1,17 @@
 package domain
 
 type User struct {
-	ID       string
-	Login    string
-	Password string
+	ID           string
+	Login        string
+	Password     string
+	ReferralCode string
 }
 
 type RegisterRequest struct {
 	Login    string
 	Password string
+	// ReferralCode is the code of the user who referred this one; empty if nobody did.
+	ReferralCode string
 }
 
 type LoginRequest struct {
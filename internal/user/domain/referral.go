@@ -0,0 +1,14 @@
+package domain
+
+import "strings"
+
+const (
+	referralCodeLength = 8
+
+	createUserAttempts = 3
+)
+
+// NormalizeReferralCode makes codes typed by users match the issued ones.
+func NormalizeReferralCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -1,17 +1,21 @@
 package domain
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/aifedorov/gophermart/internal/pkg/logger"
+	"github.com/aifedorov/gophermart/internal/pkg/randcode"
+	"github.com/aifedorov/gophermart/internal/pkg/tracing"
 	repository "github.com/aifedorov/gophermart/internal/user/repository/db"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type Service interface {
-	Register(req RegisterRequest) (*User, error)
-	Login(req LoginRequest) (*User, error)
+	Register(ctx context.Context, req RegisterRequest) (*User, error)
+	Login(ctx context.Context, req LoginRequest) (*User, error)
 }
 type service struct {
 	repo repository.Repository
@@ -23,25 +27,42 @@ func NewService(repo repository.Repository) Service {
 	}
 }
 
-func (s *service) Register(req RegisterRequest) (*User, error) {
+func (s *service) Register(ctx context.Context, req RegisterRequest) (*User, error) {
+	ctx, span := tracing.Start(ctx, "userservice.Register")
+	defer span.End()
+
 	if !s.isValidCredentials(req.Login, req.Password) {
-		logger.Log.Info("userservice: invalid credentials")
+		logger.FromContext(ctx).Info("userservice: invalid credentials")
 		return nil, ErrEmptyCredentials
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
-		logger.Log.Error("userservice: failed to hash password", zap.Error(err))
+		logger.FromContext(ctx).Error("userservice: failed to hash password", zap.Error(err))
 		return nil, fmt.Errorf("userservice: failed to hash password: %w", err)
 	}
 
-	dbUser, err := s.repo.CreateUser(req.Login, string(hashedPassword))
+	var referrerID uuid.NullUUID
+	if req.ReferralCode != "" {
+		referrer, err := s.repo.GetUserByReferralCode(ctx, NormalizeReferralCode(req.ReferralCode))
+		if errors.Is(err, repository.ErrUserNotFound) {
+			logger.FromContext(ctx).Info("userservice: unknown referral code")
+			return nil, ErrInvalidReferralCode
+		}
+		if err != nil {
+			logger.FromContext(ctx).Error("userservice: failed to get referrer", zap.Error(err))
+			return nil, err
+		}
+		referrerID = uuid.NullUUID{UUID: referrer.ID, Valid: true}
+	}
+
+	dbUser, err := s.createUser(ctx, req.Login, string(hashedPassword), referrerID)
 	if errors.Is(err, repository.ErrUserAlreadyExists) {
-		logger.Log.Info("userservice: user already exists", zap.Error(err))
+		logger.FromContext(ctx).Info("userservice: user already exists", zap.Error(err))
 		return nil, ErrUserAlreadyExists
 	}
 	if err != nil {
-		logger.Log.Error("userservice: failed to create user", zap.Error(err))
+		logger.FromContext(ctx).Error("userservice: failed to create user", zap.Error(err))
 		return nil, err
 	}
 
@@ -49,25 +70,28 @@ func (s *service) Register(req RegisterRequest) (*User, error) {
 	return &domainUser, nil
 }
 
-func (s *service) Login(req LoginRequest) (*User, error) {
+func (s *service) Login(ctx context.Context, req LoginRequest) (*User, error) {
+	ctx, span := tracing.Start(ctx, "userservice.Login")
+	defer span.End()
+
 	if !s.isValidCredentials(req.Login, req.Password) {
-		logger.Log.Info("userservice: invalid credentials")
+		logger.FromContext(ctx).Info("userservice: invalid credentials")
 		return nil, ErrEmptyCredentials
 	}
 
-	dbUser, err := s.repo.GetUserByUsername(req.Login)
+	dbUser, err := s.repo.GetUserByUsername(ctx, req.Login)
 	if errors.Is(err, repository.ErrUserNotFound) {
-		logger.Log.Info("userservice: user not found", zap.String("login", req.Login))
+		logger.FromContext(ctx).Info("userservice: user not found", zap.String("login", req.Login))
 		return nil, ErrInvalidCredentials
 	}
 	if err != nil {
-		logger.Log.Error("userservice: failed to get user", zap.Error(err))
+		logger.FromContext(ctx).Error("userservice: failed to get user", zap.Error(err))
 		return nil, err
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(dbUser.PasswordHash), []byte(req.Password))
 	if err != nil {
-		logger.Log.Info("userservice: invalid password", zap.String("login", req.Login))
+		logger.FromContext(ctx).Info("userservice: invalid password", zap.String("login", req.Login))
 		return nil, ErrInvalidCredentials
 	}
 
@@ -75,14 +99,33 @@ func (s *service) Login(req LoginRequest) (*User, error) {
 	return &domainUser, nil
 }
 
+// createUser generates a referral code for the user and tries again with a new one
+// if the code is already taken.
+func (s *service) createUser(ctx context.Context, login, passwordHash string, referrerID uuid.NullUUID) (repository.User, error) {
+	for attempt := 1; ; attempt++ {
+		code, err := randcode.Generate(referralCodeLength)
+		if err != nil {
+			return repository.User{}, fmt.Errorf("userservice: failed to generate referral code: %w", err)
+		}
+
+		dbUser, err := s.repo.CreateUser(ctx, login, passwordHash, code, referrerID)
+		if errors.Is(err, repository.ErrReferralCodeTaken) && attempt < createUserAttempts {
+			logger.FromContext(ctx).Warn("userservice: generated referral code is taken", zap.Int("attempt", attempt))
+			continue
+		}
+		return dbUser, err
+	}
+}
+
 func (s *service) isValidCredentials(login, password string) bool {
 	return login != "" && password != ""
 }
 
 func (s *service) convertUserToDomain(dbUser repository.User) User {
 	return User{
-		ID:       dbUser.ID.String(),
-		Login:    dbUser.Username,
-		Password: dbUser.PasswordHash,
+		ID:           dbUser.ID.String(),
+		Login:        dbUser.Username,
+		Password:     dbUser.PasswordHash,
+		ReferralCode: dbUser.ReferralCode,
 	}
 }
@@ -12,11 +12,12 @@ import (
 
 func NewLoginHandler(cfg config.Config, userService domain.Service) http.HandlerFunc {
 	return func(rw http.ResponseWriter, req *http.Request) {
+		log := logger.FromContext(req.Context())
 		rw.Header().Set("Content-Type", "application/json")
 
 		body, err := decodeLogin(req)
 		if err != nil {
-			logger.Log.Info("failed to decode request", zap.Error(err))
+			log.Info("failed to decode request", zap.Error(err))
 			http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 			return
 		}
@@ -26,24 +27,24 @@ func NewLoginHandler(cfg config.Config, userService domain.Service) http.Handler
 			Password: body.Password,
 		}
 
-		authenticatedUser, err := userService.Login(userReq)
+		authenticatedUser, err := userService.Login(req.Context(), userReq)
 		if errors.Is(err, domain.ErrEmptyCredentials) {
-			logger.Log.Info("empty login or password")
+			log.Info("empty login or password")
 			http.Error(rw, "empty login or password", http.StatusBadRequest)
 			return
 		}
 		if errors.Is(err, domain.ErrInvalidCredentials) {
-			logger.Log.Info("invalid login or password")
+			log.Info("invalid login or password")
 			http.Error(rw, "invalid login or password", http.StatusUnauthorized)
 			return
 		}
 		if errors.Is(err, domain.ErrUserAlreadyExists) {
-			logger.Log.Info("user already exists")
+			log.Info("user already exists")
 			http.Error(rw, "user already exists", http.StatusBadRequest)
 			return
 		}
 		if err != nil {
-			logger.Log.Error("failed to authenticate user", zap.Error(err))
+			log.Error("failed to authenticate user", zap.Error(err))
 			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 			return
 		}
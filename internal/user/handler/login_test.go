@@ -137,17 +137,17 @@ func newMockStorageForLogin(ctrl *gomock.Controller) repository.Repository {
 	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("test"), bcrypt.DefaultCost)
 
 	mockRepo.EXPECT().
-		GetUserByUsername("loginExists").
+		GetUserByUsername(gomock.Any(), "loginExists").
 		Return(repository.User{Username: "loginExists", PasswordHash: string(hashedPassword)}, nil).
 		AnyTimes()
 
 	mockRepo.EXPECT().
-		GetUserByUsername("loginNotExists").
+		GetUserByUsername(gomock.Any(), "loginNotExists").
 		Return(repository.User{}, repository.ErrUserNotFound).
 		AnyTimes()
 
 	mockRepo.EXPECT().
-		GetUserByUsername("test").
+		GetUserByUsername(gomock.Any(), "test").
 		Return(repository.User{}, errors.New("internal error")).
 		AnyTimes()
 
@@ -1,8 +1,13 @@
 package handler
 
 type RegisterRequest struct {
-	Login    string `json:"login"`
-	Password string `json:"password"`
+	Login        string `json:"login"`
+	Password     string `json:"password"`
+	ReferralCode string `json:"referral_code,omitempty"`
+}
+
+type RegisterResponse struct {
+	ReferralCode string `json:"referral_code"`
 }
 type LoginRequest struct {
 	Login    string `json:"login"`
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
 
@@ -14,38 +15,48 @@ import (
 
 func NewUserRegisterHandler(cfg config.Config, userService domain.Service) http.HandlerFunc {
 	return func(rw http.ResponseWriter, req *http.Request) {
+		log := logger.FromContext(req.Context())
 		rw.Header().Set("Content-Type", "application/json")
 
 		body, err := decodeRegister(req)
 		if err != nil {
-			logger.Log.Info("failed to decode request", zap.Error(err))
+			log.Info("failed to decode request", zap.Error(err))
 			http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 			return
 		}
 
 		userReq := domain.RegisterRequest{
-			Login:    body.Login,
-			Password: body.Password,
+			Login:        body.Login,
+			Password:     body.Password,
+			ReferralCode: body.ReferralCode,
 		}
 
-		registeredUser, err := userService.Register(userReq)
+		registeredUser, err := userService.Register(req.Context(), userReq)
 		if errors.Is(err, domain.ErrEmptyCredentials) {
-			logger.Log.Info("empty login or password")
+			log.Info("empty login or password")
 			http.Error(rw, "empty login or password", http.StatusBadRequest)
 			return
 		}
+		if errors.Is(err, domain.ErrInvalidReferralCode) {
+			log.Info("unknown referral code")
+			http.Error(rw, "unknown referral code", http.StatusBadRequest)
+			return
+		}
 		if errors.Is(err, domain.ErrUserAlreadyExists) {
-			logger.Log.Info("login already exists", zap.String("login", body.Login))
+			log.Info("login already exists", zap.String("login", body.Login))
 			http.Error(rw, "login already exists", http.StatusConflict)
 			return
 		}
 		if err != nil {
-			logger.Log.Error("failed to register user", zap.Error(err))
+			log.Error("failed to register user", zap.Error(err))
 			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 			return
 		}
 
 		middleware.SetNewAuthCookies(registeredUser.ID, cfg.SecretKey, rw)
 		rw.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(rw).Encode(RegisterResponse{ReferralCode: registeredUser.ReferralCode}); err != nil {
+			log.Error("failed to encode response", zap.Error(err))
+		}
 	}
 }
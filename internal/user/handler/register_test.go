@@ -10,6 +10,7 @@ import (
 	"github.com/aifedorov/gophermart/internal/user/domain"
 	repository "github.com/aifedorov/gophermart/internal/user/repository/db"
 	userMocks "github.com/aifedorov/gophermart/internal/user/repository/mocks"
+	"github.com/google/uuid"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
@@ -49,6 +50,33 @@ func TestRegisterHandler(t *testing.T) {
 				contentType: "application/json",
 			},
 		},
+		{
+			name:   "register with referral code",
+			method: http.MethodPost,
+			path:   "/api/user/register",
+			body: `{
+				"login": "referredLogin",
+				"password": "test",
+				"referral_code": "abcd2345"
+			}`,
+			want: want{
+				statusCode:  http.StatusOK,
+				contentType: "application/json",
+			},
+		},
+		{
+			name:   "unknown referral code",
+			method: http.MethodPost,
+			path:   "/api/user/register",
+			body: `{
+				"login": "newLogin",
+				"password": "test",
+				"referral_code": "UNKNOWN1"
+			}`,
+			want: want{
+				statusCode: http.StatusBadRequest,
+			},
+		},
 		{
 			name:   "missing body",
 			method: http.MethodPost,
@@ -139,29 +167,45 @@ func TestRegisterHandler(t *testing.T) {
 
 func newMockStorageForRegister(ctrl *gomock.Controller) repository.Repository {
 	mockRepo := userMocks.NewMockRepository(ctrl)
+	referrerID := uuid.New()
 
 	mockRepo.EXPECT().
-		CreateUser("loginExists", gomock.Any()).
+		CreateUser(gomock.Any(), "loginExists", gomock.Any(), gomock.Any(), gomock.Any()).
 		Return(repository.User{}, repository.ErrUserAlreadyExists).
 		AnyTimes()
 
 	mockRepo.EXPECT().
-		CreateUser("newLogin", gomock.Any()).
+		CreateUser(gomock.Any(), "newLogin", gomock.Any(), gomock.Any(), uuid.NullUUID{}).
 		Return(repository.User{Username: "newLogin"}, nil).
 		AnyTimes()
 
 	mockRepo.EXPECT().
-		CreateUser("", gomock.Any()).
+		CreateUser(gomock.Any(), "referredLogin", gomock.Any(), gomock.Any(), uuid.NullUUID{UUID: referrerID, Valid: true}).
+		Return(repository.User{Username: "referredLogin"}, nil).
+		AnyTimes()
+
+	mockRepo.EXPECT().
+		GetUserByReferralCode(gomock.Any(), "ABCD2345").
+		Return(repository.User{ID: referrerID, Username: "referrer", ReferralCode: "ABCD2345"}, nil).
+		AnyTimes()
+
+	mockRepo.EXPECT().
+		GetUserByReferralCode(gomock.Any(), "UNKNOWN1").
+		Return(repository.User{}, repository.ErrUserNotFound).
+		AnyTimes()
+
+	mockRepo.EXPECT().
+		CreateUser(gomock.Any(), "", gomock.Any(), gomock.Any(), gomock.Any()).
 		Return(repository.User{}, domain.ErrNotFound).
 		AnyTimes()
 
 	mockRepo.EXPECT().
-		CreateUser(gomock.Any(), gomock.Any()).
+		CreateUser(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 		Return(repository.User{}, domain.ErrNotFound).
 		AnyTimes()
 
 	mockRepo.EXPECT().
-		CreateUser("test", gomock.Any()).
+		CreateUser(gomock.Any(), "test", gomock.Any(), gomock.Any(), gomock.Any()).
 		Return(repository.User{}, errors.New("internal error")).
 		AnyTimes()
 
@@ -5,4 +5,5 @@ import "errors"
 var (
 	ErrUserAlreadyExists = errors.New("user already exists")
 	ErrUserNotFound      = errors.New("user not found")
+	ErrReferralCodeTaken = errors.New("referral code is taken")
 )
@@ -14,4 +14,5 @@ type User struct {
 	Username     string
 	PasswordHash string
 	CreatedAt    pgtype.Timestamp
+	ReferralCode string
 }
@@ -12,30 +12,32 @@ import (
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (username, password_hash)
-VALUES ($1, $2)
-RETURNING id, username, password_hash, created_at
+INSERT INTO users (username, password_hash, referral_code)
+VALUES ($1, $2, $3)
+RETURNING id, username, password_hash, created_at, referral_code
 `
 
 type CreateUserParams struct {
 	Username     string
 	PasswordHash string
+	ReferralCode string
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRow(ctx, createUser, arg.Username, arg.PasswordHash)
+	row := q.db.QueryRow(ctx, createUser, arg.Username, arg.PasswordHash, arg.ReferralCode)
 	var i User
 	err := row.Scan(
 		&i.ID,
 		&i.Username,
 		&i.PasswordHash,
 		&i.CreatedAt,
+		&i.ReferralCode,
 	)
 	return i, err
 }
 
 const getUserByCredentials = `-- name: GetUserByCredentials :one
-SELECT id, username, password_hash, created_at
+SELECT id, username, password_hash, created_at, referral_code
 FROM users
 WHERE username = $1
   AND password_hash = $2
@@ -54,12 +56,13 @@ func (q *Queries) GetUserByCredentials(ctx context.Context, arg GetUserByCredent
 		&i.Username,
 		&i.PasswordHash,
 		&i.CreatedAt,
+		&i.ReferralCode,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, username, password_hash, created_at
+SELECT id, username, password_hash, created_at, referral_code
 FROM users
 WHERE id = $1
 `
@@ -72,12 +75,32 @@ func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Username,
 		&i.PasswordHash,
 		&i.CreatedAt,
+		&i.ReferralCode,
+	)
+	return i, err
+}
+
+const getUserByReferralCode = `-- name: GetUserByReferralCode :one
+SELECT id, username, password_hash, created_at, referral_code
+FROM users
+WHERE referral_code = $1
+`
+
+func (q *Queries) GetUserByReferralCode(ctx context.Context, referralCode string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByReferralCode, referralCode)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.PasswordHash,
+		&i.CreatedAt,
+		&i.ReferralCode,
 	)
 	return i, err
 }
 
 const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT id, username, password_hash, created_at
+SELECT id, username, password_hash, created_at, referral_code
 FROM users
 WHERE username = $1
 `
@@ -90,6 +113,7 @@ func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User,
 		&i.Username,
 		&i.PasswordHash,
 		&i.CreatedAt,
+		&i.ReferralCode,
 	)
 	return i, err
 }
@@ -4,50 +4,107 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"github.com/aifedorov/gophermart/internal/pkg/logger"
 	"github.com/google/uuid"
 	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Repository interface {
-	CreateUser(username, passwordHash string) (User, error)
-	GetUserByID(userID uuid.UUID) (User, error)
-	GetUserByUsername(username string) (User, error)
+	CreateUser(ctx context.Context, username, passwordHash, referralCode string, referrerID uuid.NullUUID) (User, error)
+	GetUserByID(ctx context.Context, userID uuid.UUID) (User, error)
+	GetUserByUsername(ctx context.Context, username string) (User, error)
+	GetUserByReferralCode(ctx context.Context, referralCode string) (User, error)
+}
+
+// ReferralWriter records that a user was referred. The referrals belong to the order repository,
+// which writes them in the transaction that creates the referred user.
+type ReferralWriter interface {
+	CreateReferral(ctx context.Context, tx pgx.Tx, referrerID, referredID uuid.UUID) error
 }
 
 type service struct {
-	ctx     context.Context
-	queries *Queries
+	queries   *Queries
+	pgpool    *pgxpool.Pool
+	referrals ReferralWriter
 }
 
-func NewRepository(ctx context.Context, db DBTX) Repository {
+func NewRepository(pgpool *pgxpool.Pool, referrals ReferralWriter) Repository {
 	return &service{
-		ctx:     ctx,
-		queries: New(db),
+		queries:   New(pgpool),
+		pgpool:    pgpool,
+		referrals: referrals,
 	}
 }
 
-func (s service) CreateUser(username, passwordHash string) (User, error) {
-	newUser, err := s.queries.CreateUser(
-		s.ctx,
+// referralCodeIndex is the unique index that keeps referral codes apart.
+const referralCodeIndex = "idx_users_referral_code"
+
+// CreateUser creates the user with its referral code. A valid referrerID also records the referral,
+// in the same transaction, so a user is never created without the referral it registered with.
+func (s service) CreateUser(ctx context.Context, username, passwordHash, referralCode string, referrerID uuid.NullUUID) (User, error) {
+	tx, err := s.pgpool.Begin(ctx)
+	if err != nil {
+		return User{}, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	newUser, err := s.queries.WithTx(tx).CreateUser(
+		ctx,
 		CreateUserParams{
-			username,
-			passwordHash,
+			Username:     username,
+			PasswordHash: passwordHash,
+			ReferralCode: referralCode,
 		},
 	)
 	var pgErr *pgconn.PgError
 	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+		if pgErr.ConstraintName == referralCodeIndex {
+			logger.FromContext(ctx).Debug("userrepository: referral code is taken")
+			return User{}, ErrReferralCodeTaken
+		}
+		logger.FromContext(ctx).Debug("userrepository: username is taken")
 		return User{}, ErrUserAlreadyExists
 	}
+	if err != nil {
+		return User{}, err
+	}
+
+	if referrerID.Valid {
+		err = s.referrals.CreateReferral(ctx, tx, referrerID.UUID, newUser.ID)
+		if err != nil {
+			return User{}, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return User{}, err
+	}
 	return newUser, nil
 }
 
-func (s service) GetUserByID(userID uuid.UUID) (User, error) {
-	return s.queries.GetUserByID(s.ctx, userID)
+func (s service) GetUserByID(ctx context.Context, userID uuid.UUID) (User, error) {
+	return s.queries.GetUserByID(ctx, userID)
+}
+
+func (s service) GetUserByUsername(ctx context.Context, username string) (User, error) {
+	user, err := s.queries.GetUserByUsername(ctx, username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, ErrUserNotFound
+	}
+	if err != nil {
+		return User{}, err
+	}
+	return user, nil
 }
 
-func (s service) GetUserByUsername(username string) (User, error) {
-	user, err := s.queries.GetUserByUsername(s.ctx, username)
+func (s service) GetUserByReferralCode(ctx context.Context, referralCode string) (User, error) {
+	user, err := s.queries.GetUserByReferralCode(ctx, referralCode)
 	if errors.Is(err, sql.ErrNoRows) {
 		return User{}, ErrUserNotFound
 	}
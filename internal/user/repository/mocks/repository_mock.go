@@ -10,10 +10,12 @@
 package mock_user
 
 import (
+	context "context"
 	reflect "reflect"
 
 	repository "github.com/aifedorov/gophermart/internal/user/repository/db"
 	uuid "github.com/google/uuid"
+	pgx "github.com/jackc/pgx/v5"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -42,46 +44,99 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 }
 
 // CreateUser mocks base method.
-func (m *MockRepository) CreateUser(username, passwordHash string) (repository.User, error) {
+func (m *MockRepository) CreateUser(ctx context.Context, username, passwordHash, referralCode string, referrerID uuid.NullUUID) (repository.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", username, passwordHash)
+	ret := m.ctrl.Call(m, "CreateUser", ctx, username, passwordHash, referralCode, referrerID)
 	ret0, _ := ret[0].(repository.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateUser indicates an expected call of CreateUser.
-func (mr *MockRepositoryMockRecorder) CreateUser(username, passwordHash any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) CreateUser(ctx, username, passwordHash, referralCode, referrerID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockRepository)(nil).CreateUser), username, passwordHash)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockRepository)(nil).CreateUser), ctx, username, passwordHash, referralCode, referrerID)
 }
 
 // GetUserByID mocks base method.
-func (m *MockRepository) GetUserByID(userID uuid.UUID) (repository.User, error) {
+func (m *MockRepository) GetUserByID(ctx context.Context, userID uuid.UUID) (repository.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserByID", userID)
+	ret := m.ctrl.Call(m, "GetUserByID", ctx, userID)
 	ret0, _ := ret[0].(repository.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUserByID indicates an expected call of GetUserByID.
-func (mr *MockRepositoryMockRecorder) GetUserByID(userID any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetUserByID(ctx, userID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockRepository)(nil).GetUserByID), userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockRepository)(nil).GetUserByID), ctx, userID)
+}
+
+// GetUserByReferralCode mocks base method.
+func (m *MockRepository) GetUserByReferralCode(ctx context.Context, referralCode string) (repository.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByReferralCode", ctx, referralCode)
+	ret0, _ := ret[0].(repository.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByReferralCode indicates an expected call of GetUserByReferralCode.
+func (mr *MockRepositoryMockRecorder) GetUserByReferralCode(ctx, referralCode any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByReferralCode", reflect.TypeOf((*MockRepository)(nil).GetUserByReferralCode), ctx, referralCode)
 }
 
 // GetUserByUsername mocks base method.
-func (m *MockRepository) GetUserByUsername(username string) (repository.User, error) {
+func (m *MockRepository) GetUserByUsername(ctx context.Context, username string) (repository.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserByUsername", username)
+	ret := m.ctrl.Call(m, "GetUserByUsername", ctx, username)
 	ret0, _ := ret[0].(repository.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUserByUsername indicates an expected call of GetUserByUsername.
-func (mr *MockRepositoryMockRecorder) GetUserByUsername(username any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetUserByUsername(ctx, username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUsername", reflect.TypeOf((*MockRepository)(nil).GetUserByUsername), ctx, username)
+}
+
+// MockReferralWriter is a mock of ReferralWriter interface.
+type MockReferralWriter struct {
+	ctrl     *gomock.Controller
+	recorder *MockReferralWriterMockRecorder
+	isgomock struct{}
+}
+
+// MockReferralWriterMockRecorder is the mock recorder for MockReferralWriter.
+type MockReferralWriterMockRecorder struct {
+	mock *MockReferralWriter
+}
+
+// NewMockReferralWriter creates a new mock instance.
+func NewMockReferralWriter(ctrl *gomock.Controller) *MockReferralWriter {
+	mock := &MockReferralWriter{ctrl: ctrl}
+	mock.recorder = &MockReferralWriterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReferralWriter) EXPECT() *MockReferralWriterMockRecorder {
+	return m.recorder
+}
+
+// CreateReferral mocks base method.
+func (m *MockReferralWriter) CreateReferral(ctx context.Context, tx pgx.Tx, referrerID, referredID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReferral", ctx, tx, referrerID, referredID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateReferral indicates an expected call of CreateReferral.
+func (mr *MockReferralWriterMockRecorder) CreateReferral(ctx, tx, referrerID, referredID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUsername", reflect.TypeOf((*MockRepository)(nil).GetUserByUsername), username)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReferral", reflect.TypeOf((*MockReferralWriter)(nil).CreateReferral), ctx, tx, referrerID, referredID)
 }
@@ -14,7 +14,12 @@ SELECT *
 FROM users
 WHERE username = $1;
 
+-- name: GetUserByReferralCode :one
+SELECT *
+FROM users
+WHERE referral_code = $1;
+
 -- name: CreateUser :one
-INSERT INTO users (username, password_hash)
-VALUES ($1, $2)
+INSERT INTO users (username, password_hash, referral_code)
+VALUES ($1, $2, $3)
 RETURNING *;
\ No newline at end of file
@@ -3,7 +3,9 @@ CREATE TABLE users
     id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     username      VARCHAR(255) UNIQUE NOT NULL,
     password_hash VARCHAR(255)        NOT NULL,
-    created_at    TIMESTAMP        DEFAULT CURRENT_TIMESTAMP
+    created_at    TIMESTAMP        DEFAULT CURRENT_TIMESTAMP,
+    referral_code TEXT                NOT NULL
 );
 
-CREATE INDEX IF NOT EXISTS idx_users_login ON users (username);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_users_login ON users (username);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_referral_code ON users (referral_code);
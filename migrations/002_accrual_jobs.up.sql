@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS accrual_jobs
+(
+    id           UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    order_id     UUID                     NOT NULL UNIQUE REFERENCES orders (id) ON DELETE CASCADE,
+    order_number TEXT                     NOT NULL,
+    attempts     INTEGER                  NOT NULL DEFAULT 0,
+    next_run_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error   TEXT,
+    locked_by    TEXT,
+    locked_until TIMESTAMP WITH TIME ZONE,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_accrual_jobs_next_run_at ON accrual_jobs (next_run_at);
+
+INSERT INTO accrual_jobs (order_id, order_number)
+SELECT id, number
+FROM orders
+WHERE type = 'CREDIT'
+  AND status IN ('NEW', 'PROCESSING')
+ON CONFLICT (order_id) DO NOTHING;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_order_status_transitions_order_id;
+DROP TABLE IF EXISTS order_status_transitions;
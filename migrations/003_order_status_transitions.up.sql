@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS order_status_transitions
+(
+    id          UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    order_id    UUID                     NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    from_status OrderStatus,
+    to_status   OrderStatus              NOT NULL,
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_status_transitions_order_id ON order_status_transitions (order_id);
+
+INSERT INTO order_status_transitions (order_id, from_status, to_status, created_at)
+SELECT id, NULL, status, COALESCE(created_at, CURRENT_TIMESTAMP)
+FROM orders
+WHERE type = 'CREDIT';
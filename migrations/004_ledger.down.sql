@@ -0,0 +1,7 @@
+DROP VIEW IF EXISTS user_balance_audit;
+DROP TABLE IF EXISTS user_balances;
+DROP INDEX IF EXISTS idx_ledger_entries_reference;
+DROP INDEX IF EXISTS idx_ledger_entries_transaction_id;
+DROP INDEX IF EXISTS idx_ledger_entries_account;
+DROP TABLE IF EXISTS ledger_entries;
+DROP TYPE IF EXISTS LedgerDirection;
@@ -0,0 +1,67 @@
+CREATE TYPE LedgerDirection AS ENUM ('DEBIT', 'CREDIT');
+
+-- Every balance change is a transaction of two entries with the same transaction_id:
+-- a DEBIT from one account and a CREDIT to another. User accounts are named
+-- 'user:<user id>', counterparties are 'system:accrual' and 'system:withdrawals'.
+CREATE TABLE IF NOT EXISTS ledger_entries
+(
+    id             UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    transaction_id UUID                     NOT NULL,
+    account        TEXT                     NOT NULL,
+    direction      LedgerDirection          NOT NULL,
+    amount         NUMERIC(10, 2)           NOT NULL CHECK (amount > 0),
+    reference      TEXT                     NOT NULL,
+    created_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_account ON ledger_entries (account, created_at);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_transaction_id ON ledger_entries (transaction_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_reference ON ledger_entries (reference);
+
+CREATE TABLE IF NOT EXISTS user_balances
+(
+    user_id    UUID PRIMARY KEY         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    current    NUMERIC(12, 2)           NOT NULL DEFAULT 0 CHECK (current >= 0),
+    withdrawn  NUMERIC(12, 2)           NOT NULL DEFAULT 0 CHECK (withdrawn >= 0),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO ledger_entries (transaction_id, account, direction, amount, reference, created_at)
+SELECT o.id, e.account, e.direction::LedgerDirection, o.amount, o.number, COALESCE(o.processed_at, o.created_at, CURRENT_TIMESTAMP)
+FROM orders o
+         CROSS JOIN LATERAL (VALUES ('system:accrual', 'DEBIT'),
+                                    ('user:' || o.user_id, 'CREDIT')) AS e(account, direction)
+WHERE o.type = 'CREDIT'
+  AND o.status = 'PROCESSED'
+  AND o.amount > 0;
+
+INSERT INTO ledger_entries (transaction_id, account, direction, amount, reference, created_at)
+SELECT o.id, e.account, e.direction::LedgerDirection, o.amount, o.number, COALESCE(o.processed_at, o.created_at, CURRENT_TIMESTAMP)
+FROM orders o
+         CROSS JOIN LATERAL (VALUES ('user:' || o.user_id, 'DEBIT'),
+                                    ('system:withdrawals', 'CREDIT')) AS e(account, direction)
+WHERE o.type = 'DEBIT'
+  AND o.status = 'PROCESSED'
+  AND o.amount > 0;
+
+INSERT INTO user_balances (user_id, current, withdrawn)
+SELECT user_id,
+       SUM(CASE type WHEN 'CREDIT' THEN amount ELSE -amount END),
+       SUM(CASE type WHEN 'DEBIT' THEN amount ELSE 0 END)
+FROM orders
+WHERE status = 'PROCESSED'
+GROUP BY user_id;
+
+-- user_balance_audit recomputes every materialized balance from the ledger.
+-- Rows where current <> ledger_current or withdrawn <> ledger_withdrawn need attention.
+CREATE OR REPLACE VIEW user_balance_audit AS
+SELECT b.user_id,
+       b.current,
+       b.withdrawn,
+       COALESCE(SUM(CASE e.direction WHEN 'CREDIT' THEN e.amount ELSE -e.amount END), 0) AS ledger_current,
+       COALESCE(SUM(e.amount) FILTER (WHERE e.direction = 'DEBIT' AND c.account = 'system:withdrawals'),
+                0)                                                                      AS ledger_withdrawn
+FROM user_balances b
+         LEFT JOIN ledger_entries e ON e.account = 'user:' || b.user_id
+         LEFT JOIN ledger_entries c ON c.transaction_id = e.transaction_id AND c.account <> e.account
+GROUP BY b.user_id, b.current, b.withdrawn;
@@ -0,0 +1,21 @@
+DROP VIEW IF EXISTS user_balance_audit;
+
+CREATE VIEW user_balance_audit AS
+SELECT b.user_id,
+       b.current,
+       b.withdrawn,
+       COALESCE(SUM(CASE e.direction WHEN 'CREDIT' THEN e.amount ELSE -e.amount END), 0) AS ledger_current,
+       COALESCE(SUM(e.amount) FILTER (WHERE e.direction = 'DEBIT' AND c.account = 'system:withdrawals'),
+                0)                                                                      AS ledger_withdrawn
+FROM user_balances b
+         LEFT JOIN ledger_entries e ON e.account = 'user:' || b.user_id
+         LEFT JOIN ledger_entries c ON c.transaction_id = e.transaction_id AND c.account <> e.account
+GROUP BY b.user_id, b.current, b.withdrawn;
+
+ALTER TABLE user_balances
+    DROP COLUMN IF EXISTS held;
+
+DROP INDEX IF EXISTS idx_balance_holds_active_expires_at;
+DROP INDEX IF EXISTS idx_balance_holds_user_id;
+DROP TABLE IF EXISTS balance_holds;
+DROP TYPE IF EXISTS HoldStatus;
@@ -0,0 +1,44 @@
+CREATE TYPE HoldStatus AS ENUM ('ACTIVE', 'CAPTURED', 'VOIDED', 'EXPIRED');
+
+-- A hold moves points from 'user:<user id>' to 'hold:<user id>'. Capturing it moves them on
+-- to 'system:withdrawals', voiding or expiring it moves them back to the user account.
+CREATE TABLE IF NOT EXISTS balance_holds
+(
+    id           UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    user_id      UUID                     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    order_number VARCHAR(255) UNIQUE      NOT NULL,
+    amount       NUMERIC(10, 2)           NOT NULL CHECK (amount > 0),
+    status       HoldStatus               NOT NULL DEFAULT 'ACTIVE',
+    expires_at   TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_balance_holds_user_id ON balance_holds (user_id);
+CREATE INDEX IF NOT EXISTS idx_balance_holds_active_expires_at ON balance_holds (expires_at) WHERE status = 'ACTIVE';
+
+ALTER TABLE user_balances
+    ADD COLUMN IF NOT EXISTS held NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (held >= 0);
+
+DROP VIEW IF EXISTS user_balance_audit;
+
+-- user_balance_audit recomputes every materialized balance from the ledger.
+-- Rows where a materialized column differs from its ledger_ counterpart need attention.
+CREATE VIEW user_balance_audit AS
+SELECT b.user_id,
+       b.current,
+       b.held,
+       b.withdrawn,
+       COALESCE((SELECT SUM(CASE e.direction WHEN 'CREDIT' THEN e.amount ELSE -e.amount END)
+                 FROM ledger_entries e
+                 WHERE e.account = 'user:' || b.user_id), 0)  AS ledger_current,
+       COALESCE((SELECT SUM(CASE e.direction WHEN 'CREDIT' THEN e.amount ELSE -e.amount END)
+                 FROM ledger_entries e
+                 WHERE e.account = 'hold:' || b.user_id), 0)  AS ledger_held,
+       COALESCE((SELECT SUM(e.amount)
+                 FROM ledger_entries e
+                          JOIN ledger_entries c
+                               ON c.transaction_id = e.transaction_id AND c.account = 'system:withdrawals'
+                 WHERE e.account IN ('user:' || b.user_id, 'hold:' || b.user_id)
+                   AND e.direction = 'DEBIT'), 0)             AS ledger_withdrawn
+FROM user_balances b;
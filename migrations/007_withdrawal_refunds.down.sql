@@ -0,0 +1,25 @@
+DROP VIEW IF EXISTS user_balance_audit;
+
+-- user_balance_audit recomputes every materialized balance from the ledger.
+-- Rows where a materialized column differs from its ledger_ counterpart need attention.
+CREATE VIEW user_balance_audit AS
+SELECT b.user_id,
+       b.current,
+       b.held,
+       b.withdrawn,
+       COALESCE((SELECT SUM(CASE e.direction WHEN 'CREDIT' THEN e.amount ELSE -e.amount END)
+                 FROM ledger_entries e
+                 WHERE e.account = 'user:' || b.user_id), 0)  AS ledger_current,
+       COALESCE((SELECT SUM(CASE e.direction WHEN 'CREDIT' THEN e.amount ELSE -e.amount END)
+                 FROM ledger_entries e
+                 WHERE e.account = 'hold:' || b.user_id), 0)  AS ledger_held,
+       COALESCE((SELECT SUM(e.amount)
+                 FROM ledger_entries e
+                          JOIN ledger_entries c
+                               ON c.transaction_id = e.transaction_id AND c.account = 'system:withdrawals'
+                 WHERE e.account IN ('user:' || b.user_id, 'hold:' || b.user_id)
+                   AND e.direction = 'DEBIT'), 0)             AS ledger_withdrawn
+FROM user_balances b;
+
+DROP INDEX IF EXISTS idx_withdrawal_refunds_withdrawal_id;
+DROP TABLE IF EXISTS withdrawal_refunds;
@@ -0,0 +1,34 @@
+-- A refund is a compensating ledger transaction from 'system:withdrawals' back to the
+-- user account, linked to the withdrawal it reverses. Refunds of one withdrawal never
+-- exceed its sum.
+CREATE TABLE IF NOT EXISTS withdrawal_refunds
+(
+    id            UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    withdrawal_id UUID                     NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    amount        NUMERIC(10, 2)           NOT NULL CHECK (amount > 0),
+    created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_withdrawal_refunds_withdrawal_id ON withdrawal_refunds (withdrawal_id);
+
+DROP VIEW IF EXISTS user_balance_audit;
+
+-- user_balance_audit recomputes every materialized balance from the ledger.
+-- Rows where a materialized column differs from its ledger_ counterpart need attention.
+CREATE VIEW user_balance_audit AS
+SELECT b.user_id,
+       b.current,
+       b.held,
+       b.withdrawn,
+       COALESCE((SELECT SUM(CASE e.direction WHEN 'CREDIT' THEN e.amount ELSE -e.amount END)
+                 FROM ledger_entries e
+                 WHERE e.account = 'user:' || b.user_id), 0)  AS ledger_current,
+       COALESCE((SELECT SUM(CASE e.direction WHEN 'CREDIT' THEN e.amount ELSE -e.amount END)
+                 FROM ledger_entries e
+                 WHERE e.account = 'hold:' || b.user_id), 0)  AS ledger_held,
+       COALESCE((SELECT SUM(CASE e.direction WHEN 'DEBIT' THEN e.amount ELSE -e.amount END)
+                 FROM ledger_entries e
+                          JOIN ledger_entries c
+                               ON c.transaction_id = e.transaction_id AND c.account = 'system:withdrawals'
+                 WHERE e.account IN ('user:' || b.user_id, 'hold:' || b.user_id)), 0) AS ledger_withdrawn
+FROM user_balances b;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_point_lot_allocations_reference;
+DROP TABLE IF EXISTS point_lot_allocations;
+DROP INDEX IF EXISTS idx_point_lots_expires_at;
+DROP INDEX IF EXISTS idx_point_lots_user_id_expires_at;
+DROP TABLE IF EXISTS point_lots;
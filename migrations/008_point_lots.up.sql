@@ -0,0 +1,33 @@
+-- Every accrual becomes a lot that can be spent until expires_at (NULL never expires).
+-- Spending consumes the lots that expire first and records an allocation per lot, so a
+-- refund or a voided hold can put the points back into the same lots.
+CREATE TABLE IF NOT EXISTS point_lots
+(
+    id         UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    user_id    UUID                     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    reference  TEXT                     NOT NULL,
+    amount     NUMERIC(12, 2)           NOT NULL CHECK (amount > 0),
+    remaining  NUMERIC(12, 2)           NOT NULL CHECK (remaining >= 0 AND remaining <= amount),
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_point_lots_user_id_expires_at ON point_lots (user_id, expires_at) WHERE remaining > 0;
+CREATE INDEX IF NOT EXISTS idx_point_lots_expires_at ON point_lots (expires_at) WHERE remaining > 0;
+
+CREATE TABLE IF NOT EXISTS point_lot_allocations
+(
+    id         UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    lot_id     UUID                     NOT NULL REFERENCES point_lots (id) ON DELETE CASCADE,
+    reference  TEXT                     NOT NULL,
+    amount     NUMERIC(12, 2)           NOT NULL CHECK (amount >= 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_point_lot_allocations_reference ON point_lot_allocations (reference);
+
+-- Points earned before lots existed are kept in one lot per user that never expires.
+INSERT INTO point_lots (user_id, reference, amount, remaining)
+SELECT user_id, 'migration', current, current
+FROM user_balances
+WHERE current > 0;
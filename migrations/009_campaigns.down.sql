@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_order_campaign_bonuses_campaign_id;
+DROP TABLE IF EXISTS order_campaign_bonuses;
+DROP TABLE IF EXISTS user_cohorts;
+DROP INDEX IF EXISTS idx_campaigns_window;
+DROP TABLE IF EXISTS campaigns;
@@ -0,0 +1,44 @@
+-- A campaign adds points on top of the accrual of orders processed between starts_at and
+-- ends_at (NULL runs forever). The bonus is accrual * (multiplier - 1) plus the fixed bonus.
+-- days_of_week limits the campaign to some weekdays in UTC, 0 is Sunday; NULL means every day.
+-- A campaign with a cohort only applies to the users listed in user_cohorts under that name.
+-- Once spent reaches budget the campaign stops paying; a NULL budget is unlimited.
+CREATE TABLE IF NOT EXISTS campaigns
+(
+    id               UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    name             TEXT                     NOT NULL,
+    starts_at        TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at          TIMESTAMP WITH TIME ZONE,
+    days_of_week     SMALLINT[],
+    first_order_only BOOLEAN                  NOT NULL DEFAULT FALSE,
+    cohort           TEXT,
+    multiplier       NUMERIC(6, 2)            NOT NULL DEFAULT 1 CHECK (multiplier >= 1),
+    bonus            NUMERIC(10, 2)           NOT NULL DEFAULT 0 CHECK (bonus >= 0),
+    budget           NUMERIC(12, 2) CHECK (budget >= 0),
+    spent            NUMERIC(12, 2)           NOT NULL DEFAULT 0 CHECK (spent >= 0 AND spent <= COALESCE(budget, spent)),
+    created_at       TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_at IS NULL OR ends_at > starts_at),
+    CHECK (multiplier > 1 OR bonus > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_campaigns_window ON campaigns (starts_at, ends_at);
+
+CREATE TABLE IF NOT EXISTS user_cohorts
+(
+    cohort  TEXT NOT NULL,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    PRIMARY KEY (cohort, user_id)
+);
+
+-- order_campaign_bonuses is the breakdown of the campaign points credited with an order.
+CREATE TABLE IF NOT EXISTS order_campaign_bonuses
+(
+    id          UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    order_id    UUID                     NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    campaign_id UUID                     NOT NULL REFERENCES campaigns (id),
+    amount      NUMERIC(10, 2)           NOT NULL CHECK (amount > 0),
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (order_id, campaign_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_campaign_bonuses_campaign_id ON order_campaign_bonuses (campaign_id);
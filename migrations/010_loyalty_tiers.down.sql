@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_orders_user_id_processed_at;
+DROP TABLE IF EXISTS order_tier_bonuses;
+DROP INDEX IF EXISTS idx_user_tier_history_user_id;
+DROP TABLE IF EXISTS user_tier_history;
+DROP TABLE IF EXISTS user_tiers;
@@ -0,0 +1,35 @@
+-- user_tiers keeps the tier the recalculation job assigned to every user, together with
+-- the benefits of that tier at the time, so accruals and withdrawals do not need the config.
+-- A withdrawal_limit of 0 is unlimited, otherwise it caps the points withdrawn or held per 24 hours.
+CREATE TABLE IF NOT EXISTS user_tiers
+(
+    user_id            UUID PRIMARY KEY         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    tier               TEXT                     NOT NULL,
+    volume             NUMERIC(12, 2)           NOT NULL DEFAULT 0,
+    accrual_multiplier NUMERIC(6, 2)            NOT NULL DEFAULT 1 CHECK (accrual_multiplier >= 1),
+    withdrawal_limit   NUMERIC(12, 2)           NOT NULL DEFAULT 0 CHECK (withdrawal_limit >= 0),
+    updated_at         TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS user_tier_history
+(
+    id         UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    user_id    UUID                     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    from_tier  TEXT,
+    to_tier    TEXT                     NOT NULL,
+    volume     NUMERIC(12, 2)           NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_tier_history_user_id ON user_tier_history (user_id, created_at);
+
+-- order_tier_bonuses records the points the tier multiplier added to a processed order.
+CREATE TABLE IF NOT EXISTS order_tier_bonuses
+(
+    order_id   UUID PRIMARY KEY         NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    tier       TEXT                     NOT NULL,
+    amount     NUMERIC(10, 2)           NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_user_id_processed_at ON orders (user_id, processed_at) WHERE status = 'PROCESSED';
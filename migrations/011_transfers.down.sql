@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_transfers_recipient_id;
+DROP INDEX IF EXISTS idx_transfers_sender_id;
+DROP TABLE IF EXISTS transfers;
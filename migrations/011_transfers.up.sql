@@ -0,0 +1,14 @@
+-- A transfer moves points from one user to another. Its ledger transaction goes from the
+-- sender's account straight to the recipient's, referenced as 'transfer:<transfer id>'.
+CREATE TABLE IF NOT EXISTS transfers
+(
+    id           UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    sender_id    UUID                     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    recipient_id UUID                     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    amount       NUMERIC(10, 2)           NOT NULL CHECK (amount > 0),
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (sender_id <> recipient_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transfers_sender_id ON transfers (sender_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_transfers_recipient_id ON transfers (recipient_id, created_at);
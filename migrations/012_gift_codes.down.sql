@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_gift_code_redemptions_user_id;
+DROP TABLE IF EXISTS gift_code_redemptions;
+DROP INDEX IF EXISTS idx_gift_codes_batch_id;
+DROP TABLE IF EXISTS gift_codes;
+DROP TABLE IF EXISTS gift_code_batches;
@@ -0,0 +1,36 @@
+-- A gift code batch is a set of codes issued together. Every code of the batch credits amount
+-- points on redemption, can be redeemed max_redemptions times in total and once per user,
+-- until expires_at (NULL never expires). A redemption is a ledger transaction from
+-- system:giftcodes to the user, referenced as 'giftcode:<redemption id>'.
+CREATE TABLE IF NOT EXISTS gift_code_batches
+(
+    id              UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    name            TEXT                     NOT NULL,
+    amount          NUMERIC(10, 2)           NOT NULL CHECK (amount > 0),
+    max_redemptions INTEGER                  NOT NULL CHECK (max_redemptions > 0),
+    expires_at      TIMESTAMP WITH TIME ZONE,
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS gift_codes
+(
+    id          UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    batch_id    UUID                     NOT NULL REFERENCES gift_code_batches (id) ON DELETE CASCADE,
+    code        TEXT                     NOT NULL UNIQUE,
+    redemptions INTEGER                  NOT NULL DEFAULT 0 CHECK (redemptions >= 0),
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_gift_codes_batch_id ON gift_codes (batch_id);
+
+CREATE TABLE IF NOT EXISTS gift_code_redemptions
+(
+    id           UUID PRIMARY KEY                  DEFAULT gen_random_uuid(),
+    gift_code_id UUID                     NOT NULL REFERENCES gift_codes (id) ON DELETE CASCADE,
+    user_id      UUID                     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    amount       NUMERIC(10, 2)           NOT NULL CHECK (amount > 0),
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (gift_code_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_gift_code_redemptions_user_id ON gift_code_redemptions (user_id);
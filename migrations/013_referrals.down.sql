@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_referrals_referrer_id;
+DROP TABLE IF EXISTS referrals;
+DROP TYPE IF EXISTS ReferralStatus;
+DROP INDEX IF EXISTS idx_users_referral_code;
+ALTER TABLE users
+    DROP COLUMN IF EXISTS referral_code;
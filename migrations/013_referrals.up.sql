@@ -0,0 +1,35 @@
+-- Every user gets a referral code at registration. Users registered before referrals existed
+-- get one derived from their id.
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS referral_code TEXT;
+
+UPDATE users
+SET referral_code = upper(substr(md5(id::TEXT), 1, 10))
+WHERE referral_code IS NULL;
+
+ALTER TABLE users
+    ALTER COLUMN referral_code SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_referral_code ON users (referral_code);
+
+CREATE TYPE ReferralStatus AS ENUM ('PENDING', 'REWARDED', 'REJECTED');
+
+-- A referral links a user to the user whose code they registered with. It stays PENDING until
+-- the first order of the referred user is processed, which either rewards both users or
+-- rejects the referral with a reason. Rewards are ledger transactions from system:referrals,
+-- referenced as 'referral:<referred user id>'.
+CREATE TABLE IF NOT EXISTS referrals
+(
+    referred_id     UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    referrer_id     UUID                     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status          ReferralStatus           NOT NULL DEFAULT 'PENDING',
+    reason          TEXT,
+    order_id        UUID REFERENCES orders (id) ON DELETE SET NULL,
+    referrer_reward NUMERIC(10, 2)           NOT NULL DEFAULT 0 CHECK (referrer_reward >= 0),
+    referred_reward NUMERIC(10, 2)           NOT NULL DEFAULT 0 CHECK (referred_reward >= 0),
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    settled_at      TIMESTAMP WITH TIME ZONE,
+    CHECK (referrer_id <> referred_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_referrals_referrer_id ON referrals (referrer_id, created_at);
@@ -0,0 +1,4 @@
+-- A key that is still in progress after locked_until was left by a request that crashed or
+-- timed out, and a retry may take it over. Existing in-progress keys are stale right away.
+ALTER TABLE idempotency_keys
+    ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP;
@@ -0,0 +1,4 @@
+-- token identifies the reservation that holds a key. A request that lost its key to a retry
+-- after the lease must not complete or release the key the retry now holds.
+ALTER TABLE idempotency_keys
+    ADD COLUMN IF NOT EXISTS token UUID NOT NULL DEFAULT gen_random_uuid();
@@ -0,0 +1,36 @@
+// Package migrations embeds the SQL migrations applied by golang-migrate,
+// so the service can tell which schema version it expects.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// LatestVersion returns the highest migration version shipped with the binary.
+func LatestVersion() (uint, error) {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint
+	for _, entry := range entries {
+		prefix, _, found := strings.Cut(entry.Name(), "_")
+		if !found {
+			return 0, fmt.Errorf("migrations: unexpected file name %q", entry.Name())
+		}
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("migrations: unexpected file name %q: %w", entry.Name(), err)
+		}
+		latest = max(latest, uint(version))
+	}
+	return latest, nil
+}